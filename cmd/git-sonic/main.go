@@ -16,11 +16,17 @@ import (
 	"git_sonic/internal/config"
 	server "git_sonic/internal/controller/http"
 	"git_sonic/internal/controller/webhook"
+	"git_sonic/internal/service/janitor"
+	"git_sonic/internal/service/poller"
 	"git_sonic/internal/service/queue"
+	"git_sonic/internal/service/scheduler"
 	"git_sonic/internal/service/workflow"
+	"git_sonic/pkg/agenttools"
 	"git_sonic/pkg/allowlist"
 	"git_sonic/pkg/github"
 	"git_sonic/pkg/gitutil"
+	"git_sonic/pkg/logging"
+	"git_sonic/pkg/redact"
 	"github.com/MimeLyc/agent-core-go/pkg/agent"
 	"github.com/MimeLyc/agent-core-go/pkg/llm"
 	"github.com/MimeLyc/agent-core-go/pkg/mcp"
@@ -30,6 +36,15 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "run-issue", "run-pr":
+			os.Exit(runCommand(os.Args[1], os.Args[2:]))
+		case "replay":
+			os.Exit(replayCommand(os.Args[2:]))
+		}
+	}
+
 	var printConfig bool
 	var outputFormat string
 	flag.BoolVar(&printConfig, "print-config", false, "print resolved configuration and exit")
@@ -40,6 +55,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("config error: %v", err)
 	}
+	redactLogs(cfg)
 	if printConfig {
 		if outputFormat == "json" {
 			enc := json.NewEncoder(os.Stdout)
@@ -57,72 +73,91 @@ func main() {
 		log.Fatalf("allowlist error: %v", err)
 	}
 
-	ghClient := github.NewClient("", cfg.GitHubToken)
-	gitClient := gitutil.Client{}
-	var llmRunner llm.Runner
-	var chatAgent agent.Agent
-
-	// Route based on agent type
-	switch cfg.AgentType {
-	case "api", "cli", "claude-code", "auto":
-		llmRunner, chatAgent = createUnifiedAgentRunner(cfg)
-		log.Printf("unified agent mode: type=%s provider=%s", cfg.AgentType, cfg.LLMProviderType)
-	default:
-		// Legacy path for backward compatibility
-		if cfg.AgentMode {
-			llmRunner = createAgentRunner(cfg)
-			log.Printf("agent mode enabled: max_iterations=%d tools_enabled=%v mcp_servers=%d",
-				cfg.AgentMaxIterations, cfg.ToolsEnabled, len(cfg.MCPServers))
-		} else if cfg.LLMAPIBaseURL != "" && cfg.LLMAPIKey != "" && cfg.LLMAPIModel != "" {
-			llmRunner = llm.APIRunner{
-				BaseURL:      cfg.LLMAPIBaseURL,
-				APIKey:       cfg.LLMAPIKey,
-				Model:        cfg.LLMAPIModel,
-				Path:         cfg.LLMAPIPath,
-				APIKeyHeader: cfg.LLMAPIKeyHeader,
-				APIKeyPrefix: cfg.LLMAPIKeyPrefix,
-				Timeout:      cfg.LLMTimeout,
-				MaxAttempts:  cfg.LLMAPIMaxAttempts,
-			}
-		} else {
-			llmRunner = llm.CommandRunner{Command: cfg.LLMCommand, Args: cfg.LLMArgs, Timeout: cfg.LLMTimeout}
-		}
+	ghClient := github.NewClient(cfg.GitHubAPIURL, cfg.GitHubToken)
+	cfg = resolveCommitAuthorID(context.Background(), cfg, ghClient)
+	cfg = resolveBotLogin(context.Background(), cfg, ghClient)
+	llmRunner, chatAgent := createRunner(cfg)
+	gitClient, removeKeyring, err := importSigningKey(context.Background(), cfg, newGitClient(cfg))
+	if err != nil {
+		log.Fatalf("commit signing error: %v", err)
 	}
-	engine := workflow.NewEngine(cfg, ghClient, gitClient, llmRunner)
+	defer removeKeyring()
+	engine := newEngine(cfg, ghClient, gitClient, llmRunner)
 
 	handler := func(ctx context.Context, job queue.Job) error {
 		event := job.Event
 		summary := eventSummary(event)
 		log.Printf("job start: %s", summary)
-		var err error
-		switch event.Type {
-		case webhook.EventIssues:
-			err = engine.HandleIssueLabel(ctx, event)
-		case webhook.EventIssueComment:
-			err = engine.HandleIssueComment(ctx, event)
-		case webhook.EventPRComment:
-			err = engine.HandlePRComment(ctx, event)
-		default:
-			err = nil
-		}
+		err := dispatchEvent(ctx, engine, event)
 		if err != nil {
 			// Use %+v to include stack trace for WorkflowError
 			log.Printf("job error: %s err=%+v", summary, err)
 		} else {
 			log.Printf("job done: %s", summary)
 		}
+		engine.ReportResult(ctx, event, err)
 		return err
 	}
 
 	q := queue.New(cfg.MaxWorkers, handler)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	// Cancel requests are handled when an event arrives rather than when it
+	// is enqueued, since a full queue would drop them.
+	cancelRuns := func(event webhook.Event) {
+		go engine.HandleCancel(ctx, event)
+	}
 	q.Start(ctx, cfg.MaxWorkers)
 
-	srv := server.New(cfg, ipAllowlist, q)
+	if cfg.WorkspaceGCInterval > 0 {
+		gc := janitor.New(cfg.RepoCloneBase, janitor.Policy{
+			KeepPerTarget: cfg.WorkspaceKeepPerTarget,
+			MaxAge:        cfg.WorkspaceMaxAge,
+			FailedMaxAge:  cfg.WorkspaceFailedMaxAge,
+			MaxTotalBytes: cfg.WorkspaceMaxBytes,
+			Outputs:       cfg.WorkspaceOutputs,
+			DryRun:        !cfg.WorkspaceGCDelete,
+		})
+		go gc.Run(ctx, cfg.WorkspaceGCInterval)
+		log.Printf("workspace janitor enabled: interval=%s keep_per_target=%d max_age=%s outputs=%s delete=%t",
+			cfg.WorkspaceGCInterval, cfg.WorkspaceKeepPerTarget, cfg.WorkspaceMaxAge, cfg.WorkspaceOutputs, cfg.WorkspaceGCDelete)
+	}
+
+	if len(cfg.ScheduledTasks) > 0 {
+		sched, err := scheduler.New(cfg.ScheduledTasks, q)
+		if err != nil {
+			log.Fatalf("scheduler error: %v", err)
+		}
+		go sched.Run(ctx)
+		log.Printf("scheduler enabled: tasks=%d", len(cfg.ScheduledTasks))
+	}
+
+	if cfg.PollInterval > 0 {
+		poll, err := poller.New(ghClient, q, poller.Options{
+			Repos:         cfg.PollRepos,
+			TriggerLabels: cfg.TriggerLabels,
+			Lookback:      cfg.PollLookback,
+			CursorFile:    cfg.PollCursorFile,
+		})
+		if err != nil {
+			log.Fatalf("poller error: %v", err)
+		}
+		go poll.WithCancelHook(cancelRuns).Run(ctx, cfg.PollInterval)
+		log.Printf("poll mode enabled: interval=%s repos=%v cursor_file=%s", cfg.PollInterval, cfg.PollRepos, cfg.PollCursorFile)
+	}
+
+	srv := server.New(cfg, ipAllowlist, q).WithCancelHook(cancelRuns)
 	if chatAgent != nil {
 		srv = srv.WithAgent(chatAgent)
 	}
+	if cfg.DeliveryStoreDir != "" {
+		store := webhook.NewStore(cfg.DeliveryStoreDir)
+		srv = srv.WithDeliveryStore(store)
+		if cfg.DeliveryRetention > 0 {
+			go pruneDeliveries(ctx, store, cfg.DeliveryRetention)
+		}
+		log.Printf("webhook deliveries stored in %s: retention=%s", cfg.DeliveryStoreDir, cfg.DeliveryRetention)
+	}
 	httpServer := &http.Server{
 		Addr:    cfg.ListenAddr,
 		Handler: srv.Handler(),
@@ -145,6 +180,26 @@ func main() {
 	q.Stop()
 }
 
+// pruneDeliveries removes stored deliveries older than retention immediately
+// and then hourly until ctx is done.
+func pruneDeliveries(ctx context.Context, store *webhook.Store, retention time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		removed, err := store.Prune(time.Now().Add(-retention))
+		if err != nil {
+			log.Printf("delivery pruning failed: %v", err)
+		} else if removed > 0 {
+			log.Printf("pruned %d stored deliveries older than %s", removed, retention)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 func eventSummary(event webhook.Event) string {
 	issue := ""
 	if event.Issue != nil {
@@ -175,8 +230,154 @@ func eventSummary(event webhook.Event) string {
 	return strings.Join(parts, " ")
 }
 
+// dispatchEvent routes an event to the engine handler for its type.
+func dispatchEvent(ctx context.Context, engine *workflow.Engine, event webhook.Event) error {
+	switch event.Type {
+	case webhook.EventIssues:
+		if event.Action == "opened" {
+			return engine.HandleIssueOpened(ctx, event)
+		}
+		return engine.HandleIssueLabel(ctx, event)
+	case webhook.EventIssueComment:
+		return engine.HandleIssueComment(ctx, event)
+	case webhook.EventPRComment:
+		return engine.HandlePRComment(ctx, event)
+	case webhook.EventPullRequest:
+		return engine.HandlePullRequest(ctx, event)
+	case webhook.EventSchedule:
+		return engine.HandleScheduledTask(ctx, event)
+	default:
+		return nil
+	}
+}
+
+// resolveCommitAuthorID looks up the commit author's account ID when the
+// commit email is derived from it, so that bot commits are attributed to the
+// app. Lookup failures keep the ID-less noreply address.
+func resolveCommitAuthorID(ctx context.Context, cfg config.Config, gh *github.Client) config.Config {
+	if cfg.CommitAuthorEmail != "" || cfg.CommitAuthorID != 0 {
+		return cfg
+	}
+	id, err := gh.GetUserID(ctx, cfg.CommitAuthorName)
+	if err != nil {
+		log.Printf("failed to look up the ID of %s, commits may not be attributed to it (set COMMIT_AUTHOR_ID): %v", cfg.CommitAuthorName, err)
+		return cfg
+	}
+	cfg.CommitAuthorID = id
+	return cfg
+}
+
+// resolveBotLogin sets BOT_LOGIN to the login the token belongs to when it is
+// not configured, so that plan comments posted with a personal access token
+// are recognized. App installation tokens cannot look themselves up; they
+// comment as the app's bot account, which COMMIT_AUTHOR_NAME names by default.
+func resolveBotLogin(ctx context.Context, cfg config.Config, gh *github.Client) config.Config {
+	if cfg.BotLogin != "" {
+		return cfg
+	}
+	login, err := gh.GetAuthenticatedLogin(ctx)
+	if err != nil || login == "" {
+		log.Printf("failed to look up the token's login, assuming %s (set BOT_LOGIN): %v", cfg.CommitAuthorName, err)
+		cfg.BotLogin = cfg.CommitAuthorName
+		return cfg
+	}
+	cfg.BotLogin = login
+	return cfg
+}
+
+// newGitClient creates the git client with the configured commit identity.
+func newGitClient(cfg config.Config) gitutil.Client {
+	return gitutil.Client{Commit: gitutil.CommitConfig{
+		AuthorName:    cfg.CommitAuthorName,
+		AuthorEmail:   cfg.CommitEmail(),
+		SignOff:       cfg.CommitSignOff,
+		SigningFormat: cfg.CommitSigningFormat,
+		SigningKey:    cfg.CommitSigningKey,
+	}}
+}
+
+// importSigningKey imports COMMIT_SIGNING_KEY_FILE into a private GnuPG home
+// and signs the git client's commits with it, using the imported key unless
+// COMMIT_SIGNING_KEY names another one. The returned cleanup removes the home.
+func importSigningKey(ctx context.Context, cfg config.Config, git gitutil.Client) (gitutil.Client, func(), error) {
+	if cfg.CommitSigningKeyFile == "" {
+		return git, func() {}, nil
+	}
+	home, err := os.MkdirTemp("", "git-sonic-gnupg-")
+	if err != nil {
+		return git, nil, err
+	}
+	cleanup := func() { os.RemoveAll(home) }
+	fingerprint, err := gitutil.ImportGPGKey(ctx, cfg.CommitSigningKeyFile, home)
+	if err != nil {
+		cleanup()
+		return git, nil, err
+	}
+	git.Commit.GPGHome = home
+	if git.Commit.SigningKey == "" {
+		git.Commit.SigningKey = fingerprint
+	}
+	log.Printf("commit signing key %s imported from %s", git.Commit.SigningKey, cfg.CommitSigningKeyFile)
+	return git, cleanup, nil
+}
+
+// newEngine creates the workflow engine, adding the read-only runner for
+// question answering and the dry-run runner when the agent supports them.
+func newEngine(cfg config.Config, gh workflow.GitHubClient, git workflow.GitClient, runner llm.Runner) *workflow.Engine {
+	engine := workflow.NewEngine(cfg, gh, git, runner)
+	if askRunner := createReadOnlyRunner(cfg); askRunner != nil {
+		engine = engine.WithReadOnlyRunner(askRunner)
+		log.Printf("read-only agent enabled for %s", cfg.AskCommand)
+	}
+	if dryRunRunner := createDryRunRunner(cfg); dryRunRunner != nil {
+		engine = engine.WithDryRunRunner(dryRunRunner)
+		log.Printf("dry-run agent enabled without GitHub API and MCP tools")
+	}
+	return engine
+}
+
+// redactLogs routes the process logs through a redactor for the configured
+// credentials.
+func redactLogs(cfg config.Config) {
+	redactor := redact.New(cfg.Secrets()...)
+	logging.SetOutput(redactor.Writer(os.Stdout))
+	log.SetOutput(redactor.Writer(os.Stderr))
+}
+
+// createRunner creates the LLM runner selected by the configuration. The
+// agent is returned for the chat endpoint when the unified agent is used.
+func createRunner(cfg config.Config) (llm.Runner, agent.Agent) {
+	// Route based on agent type
+	switch cfg.AgentType {
+	case "api", "cli", "claude-code", "auto":
+		llmRunner, chatAgent := createUnifiedAgentRunner(cfg, true)
+		log.Printf("unified agent mode: type=%s provider=%s", cfg.AgentType, cfg.LLMProviderType)
+		return llmRunner, chatAgent
+	}
+	// Legacy path for backward compatibility
+	if cfg.AgentMode {
+		log.Printf("agent mode enabled: max_iterations=%d tools_enabled=%v mcp_servers=%d",
+			cfg.AgentMaxIterations, cfg.ToolsEnabled, len(cfg.MCPServers))
+		return createAgentRunner(cfg, true), nil
+	}
+	if cfg.LLMAPIBaseURL != "" && cfg.LLMAPIKey != "" && cfg.LLMAPIModel != "" {
+		return llm.APIRunner{
+			BaseURL:      cfg.LLMAPIBaseURL,
+			APIKey:       cfg.LLMAPIKey,
+			Model:        cfg.LLMAPIModel,
+			Path:         cfg.LLMAPIPath,
+			APIKeyHeader: cfg.LLMAPIKeyHeader,
+			APIKeyPrefix: cfg.LLMAPIKeyPrefix,
+			Timeout:      cfg.LLMTimeout,
+			MaxAttempts:  cfg.LLMAPIMaxAttempts,
+		}, nil
+	}
+	return llm.CommandRunner{Command: cfg.LLMCommand, Args: cfg.LLMArgs, Timeout: cfg.LLMTimeout}, nil
+}
+
 // createAgentRunner creates an orchestrator-based LLM runner for agent mode.
-func createAgentRunner(cfg config.Config) llm.Runner {
+// githubTools adds the GitHub API tools to the built-in tools.
+func createAgentRunner(cfg config.Config, githubTools bool) llm.Runner {
 	log.Printf("[agent-init] creating agent runner: base_url=%s model=%s max_tokens=%d max_iterations=%d",
 		cfg.LLMAPIBaseURL, cfg.LLMAPIModel, cfg.AgentMaxTokens, cfg.AgentMaxIterations)
 
@@ -185,7 +386,7 @@ func createAgentRunner(cfg config.Config) llm.Runner {
 
 	// Register built-in tools if enabled
 	if cfg.ToolsEnabled {
-		builtin.RegisterAllWithGitHub(registry)
+		registerBuiltinTools(registry, githubTools)
 		log.Printf("[agent-init] registered %d built-in tools: %v", registry.Count(), registry.Names())
 	} else {
 		log.Printf("[agent-init] built-in tools disabled")
@@ -257,13 +458,13 @@ func createAgentRunner(cfg config.Config) llm.Runner {
 
 // createUnifiedAgentRunner creates an LLM runner using the unified agent interface.
 // It also returns the agent instance for direct use (e.g., chat endpoint).
-func createUnifiedAgentRunner(cfg config.Config) (llm.Runner, agent.Agent) {
+func createUnifiedAgentRunner(cfg config.Config, githubTools bool) (llm.Runner, agent.Agent) {
 	log.Printf("[agent-init] creating unified agent runner: type=%s", cfg.AgentType)
 
 	// Create tool registry for API agent
 	registry := tools.NewRegistry()
 	if cfg.ToolsEnabled {
-		builtin.RegisterAllWithGitHub(registry)
+		registerBuiltinTools(registry, githubTools)
 		log.Printf("[agent-init] registered %d built-in tools: %v", registry.Count(), registry.Names())
 	}
 
@@ -354,6 +555,87 @@ func createUnifiedAgentRunner(cfg config.Config) (llm.Runner, agent.Agent) {
 	return runner, ag
 }
 
+// registerBuiltinTools registers the built-in tools, with or without the
+// GitHub API tools.
+func registerBuiltinTools(registry *tools.Registry, githubTools bool) {
+	if githubTools {
+		builtin.RegisterAllWithGitHub(registry)
+		return
+	}
+	builtin.RegisterAll(registry)
+}
+
+// createDryRunRunner creates the runner used in dry-run mode. Its agent has
+// neither GitHub API tools nor MCP servers, which act on GitHub with their own
+// credentials. It returns nil when no repository runs dry or the agent's tools
+// cannot be restricted.
+func createDryRunRunner(cfg config.Config) llm.Runner {
+	dryRun := cfg.DryRun
+	for _, settings := range cfg.RepoSettings {
+		dryRun = dryRun || (settings.DryRun != nil && *settings.DryRun)
+	}
+	if !dryRun {
+		return nil
+	}
+	cfg.MCPServers = nil
+	switch {
+	case cfg.AgentType == "api" || cfg.AgentType == "auto":
+		runner, _ := createUnifiedAgentRunner(cfg, false)
+		return runner
+	case cfg.AgentType == "" && cfg.AgentMode:
+		return createAgentRunner(cfg, false)
+	}
+	return nil
+}
+
+// createReadOnlyRunner creates an API agent limited to read and search tools
+// for answering questions. It returns nil when the configured agent has no
+// tool registry to restrict, in which case ask commands are refused.
+func createReadOnlyRunner(cfg config.Config) llm.Runner {
+	apiAgent := cfg.AgentType == "api" || (cfg.AgentType == "" && cfg.AgentMode)
+	if !apiAgent || cfg.LLMAPIBaseURL == "" || cfg.LLMAPIKey == "" {
+		return nil
+	}
+
+	registry := tools.NewRegistry()
+	registry.MustRegister(builtin.ReadFileTool{})
+	registry.MustRegister(builtin.ListFilesTool{})
+	registry.MustRegister(builtin.GitStatusTool{})
+	registry.MustRegister(builtin.GitDiffTool{})
+	registry.MustRegister(builtin.GitLogTool{})
+	registry.MustRegister(agenttools.SearchTool{})
+	log.Printf("[agent-init] registered %d read-only tools: %v", registry.Count(), registry.Names())
+
+	ag, err := agent.NewAgent(agent.AgentConfig{
+		Type:     agent.AgentTypeAPI,
+		Registry: registry,
+		API: &agent.APIConfig{
+			ProviderType:  llm.LLMProviderType(cfg.LLMProviderType),
+			BaseURL:       cfg.LLMAPIBaseURL,
+			APIKey:        cfg.LLMAPIKey,
+			Model:         cfg.LLMAPIModel,
+			MaxTokens:     cfg.AgentMaxTokens,
+			Timeout:       cfg.LLMTimeout,
+			MaxAttempts:   cfg.LLMAPIMaxAttempts,
+			MaxIterations: cfg.AgentMaxIterations,
+			MaxMessages:   cfg.AgentMaxMessages,
+			SystemPrompt:  readOnlyAgentSystemPrompt,
+		},
+	})
+	if err != nil {
+		log.Printf("warning: failed to create read-only agent: %v", err)
+		return nil
+	}
+	return agent.NewRunnerAdapter(ag, readOnlyAgentSystemPrompt)
+}
+
+const readOnlyAgentSystemPrompt = `You are a read-only engineering assistant running in a repository workspace.
+Your current working directory is the repository root.
+You can only read, list and search files, and inspect git history. You cannot modify the repository.
+All file paths must be RELATIVE to the repository root.
+Answer the question in the task context, citing the files and line ranges you relied on.
+When complete, output a JSON object with decision 'proceed', the answer in 'answer' and 'summary', and a 'citations' array.`
+
 const defaultAgentSystemPrompt = `You are an autonomous engineering agent running in a repository workspace.
 Your current working directory is the repository root. All bash commands execute here.
 
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"git_sonic/internal/config"
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/pkg/github"
+)
+
+// replayCommand implements the replay subcommand, which re-runs a stored
+// webhook delivery in the foreground with the current configuration.
+func replayCommand(args []string) int {
+	var opts localOptions
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	opts.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: git-sonic replay <delivery-id|file.json> [flags]")
+		fs.PrintDefaults()
+	}
+	target, ok := parseWithTarget(fs, args)
+	if !ok {
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		return 1
+	}
+	redactLogs(cfg)
+	delivery, err := loadDelivery(cfg, target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		return 1
+	}
+	event, err := delivery.Event()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay: parse delivery %s: %v\n", delivery.ID, err)
+		return 1
+	}
+	event.DeliveryID = "replay-" + event.DeliveryID
+
+	engine, cleanup, err := opts.newEngine(cfg, github.NewClient(cfg.GitHubAPIURL, cfg.GitHubToken))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer cleanup()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return runResult(dispatchEvent(ctx, engine, event))
+}
+
+// loadDelivery reads target as a delivery file when it looks like a path and
+// otherwise looks it up by delivery ID in DELIVERY_STORE_DIR.
+func loadDelivery(cfg config.Config, target string) (webhook.Delivery, error) {
+	if strings.HasSuffix(target, ".json") || strings.ContainsAny(target, `/\`) {
+		return webhook.LoadDelivery(target)
+	}
+	if cfg.DeliveryStoreDir == "" {
+		return webhook.Delivery{}, fmt.Errorf("DELIVERY_STORE_DIR is not set; pass a delivery file instead of %q", target)
+	}
+	return webhook.NewStore(cfg.DeliveryStoreDir).Load(target)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"git_sonic/internal/config"
+	"git_sonic/internal/controller/webhook"
+)
+
+func TestLoadDeliveryByIDAndPath(t *testing.T) {
+	dir := t.TempDir()
+	header := http.Header{}
+	header.Set("X-GitHub-Event", "issues")
+	header.Set("X-GitHub-Delivery", "abc-123")
+	payload := `{"action":"labeled","label":{"name":"ai-ready"},"issue":{"number":7,"state":"open"},"repository":{"full_name":"org/repo"},"sender":{"login":"octocat"}}`
+	if err := webhook.NewStore(dir).Save(webhook.NewDelivery(header, []byte(payload), time.Now())); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	cfg := config.Config{DeliveryStoreDir: dir}
+	for _, target := range []string{"abc-123", filepath.Join(dir, "abc-123.json")} {
+		delivery, err := loadDelivery(cfg, target)
+		if err != nil {
+			t.Fatalf("loadDelivery(%q): %v", target, err)
+		}
+		if event, err := delivery.Event(); err != nil || event.Issue.Number != 7 || event.Label != "ai-ready" {
+			t.Fatalf("loadDelivery(%q) event = %+v, %v", target, event, err)
+		}
+	}
+
+	if _, err := loadDelivery(config.Config{}, "abc-123"); err == nil || !strings.Contains(err.Error(), "DELIVERY_STORE_DIR is not set") {
+		t.Fatalf("expected an error without a store, got %v", err)
+	}
+	if _, err := loadDelivery(cfg, "missing"); err == nil {
+		t.Fatal("expected an error for an unknown delivery")
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"git_sonic/internal/config"
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/internal/service/workflow"
+	"git_sonic/pkg/github"
+)
+
+// localOptions are the flags shared by the foreground subcommands.
+type localOptions struct {
+	dryRun        bool
+	keepWorkspace bool
+}
+
+func (o *localOptions) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.dryRun, "dry-run", false, "run the workflow without pushing, opening PRs or writing to GitHub")
+	fs.BoolVar(&o.keepWorkspace, "keep-workspace", false, "keep the workspace after the run and print its location")
+}
+
+// newEngine creates an engine that works in a temporary workspace. The
+// returned cleanup removes the workspace unless it is kept.
+func (o localOptions) newEngine(cfg config.Config, ghClient *github.Client) (*workflow.Engine, func(), error) {
+	workspace, err := os.MkdirTemp("", "git-sonic-run-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("create workspace: %w", err)
+	}
+	cfg.RepoCloneBase = workspace
+	cleanup := func() { os.RemoveAll(workspace) }
+	if o.keepWorkspace {
+		cleanup = func() { fmt.Fprintf(os.Stderr, "workspace kept at %s\n", workspace) }
+	}
+
+	if o.dryRun {
+		// --dry-run wins over per-repository dry_run settings.
+		cfg.DryRun = true
+		for name, settings := range cfg.RepoSettings {
+			settings.DryRun = nil
+			cfg.RepoSettings[name] = settings
+		}
+	}
+	cfg = resolveCommitAuthorID(context.Background(), cfg, ghClient)
+	cfg = resolveBotLogin(context.Background(), cfg, ghClient)
+	llmRunner, _ := createRunner(cfg)
+	gitClient, removeKeyring, err := importSigningKey(context.Background(), cfg, newGitClient(cfg))
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("commit signing: %w", err)
+	}
+	removeWorkspace := cleanup
+	cleanup = func() {
+		removeKeyring()
+		removeWorkspace()
+	}
+	return newEngine(cfg, ghClient, gitClient, llmRunner), cleanup, nil
+}
+
+// parseWithTarget parses flags placed before or after a single positional
+// argument and returns that argument.
+func parseWithTarget(fs *flag.FlagSet, args []string) (string, bool) {
+	if err := fs.Parse(args); err != nil {
+		return "", false
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return "", false
+	}
+	target := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return "", false
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return "", false
+	}
+	return target, true
+}
+
+// runCommand implements the run-issue and run-pr subcommands, which run a
+// single workflow in the foreground without the webhook server.
+func runCommand(name string, args []string) int {
+	var opts localOptions
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	opts.register(fs)
+	sender := fs.String("sender", "", "GitHub login to attribute the event to")
+	label := fs.String("label", "", "trigger label to simulate (run-issue; default: first TRIGGER_LABELS entry)")
+	command := fs.String("command", "", "comment to simulate, e.g. /ai-optimize (run-pr; default: first PR_SLASH_COMMANDS entry)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: git-sonic %s owner/repo#number [flags]\n", name)
+		fs.PrintDefaults()
+	}
+
+	target, ok := parseWithTarget(fs, args)
+	if !ok {
+		return 2
+	}
+	fullName, number, err := parseTarget(target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		return 1
+	}
+	redactLogs(cfg)
+	ghClient := github.NewClient(cfg.GitHubAPIURL, cfg.GitHubToken)
+	engine, cleanup, err := opts.newEngine(cfg, ghClient)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer cleanup()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	owner, repo, _ := strings.Cut(fullName, "/")
+	repoInfo, err := ghClient.GetRepo(ctx, owner, repo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "get repository %s: %v\n", fullName, err)
+		return 1
+	}
+	repository := webhook.Repository{FullName: fullName, CloneURL: repoInfo.CloneURL, DefaultBranch: repoInfo.DefaultBranch}
+
+	deliveryID := fmt.Sprintf("cli-%s-%s-%d", name, strings.ReplaceAll(fullName, "/", "-"), number)
+	switch name {
+	case "run-issue":
+		if *label == "" && len(cfg.TriggerLabels) > 0 {
+			*label = cfg.TriggerLabels[0]
+		}
+		issue, err := ghClient.GetIssue(ctx, owner, repo, number)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "get issue %s: %v\n", target, err)
+			return 1
+		}
+		err = engine.HandleIssueLabel(ctx, webhook.Event{
+			Type:       webhook.EventIssues,
+			Action:     "labeled",
+			DeliveryID: deliveryID,
+			Repository: repository,
+			Issue:      &webhook.Issue{Number: issue.Number, State: issue.State, Title: issue.Title, Body: issue.Body, Labels: issue.Labels},
+			Label:      *label,
+			Sender:     *sender,
+		})
+		return runResult(err)
+	default:
+		if *command == "" && len(cfg.PRSlashCommands) > 0 {
+			*command = cfg.PRSlashCommands[0]
+		}
+		pr, err := ghClient.GetPR(ctx, owner, repo, number)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "get pull request %s: %v\n", target, err)
+			return 1
+		}
+		err = engine.HandlePRComment(ctx, webhook.Event{
+			Type:        webhook.EventPRComment,
+			Action:      "created",
+			DeliveryID:  deliveryID,
+			Repository:  repository,
+			PullRequest: &webhook.PullRequest{Number: pr.Number, State: pr.State, Title: pr.Title, Body: pr.Body, HeadRef: pr.HeadRef, BaseRef: pr.BaseRef, Author: pr.Author, Draft: pr.Draft},
+			CommentBody: *command,
+			Sender:      *sender,
+		})
+		return runResult(err)
+	}
+}
+
+// parseTarget splits "owner/repo#number" into the repository and number.
+func parseTarget(target string) (string, int, error) {
+	fullName, num, ok := strings.Cut(target, "#")
+	owner, repo, hasSlash := strings.Cut(fullName, "/")
+	if !ok || !hasSlash || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", 0, fmt.Errorf("target must be owner/repo#number, got %q", target)
+	}
+	number, err := strconv.Atoi(num)
+	if err != nil || number < 1 {
+		return "", 0, fmt.Errorf("invalid issue number in %q", target)
+	}
+	return fullName, number, nil
+}
+
+func runResult(err error) int {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "run failed: %+v\n", err)
+		return 1
+	}
+	return 0
+}
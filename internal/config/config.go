@@ -2,10 +2,15 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
+	"git_sonic/pkg/policy"
 	"github.com/MimeLyc/agent-core-go/pkg/llm"
 )
 
@@ -15,21 +20,139 @@ type Config struct {
 	WebhookPath     string
 	IPAllowlist     string
 	GitHubToken     string
+	GitHubAPIURL    string
 	RepoCloneBase   string
 	MaxWorkers      int
 	TriggerLabels   []string
 	NeedsInfoLabel  string
 	InProgressLabel string
 	DoneLabel       string
+	FailedLabel     string
 	PRSlashCommands []string
 	LogLevel        string
 
+	// RequestCodeOwnerReviews requests CODEOWNERS of changed files as PR reviewers.
+	RequestCodeOwnerReviews bool
+	// MirrorCache keeps a bare mirror per repository under RepoCloneBase and
+	// creates workspaces from it instead of cloning from scratch.
+	MirrorCache bool
+	// CloneDepth limits workspace clone history (0 = full history).
+	CloneDepth int
+	// CloneFilter is a partial clone filter spec, e.g. "blob:none".
+	CloneFilter string
+
+	// PushMaxAttempts bounds rebase-and-push retries when a push is rejected.
+	PushMaxAttempts int
+	// ConflictResolutionLLM lets the LLM resolve rebase conflicts before pushing.
+	ConflictResolutionLLM bool
+
+	// Commit identity and signing. The author is also used as committer.
+	// An empty CommitAuthorEmail is derived from the name and CommitAuthorID
+	// (see CommitEmail).
+	CommitAuthorName  string
+	CommitAuthorEmail string
+	CommitAuthorID    int64
+	CommitSignOff     bool
+	// CommitCoAuthorRequester adds a Co-authored-by trailer for the issue author.
+	CommitCoAuthorRequester bool
+	// CommitSigningFormat is "gpg" or "ssh"; empty disables signing.
+	CommitSigningFormat string
+	// CommitSigningKey is a GPG key ID or a path to a mounted SSH key.
+	CommitSigningKey string
+	// CommitSigningKeyFile is an exported GPG secret key imported into a
+	// private GnuPG home at startup.
+	CommitSigningKeyFile string
+
+	// Workspace retention. The janitor runs every WorkspaceGCInterval (0 disables it)
+	// and only reports what it would expire unless WorkspaceGCDelete is set.
+	WorkspaceGCInterval    time.Duration
+	WorkspaceGCDelete      bool
+	WorkspaceKeepPerTarget int
+	WorkspaceMaxAge        time.Duration
+	WorkspaceFailedMaxAge  time.Duration
+	WorkspaceMaxBytes      int64
+	// WorkspaceOutputs is "keep", "archive" or "delete" for outputs/ of expired workspaces.
+	WorkspaceOutputs string
+
+	// BranchTemplate is a text/template for the branches automation pushes.
+	// Fields: .Kind ("issue", "follow-up" or "backport"), .Number, .Slug
+	// (slugified issue or PR title), .Sender, .Target (backport target) and
+	// .Timestamp. Left at the default, each kind keeps its own llm/ prefix.
+	BranchTemplate string
+	// StatusTemplate is a text/template rendering the Markdown of the status
+	// comment a run keeps up to date. Empty uses the built-in template.
+	StatusTemplate string
+	// JobDetailsURL is a text/template for the job details link in failure
+	// comments, e.g. a log search. Fields: .DeliveryID, .Repo, .Number and
+	// .Workspace (the workspace directory name).
+	JobDetailsURL string
+	// BackportCommand is the PR comment command that backports a merged PR
+	// to the release branches listed after it.
+	BackportCommand string
+	// PlanMode makes issue runs post an implementation plan first; changes are
+	// made only after an authorized user approves it with ApproveCommand.
+	PlanMode         bool
+	PlanPendingLabel string
+	ApproveCommand   string
+	// BotLogin is the GitHub login the bot comments as; only its plan
+	// comments can be approved. When unset it is resolved from the token at
+	// startup.
+	BotLogin string
+	// ReviewCommand is the PR comment command requesting an AI code review.
+	ReviewCommand string
+	// AskCommand is the issue/PR comment command asking a question about the
+	// codebase. Answers are produced by a read-only run and never push.
+	AskCommand string
+	// CancelCommand is the issue/PR comment command that cancels the run in
+	// flight on that issue or PR. It requires write access.
+	CancelCommand string
+	// TriageOnOpen runs the read-only triage workflow when an issue is opened.
+	TriageOnOpen bool
+	// TriageApplyLabels applies the suggested labels instead of only listing them.
+	TriageApplyLabels bool
+	// MaxChangedFiles and MaxChangedLines bound the size of a change; larger
+	// changes are not committed and the issue moves to needs-info (0 = no limit).
+	MaxChangedFiles int
+	MaxChangedLines int
+	// PolicyRules are the guardrail rules (see pkg/policy) checked before a
+	// change is committed; POLICY_RULES=none turns them all off.
+	// PolicyMaxFileBytes is the file_size limit.
+	PolicyRules        []string
+	PolicyMaxFileBytes int64
+	// DryRun runs workflows up to committing locally but records pushes and
+	// GitHub writes in the job's outputs instead of performing them.
+	DryRun bool
+	// BaseLabelPrefix marks issue labels selecting the base branch (e.g. "base:release-1.2").
+	BaseLabelPrefix string
+
+	// PollInterval enables poll mode: every interval, PollRepos are searched
+	// for trigger labels and new comments, which are enqueued like webhooks.
+	PollInterval time.Duration
+	PollRepos    []string
+	// PollLookback is how far back the first poll of a repository looks.
+	PollLookback time.Duration
+	// PollCursorFile persists the per-repository poll cursors.
+	PollCursorFile string
+
+	// DeliveryStoreDir persists raw webhook deliveries for replay (empty disables it).
+	DeliveryStoreDir string
+	// DeliveryRetention is how long stored deliveries are kept (0 keeps them forever).
+	DeliveryRetention time.Duration
+	// AdminToken enables the /admin endpoints for requests bearing it (empty disables them).
+	AdminToken string
+
+	// RepoSettings holds per-repository overrides keyed by "owner/repo".
+	RepoSettings map[string]RepoSettings
+	// ScheduledTasks are recurring maintenance tasks run by the built-in scheduler.
+	ScheduledTasks []ScheduledTask
+
 	// AI/LLM runtime configuration remains in reusable pkg/llm.
 	llm.RuntimeConfig
 }
 
 const (
 	defaultListenAddr      = ":8080"
+	defaultGitHubAPIURL    = "https://api.github.com"
 	defaultWebhookPath     = "/webhook"
 	defaultRepoBase        = "./workdir"
 	defaultMaxWorkers      = 2
@@ -37,10 +160,38 @@ const (
 	defaultNeedsInfoLabel  = "ai-needs-info"
 	defaultInProgressLabel = "ai-in-progress"
 	defaultDoneLabel       = "ai-done"
+	defaultFailedLabel     = "ai-failed"
+	defaultPlanLabel       = "ai-plan-pending"
+	defaultApproveCommand  = "/ai-approve"
 	defaultPRSlashCommands = "/ai-optimize"
+	defaultBackportCommand = "/ai-backport"
+	defaultReviewCommand   = "/ai-review"
+	defaultAskCommand      = "/ai-ask"
+	defaultCancelCommand   = "/ai-cancel"
 	defaultLogLevel        = "info"
+	defaultPolicyRules     = "binary,file_size,lockfile_only,test_deletion,new_dependency,secrets"
+
+	defaultPushMaxAttempts = 3
+	defaultPollLookback    = time.Hour
+
+	defaultBaseLabelPrefix = "base:"
+
+	defaultPolicyMaxFileBytes = 1 << 20
+
+	defaultCommitAuthorName = "git-sonic[bot]"
+
+	defaultWorkspaceGCInterval    = time.Hour
+	defaultWorkspaceKeepPerTarget = 3
+	defaultWorkspaceMaxAge        = 7 * 24 * time.Hour
+	defaultWorkspaceFailedMaxAge  = 14 * 24 * time.Hour
+	defaultWorkspaceOutputs       = "keep"
+
+	defaultDeliveryRetention = 7 * 24 * time.Hour
 )
 
+// DefaultBranchTemplate is the issue branch name template used when BRANCH_TEMPLATE is unset.
+const DefaultBranchTemplate = "llm/issue-{{.Number}}-{{.Timestamp}}"
+
 // Load loads configuration from environment variables.
 func Load() (Config, error) {
 	return LoadFromEnv(os.Getenv)
@@ -53,16 +204,146 @@ func LoadFromEnv(getenv func(string) string) (Config, error) {
 		WebhookPath:     getOrDefault(getenv, "WEBHOOK_PATH", defaultWebhookPath),
 		IPAllowlist:     getenv("IP_ALLOWLIST"),
 		GitHubToken:     getenv("GITHUB_TOKEN"),
+		GitHubAPIURL:    getOrDefault(getenv, "GITHUB_API_URL", defaultGitHubAPIURL),
 		RepoCloneBase:   getOrDefault(getenv, "REPO_CLONE_BASE", defaultRepoBase),
 		MaxWorkers:      getIntOrDefault(getenv, "MAX_WORKERS", defaultMaxWorkers),
 		TriggerLabels:   parseList(getOrDefault(getenv, "TRIGGER_LABELS", defaultTriggerLabels)),
 		NeedsInfoLabel:  getOrDefault(getenv, "NEEDS_INFO_LABEL", defaultNeedsInfoLabel),
 		InProgressLabel: getOrDefault(getenv, "IN_PROGRESS_LABEL", defaultInProgressLabel),
 		DoneLabel:       getOrDefault(getenv, "DONE_LABEL", defaultDoneLabel),
+		FailedLabel:     getOrDefault(getenv, "FAILED_LABEL", defaultFailedLabel),
 		PRSlashCommands: parseList(getOrDefault(getenv, "PR_SLASH_COMMANDS", defaultPRSlashCommands)),
 		LogLevel:        getOrDefault(getenv, "LOG_LEVEL", defaultLogLevel),
 		RuntimeConfig:   llm.LoadRuntimeConfig(getenv),
+
+		RequestCodeOwnerReviews: getBoolOrDefault(getenv, "REQUEST_CODEOWNER_REVIEWS", true),
+		MirrorCache:             getBoolOrDefault(getenv, "MIRROR_CACHE", false),
+		CloneDepth:              getIntOrDefault(getenv, "CLONE_DEPTH", 0),
+		CloneFilter:             getenv("CLONE_FILTER"),
+
+		BranchTemplate:  getOrDefault(getenv, "BRANCH_TEMPLATE", DefaultBranchTemplate),
+		BaseLabelPrefix: getOrDefault(getenv, "BASE_LABEL_PREFIX", defaultBaseLabelPrefix),
+		BackportCommand: getOrDefault(getenv, "BACKPORT_COMMAND", defaultBackportCommand),
+
+		ReviewCommand:     getOrDefault(getenv, "REVIEW_COMMAND", defaultReviewCommand),
+		AskCommand:        getOrDefault(getenv, "ASK_COMMAND", defaultAskCommand),
+		CancelCommand:     getOrDefault(getenv, "CANCEL_COMMAND", defaultCancelCommand),
+		TriageOnOpen:      getBoolOrDefault(getenv, "TRIAGE_ON_OPEN", false),
+		TriageApplyLabels: getBoolOrDefault(getenv, "TRIAGE_APPLY_LABELS", false),
+
+		DryRun:          getBoolOrDefault(getenv, "DRY_RUN", false),
+		MaxChangedFiles: getIntOrDefault(getenv, "MAX_CHANGED_FILES", 0),
+		MaxChangedLines: getIntOrDefault(getenv, "MAX_CHANGED_LINES", 0),
+		PolicyRules:     parsePolicyRules(getOrDefault(getenv, "POLICY_RULES", defaultPolicyRules)),
+
+		PlanMode:         getBoolOrDefault(getenv, "PLAN_MODE", false),
+		PlanPendingLabel: getOrDefault(getenv, "PLAN_PENDING_LABEL", defaultPlanLabel),
+		ApproveCommand:   getOrDefault(getenv, "APPROVE_COMMAND", defaultApproveCommand),
+
+		PollInterval:   getDurationOrDefault(getenv, "POLL_INTERVAL", 0),
+		PollRepos:      parseList(getenv("POLL_REPOS")),
+		PollLookback:   getDurationOrDefault(getenv, "POLL_LOOKBACK", defaultPollLookback),
+		PollCursorFile: getenv("POLL_CURSOR_FILE"),
+
+		DeliveryStoreDir:  getenv("DELIVERY_STORE_DIR"),
+		DeliveryRetention: getDurationOrDefault(getenv, "DELIVERY_RETENTION", defaultDeliveryRetention),
+		AdminToken:        getenv("ADMIN_TOKEN"),
+
+		PushMaxAttempts:         getIntOrDefault(getenv, "PUSH_MAX_ATTEMPTS", defaultPushMaxAttempts),
+		ConflictResolutionLLM:   getBoolOrDefault(getenv, "CONFLICT_RESOLUTION_LLM", false),
+		CommitAuthorName:        getOrDefault(getenv, "COMMIT_AUTHOR_NAME", defaultCommitAuthorName),
+		CommitAuthorEmail:       getenv("COMMIT_AUTHOR_EMAIL"),
+		CommitAuthorID:          int64(getIntOrDefault(getenv, "COMMIT_AUTHOR_ID", 0)),
+		CommitSignOff:           getBoolOrDefault(getenv, "COMMIT_SIGNOFF", false),
+		CommitCoAuthorRequester: getBoolOrDefault(getenv, "COMMIT_CO_AUTHOR_REQUESTER", true),
+		CommitSigningFormat:     getenv("COMMIT_SIGNING_FORMAT"),
+		CommitSigningKey:        getenv("COMMIT_SIGNING_KEY"),
+		CommitSigningKeyFile:    getenv("COMMIT_SIGNING_KEY_FILE"),
+
+		WorkspaceGCInterval:    getDurationOrDefault(getenv, "WORKSPACE_GC_INTERVAL", defaultWorkspaceGCInterval),
+		WorkspaceGCDelete:      getBoolOrDefault(getenv, "WORKSPACE_GC_DELETE", false),
+		WorkspaceKeepPerTarget: getIntOrDefault(getenv, "WORKSPACE_KEEP_PER_TARGET", defaultWorkspaceKeepPerTarget),
+		WorkspaceMaxAge:        getDurationOrDefault(getenv, "WORKSPACE_MAX_AGE", defaultWorkspaceMaxAge),
+		WorkspaceFailedMaxAge:  getDurationOrDefault(getenv, "WORKSPACE_FAILED_MAX_AGE", defaultWorkspaceFailedMaxAge),
+		WorkspaceOutputs:       getOrDefault(getenv, "WORKSPACE_OUTPUTS", defaultWorkspaceOutputs),
+	}
+
+	maxBytes, err := parseBytes(getenv("WORKSPACE_MAX_BYTES"))
+	if err != nil {
+		return Config{}, fmt.Errorf("WORKSPACE_MAX_BYTES is invalid: %w", err)
+	}
+	cfg.WorkspaceMaxBytes = maxBytes
+	cfg.BotLogin = getenv("BOT_LOGIN")
+	cfg.PolicyMaxFileBytes = defaultPolicyMaxFileBytes
+	if value := getenv("POLICY_MAX_FILE_BYTES"); value != "" {
+		if cfg.PolicyMaxFileBytes, err = parseBytes(value); err != nil {
+			return Config{}, fmt.Errorf("POLICY_MAX_FILE_BYTES is invalid: %w", err)
+		}
+	}
+	if err := validatePolicyRules(cfg.PolicyRules); err != nil {
+		return Config{}, fmt.Errorf("POLICY_RULES is invalid: %w", err)
+	}
+	switch cfg.CommitSigningFormat {
+	case "", "gpg", "ssh":
+	default:
+		return Config{}, fmt.Errorf("COMMIT_SIGNING_FORMAT must be gpg or ssh, got %q", cfg.CommitSigningFormat)
+	}
+	if cfg.CommitSigningFormat == "ssh" && cfg.CommitSigningKey == "" {
+		return Config{}, errors.New("COMMIT_SIGNING_KEY is required for ssh commit signing")
+	}
+	if cfg.CommitSigningKeyFile != "" && cfg.CommitSigningFormat != "gpg" {
+		return Config{}, errors.New("COMMIT_SIGNING_KEY_FILE requires COMMIT_SIGNING_FORMAT=gpg")
+	}
+	if cfg.CommitSigningFormat == "gpg" && cfg.CommitSigningKey == "" && cfg.CommitSigningKeyFile == "" {
+		return Config{}, errors.New("COMMIT_SIGNING_KEY (a key in GNUPGHOME) or COMMIT_SIGNING_KEY_FILE is required for gpg commit signing")
+	}
+	switch cfg.WorkspaceOutputs {
+	case "keep", "archive", "delete":
+	default:
+		return Config{}, fmt.Errorf("WORKSPACE_OUTPUTS must be keep, archive or delete, got %q", cfg.WorkspaceOutputs)
+	}
+
+	if err := validateBranchTemplate(cfg.BranchTemplate); err != nil {
+		return Config{}, fmt.Errorf("BRANCH_TEMPLATE is invalid: %w", err)
+	}
+	cfg.JobDetailsURL = getenv("JOB_DETAILS_URL")
+	if _, err := template.New("job").Parse(cfg.JobDetailsURL); err != nil {
+		return Config{}, fmt.Errorf("JOB_DETAILS_URL is invalid: %w", err)
+	}
+	if path := getenv("STATUS_TEMPLATE_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("STATUS_TEMPLATE_FILE: %w", err)
+		}
+		if _, err := template.New("status").Parse(string(data)); err != nil {
+			return Config{}, fmt.Errorf("STATUS_TEMPLATE_FILE is invalid: %w", err)
+		}
+		cfg.StatusTemplate = string(data)
+	}
+
+	repoSettings, err := parseRepoSettings(getenv("REPO_SETTINGS"))
+	if err != nil {
+		return Config{}, err
 	}
+	cfg.RepoSettings = repoSettings
+
+	if cfg.PollCursorFile == "" {
+		cfg.PollCursorFile = filepath.Join(cfg.RepoCloneBase, ".poll-cursor.json")
+	}
+	if cfg.PollInterval > 0 && len(cfg.PollRepos) == 0 {
+		return Config{}, errors.New("POLL_REPOS is required when POLL_INTERVAL is set")
+	}
+	for _, repo := range cfg.PollRepos {
+		if owner, name, ok := strings.Cut(repo, "/"); !ok || owner == "" || name == "" {
+			return Config{}, fmt.Errorf("POLL_REPOS entries must be owner/repo, got %q", repo)
+		}
+	}
+
+	scheduledTasks, err := parseScheduledTasks(getenv("SCHEDULED_TASKS"))
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.ScheduledTasks = scheduledTasks
 
 	if cfg.GitHubToken == "" {
 		return Config{}, errors.New("GITHUB_TOKEN is required")
@@ -109,6 +390,12 @@ func LoadFromEnv(getenv func(string) string) (Config, error) {
 	return cfg, nil
 }
 
+// validateBranchTemplate checks that a branch name template parses.
+func validateBranchTemplate(value string) error {
+	_, err := template.New("branch").Option("missingkey=error").Parse(value)
+	return err
+}
+
 func usesAPI(cfg Config) bool {
 	return cfg.RuntimeConfig.UsesAPI()
 }
@@ -133,6 +420,66 @@ func getIntOrDefault(getenv func(string) string, key string, def int) int {
 	return parsed
 }
 
+func getDurationOrDefault(getenv func(string) string, key string, def time.Duration) time.Duration {
+	val := getenv(key)
+	if val == "" {
+		return def
+	}
+	parsed, err := time.ParseDuration(val)
+	if err != nil {
+		return def
+	}
+	return parsed
+}
+
+// parseBytes parses a byte size such as "1073741824", "500M", "20G" or "20Gi".
+// Suffixes are binary multiples. An empty value yields 0.
+func parseBytes(value string) (int64, error) {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "B")
+	value = strings.TrimSuffix(value, "i")
+	if value == "" {
+		return 0, nil
+	}
+	multiplier := int64(1)
+	switch strings.ToUpper(value[len(value)-1:]) {
+	case "K":
+		multiplier = 1 << 10
+	case "M":
+		multiplier = 1 << 20
+	case "G":
+		multiplier = 1 << 30
+	case "T":
+		multiplier = 1 << 40
+	}
+	if multiplier > 1 {
+		value = value[:len(value)-1]
+	}
+	parsed, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return parsed * multiplier, nil
+}
+
+// validatePolicyRules rejects unknown guardrail rule names.
+func validatePolicyRules(rules []string) error {
+	for _, rule := range rules {
+		if !policy.Known(rule) {
+			return fmt.Errorf("unknown rule %q (known: %s)", rule, strings.Join(policy.All, ", "))
+		}
+	}
+	return nil
+}
+
+// parsePolicyRules parses POLICY_RULES. Since an empty value selects the
+// defaults, "none" selects no rules.
+func parsePolicyRules(value string) []string {
+	if strings.EqualFold(strings.TrimSpace(value), "none") {
+		return []string{}
+	}
+	return parseList(value)
+}
+
 func parseList(value string) []string {
 	if value == "" {
 		return nil
@@ -161,3 +508,34 @@ func getBoolOrDefault(getenv func(string) string, key string, def bool) bool {
 	}
 	return val == "true" || val == "1" || val == "yes"
 }
+
+// Secrets returns the configured credentials that must never appear in logs,
+// workspace artifacts or GitHub posts.
+func (c Config) Secrets() []string {
+	secrets := []string{c.GitHubToken, c.LLMAPIKey, c.AdminToken}
+	for _, server := range c.MCPServers {
+		for _, value := range server.Env {
+			secrets = append(secrets, value)
+		}
+	}
+	return secrets
+}
+
+// CommitEmail returns the commit author email: COMMIT_AUTHOR_EMAIL when set,
+// otherwise the GitHub noreply address of the commit author.
+func (c Config) CommitEmail() string {
+	if c.CommitAuthorEmail != "" {
+		return c.CommitAuthorEmail
+	}
+	return NoreplyEmail(c.CommitAuthorName, c.CommitAuthorID)
+}
+
+// NoreplyEmail returns the GitHub noreply address of login. GitHub attributes
+// commits to an account only when the address carries its numeric ID
+// ("<id>+<login>@users.noreply.github.com"); id 0 yields the legacy form.
+func NoreplyEmail(login string, id int64) string {
+	if id > 0 {
+		return fmt.Sprintf("%d+%s@users.noreply.github.com", id, login)
+	}
+	return login + "@users.noreply.github.com"
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// RepoSettings holds per-repository behavior overrides.
+type RepoSettings struct {
+	// ProtectedOwners lists CODEOWNERS entries (e.g. "@org/security") whose
+	// paths automation must not modify. Runs touching them fall back to needs_info.
+	ProtectedOwners []string `json:"protected_owners,omitempty"`
+	// BaseBranch is the branch issue PRs target when the issue does not select one.
+	// Empty uses the repository default branch.
+	BaseBranch string `json:"base_branch,omitempty"`
+	// BranchTemplate overrides BRANCH_TEMPLATE for this repository.
+	BranchTemplate string `json:"branch_template,omitempty"`
+	// PlanMode overrides PLAN_MODE for this repository when set.
+	PlanMode *bool `json:"plan_mode,omitempty"`
+	// DryRun overrides DRY_RUN for this repository when set.
+	DryRun *bool `json:"dry_run,omitempty"`
+	// PolicyRules overrides POLICY_RULES for this repository when set; an
+	// empty list disables the guardrails.
+	PolicyRules []string `json:"policy_rules,omitempty"`
+	// AutoReview reviews human-authored PRs when they are opened or marked ready for review.
+	AutoReview bool `json:"auto_review,omitempty"`
+}
+
+// ForRepo returns the settings for a repository full name ("owner/repo").
+// Unknown repositories get zero-value settings.
+func (c Config) ForRepo(fullName string) RepoSettings {
+	if settings, ok := c.RepoSettings[fullName]; ok {
+		return settings
+	}
+	for name, settings := range c.RepoSettings {
+		if strings.EqualFold(name, fullName) {
+			return settings
+		}
+	}
+	return RepoSettings{}
+}
+
+// parseRepoSettings parses per-repository settings from a JSON object.
+// Format: {"owner/repo":{"protected_owners":["@org/security"],"base_branch":"develop"}}
+func parseRepoSettings(value string) (map[string]RepoSettings, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var settings map[string]RepoSettings
+	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+		return nil, fmt.Errorf("REPO_SETTINGS is invalid: %w", err)
+	}
+	for name, repo := range settings {
+		if err := validatePolicyRules(repo.PolicyRules); err != nil {
+			return nil, fmt.Errorf("REPO_SETTINGS policy_rules for %s is invalid: %w", name, err)
+		}
+		if repo.BranchTemplate == "" {
+			continue
+		}
+		if err := validateBranchTemplate(repo.BranchTemplate); err != nil {
+			return nil, fmt.Errorf("REPO_SETTINGS branch_template for %s is invalid: %w", name, err)
+		}
+	}
+	return settings, nil
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"git_sonic/pkg/cron"
+)
+
+// taskName restricts scheduled task names to characters safe in branch names.
+var taskName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ScheduledTask is a recurring maintenance task run against a repository.
+type ScheduledTask struct {
+	// Name identifies the task; it appears in branch names ("llm/task-<name>-...").
+	Name string `json:"name"`
+	// Repo is the target repository ("owner/repo").
+	Repo string `json:"repo"`
+	// Schedule is a five-field cron expression evaluated in UTC.
+	Schedule string `json:"schedule"`
+	// Prompt describes the work, e.g. "Fix golangci-lint warnings".
+	Prompt string `json:"prompt"`
+	// BaseBranch is the branch the PR targets; empty uses the default branch.
+	BaseBranch string `json:"base_branch,omitempty"`
+	// Labels are added to the PR.
+	Labels []string `json:"labels,omitempty"`
+}
+
+// parseScheduledTasks parses scheduled tasks from a JSON array.
+// Format: [{"name":"fix-lint","repo":"owner/repo","schedule":"0 3 * * 1","prompt":"Fix lint warnings"}]
+func parseScheduledTasks(value string) ([]ScheduledTask, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var tasks []ScheduledTask
+	if err := json.Unmarshal([]byte(value), &tasks); err != nil {
+		return nil, fmt.Errorf("SCHEDULED_TASKS is invalid: %w", err)
+	}
+	seen := map[string]bool{}
+	for _, task := range tasks {
+		if !taskName.MatchString(task.Name) {
+			return nil, fmt.Errorf("SCHEDULED_TASKS name %q must be lowercase letters, digits, '-' or '_'", task.Name)
+		}
+		if seen[task.Name] {
+			return nil, fmt.Errorf("SCHEDULED_TASKS name %q is used more than once", task.Name)
+		}
+		seen[task.Name] = true
+		if owner, repo, ok := strings.Cut(task.Repo, "/"); !ok || owner == "" || repo == "" {
+			return nil, fmt.Errorf("SCHEDULED_TASKS repo for %s must be owner/repo, got %q", task.Name, task.Repo)
+		}
+		if strings.TrimSpace(task.Prompt) == "" {
+			return nil, fmt.Errorf("SCHEDULED_TASKS prompt for %s is required", task.Name)
+		}
+		if _, err := cron.Parse(task.Schedule); err != nil {
+			return nil, fmt.Errorf("SCHEDULED_TASKS schedule for %s is invalid: %w", task.Name, err)
+		}
+	}
+	return tasks, nil
+}
+
+// Task returns the scheduled task with the given name.
+func (c Config) Task(name string) (ScheduledTask, bool) {
+	for _, task := range c.ScheduledTasks {
+		if task.Name == name {
+			return task, true
+		}
+	}
+	return ScheduledTask{}, false
+}
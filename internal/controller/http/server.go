@@ -1,12 +1,15 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"io"
 	"net"
 	"net/http"
 	"strings"
+	"time"
 
 	"git_sonic/internal/config"
 	"git_sonic/internal/controller/webhook"
@@ -22,6 +25,8 @@ type Server struct {
 	allowlist allowlist.Allowlist
 	queue     *queue.Queue
 	agent     agent.Agent
+	store     *webhook.Store
+	onCancel  func(webhook.Event)
 	logger    *logging.Logger
 }
 
@@ -36,12 +41,29 @@ func (s *Server) WithAgent(ag agent.Agent) *Server {
 	return s
 }
 
+// WithDeliveryStore persists accepted webhook deliveries for replay.
+func (s *Server) WithDeliveryStore(store *webhook.Store) *Server {
+	s.store = store
+	return s
+}
+
+// WithCancelHook sets a function called with every webhook event before it is
+// enqueued, so that cancel requests reach in-flight runs even when the queue
+// is full. It runs on the request goroutine and should not block.
+func (s *Server) WithCancelHook(hook func(webhook.Event)) *Server {
+	s.onCancel = hook
+	return s
+}
+
 // Handler returns the HTTP handler.
 func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc(s.cfg.WebhookPath, s.handleWebhook)
 	mux.HandleFunc("/chat", s.handleChat)
 	mux.HandleFunc("/healthz", s.handleHealthz)
+	if s.cfg.AdminToken != "" {
+		mux.HandleFunc("/admin/replay/", s.handleReplay)
+	}
 	return mux
 }
 
@@ -167,6 +189,13 @@ func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusForbidden)
 		return
 	}
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Error("webhook read body error", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(payload))
 	event, err := webhook.ParseEvent(r)
 	if err != nil {
 		log.Error("webhook parse error", "client_ip", clientIP.String(), "error", err)
@@ -182,6 +211,15 @@ func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
 		"repo", event.Repository.FullName,
 	)
 
+	if s.store != nil && event.DeliveryID != "" {
+		if err := s.store.Save(webhook.NewDelivery(r.Header, payload, time.Now())); err != nil {
+			log.Warn("webhook delivery not stored", "error", err)
+		}
+	}
+
+	if s.onCancel != nil {
+		s.onCancel(event)
+	}
 	if err := s.queue.Enqueue(queue.Job{Event: event}); err != nil {
 		log.Error("webhook enqueue failed", "error", err)
 		w.WriteHeader(http.StatusServiceUnavailable)
@@ -191,6 +229,59 @@ func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusAccepted)
 }
 
+// handleReplay re-enqueues a stored delivery: POST /admin/replay/<delivery-id>.
+func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request) {
+	log := s.logger.With("path", r.URL.Path)
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorizedAdmin(r) {
+		log.Warn("replay rejected: invalid admin token")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if s.store == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "delivery store not configured")
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/admin/replay/")
+	delivery, err := s.store.Load(id)
+	if err != nil {
+		log.Warn("replay delivery not found", "delivery_id", id, "error", err)
+		writeJSONError(w, http.StatusNotFound, "delivery not found")
+		return
+	}
+	event, err := delivery.Event()
+	if err != nil {
+		log.Error("replay parse error", "delivery_id", id, "error", err)
+		writeJSONError(w, http.StatusUnprocessableEntity, "stored delivery cannot be parsed")
+		return
+	}
+	event.DeliveryID = "replay-" + delivery.ID
+	if err := s.queue.Enqueue(queue.Job{Event: event}); err != nil {
+		log.Error("replay enqueue failed", "delivery_id", id, "error", err)
+		writeJSONError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	log.Info("replay accepted", "delivery_id", event.DeliveryID, "event_type", event.Type, "repo", event.Repository.FullName)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"delivery_id": event.DeliveryID})
+}
+
+func (s *Server) authorizedAdmin(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.AdminToken)) == 1
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
 func extractClientIP(r *http.Request) net.IP {
 	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
 		parts := strings.Split(forwarded, ",")
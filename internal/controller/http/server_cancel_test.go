@@ -0,0 +1,44 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"git_sonic/internal/config"
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/internal/service/queue"
+	"git_sonic/pkg/allowlist"
+)
+
+func TestWebhookPassesEventsToCancelHookWhenQueueIsFull(t *testing.T) {
+	// Workers are never started, so the queue fills after four jobs.
+	q := queue.New(1, nil)
+	// httptest requests come from 192.0.2.1.
+	allowed, err := allowlist.Parse("192.0.2.0/24")
+	if err != nil {
+		t.Fatalf("allowlist: %v", err)
+	}
+	var cancels []int
+	srv := New(config.Config{WebhookPath: "/webhook"}, allowed, q).
+		WithCancelHook(func(event webhook.Event) { cancels = append(cancels, event.Issue.Number) })
+
+	var codes []int
+	for i := 1; i <= 5; i++ {
+		payload := fmt.Sprintf(`{"action":"created","issue":{"number":%d,"state":"open"},"comment":{"body":"/ai-cancel"},"repository":{"full_name":"org/repo"},"sender":{"login":"octocat"}}`, i)
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(payload))
+		req.Header.Set("X-GitHub-Event", "issue_comment")
+		req.Header.Set("X-GitHub-Delivery", "delivery")
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+	if codes[4] != http.StatusServiceUnavailable {
+		t.Fatalf("expected the fifth delivery to be rejected, got %v", codes)
+	}
+	if len(cancels) != 5 || cancels[4] != 5 {
+		t.Fatalf("expected every delivery to reach the cancel hook, got %v", cancels)
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"git_sonic/internal/config"
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/internal/service/queue"
+	"git_sonic/pkg/allowlist"
+)
+
+func newReplayServer(t *testing.T) (*Server, *[]queue.Job) {
+	t.Helper()
+	var jobs []queue.Job
+	q := queue.New(1, nil).WithEnqueueHook(func(job queue.Job) { jobs = append(jobs, job) })
+	store := webhook.NewStore(t.TempDir())
+	header := http.Header{}
+	header.Set("X-GitHub-Event", "issue_comment")
+	header.Set("X-GitHub-Delivery", "abc-123")
+	payload := `{"action":"created","issue":{"number":7,"state":"open"},"comment":{"body":"/ai-ask why?"},"repository":{"full_name":"org/repo"},"sender":{"login":"octocat"}}`
+	if err := store.Save(webhook.NewDelivery(header, []byte(payload), time.Now())); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	cfg := config.Config{WebhookPath: "/webhook", AdminToken: "admin-secret"}
+	return New(cfg, allowlist.Allowlist{}, q).WithDeliveryStore(store), &jobs
+}
+
+func TestReplayRequiresAdminToken(t *testing.T) {
+	srv, jobs := newReplayServer(t)
+	for _, auth := range []string{"", "Bearer wrong", "admin-secret"} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/replay/abc-123", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("Authorization %q: expected 401, got %d", auth, rec.Code)
+		}
+	}
+	if len(*jobs) != 0 {
+		t.Fatalf("expected no jobs for rejected replays, got %d", len(*jobs))
+	}
+}
+
+func TestReplayEnqueuesStoredDelivery(t *testing.T) {
+	srv, jobs := newReplayServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/replay/abc-123", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(*jobs) != 1 {
+		t.Fatalf("expected one job, got %d", len(*jobs))
+	}
+	if event := (*jobs)[0].Event; event.DeliveryID != "replay-abc-123" || event.Type != webhook.EventIssueComment || event.Issue.Number != 7 {
+		t.Fatalf("unexpected replayed event: %+v", event)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/replay/missing", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown delivery, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/replay/abc-123", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for GET, got %d", rec.Code)
+	}
+}
+
+func TestReplayDisabledWithoutAdminToken(t *testing.T) {
+	srv, _ := newReplayServer(t)
+	srv.cfg.AdminToken = ""
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/replay/abc-123", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected the replay endpoint to be absent, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"git_sonic/pkg/redact"
+)
+
+// Delivery is a raw webhook request persisted for replay.
+type Delivery struct {
+	ID         string            `json:"id"`
+	ReceivedAt time.Time         `json:"received_at"`
+	Headers    map[string]string `json:"headers"`
+	Body       json.RawMessage   `json:"body"`
+}
+
+// redactedHeaders are headers whose values are never persisted.
+var redactedHeaders = []string{"Authorization", "Cookie", "X-Hub-Signature", "X-Hub-Signature-256"}
+
+// NewDelivery captures the headers and body of a webhook request, redacting
+// signatures, credentials and secret-looking strings.
+func NewDelivery(header http.Header, body []byte, receivedAt time.Time) Delivery {
+	headers := make(map[string]string, len(header))
+	for name := range header {
+		headers[name] = header.Get(name)
+	}
+	for _, name := range redactedHeaders {
+		if _, ok := headers[http.CanonicalHeaderKey(name)]; ok {
+			headers[http.CanonicalHeaderKey(name)] = redact.Marker
+		}
+	}
+	body = []byte(redact.Text(string(body)))
+	if !json.Valid(body) {
+		// Keep invalid payloads as a JSON string so the record still loads.
+		body, _ = json.Marshal(string(body))
+	}
+	return Delivery{
+		ID:         header.Get("X-GitHub-Delivery"),
+		ReceivedAt: receivedAt.UTC(),
+		Headers:    headers,
+		Body:       body,
+	}
+}
+
+// Request rebuilds an HTTP request that ParseEvent accepts.
+func (d Delivery) Request() *http.Request {
+	req, _ := http.NewRequest(http.MethodPost, "/", bytes.NewReader(d.Body))
+	for name, value := range d.Headers {
+		req.Header.Set(name, value)
+	}
+	return req
+}
+
+// Event parses the stored delivery into an Event.
+func (d Delivery) Event() (Event, error) {
+	return ParseEvent(d.Request())
+}
+
+// Store persists deliveries as one JSON file per delivery ID.
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store rooted at dir.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Save writes the delivery to <dir>/<id>.json.
+func (s *Store) Save(d Delivery) error {
+	path, err := s.path(d.ID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// Prune removes deliveries saved before cutoff and returns how many were
+// removed. A missing store directory holds nothing to prune.
+func (s *Store) Prune(cutoff time.Time) (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, entry.Name())); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removed++
+	}
+	return removed, errors.Join(errs...)
+}
+
+// Load reads the delivery with the given ID.
+func (s *Store) Load(id string) (Delivery, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return Delivery{}, err
+	}
+	return LoadDelivery(path)
+}
+
+func (s *Store) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("invalid delivery id %q", id)
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+// LoadDelivery reads a delivery file written by Store.Save.
+func LoadDelivery(path string) (Delivery, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Delivery{}, err
+	}
+	var d Delivery
+	if err := json.Unmarshal(data, &d); err != nil {
+		return Delivery{}, fmt.Errorf("parse delivery %s: %w", path, err)
+	}
+	if len(d.Body) == 0 || d.Headers == nil {
+		return Delivery{}, errors.New("delivery is missing headers or body")
+	}
+	return d, nil
+}
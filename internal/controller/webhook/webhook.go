@@ -14,6 +14,10 @@ const (
 	EventIssues       EventType = "issues"
 	EventIssueComment EventType = "issue_comment"
 	EventPRComment    EventType = "pull_request_review_comment"
+	EventPullRequest  EventType = "pull_request"
+	// EventSchedule is a synthetic event created by the scheduler; it is
+	// never received over HTTP.
+	EventSchedule EventType = "schedule"
 )
 
 // Repository holds repository metadata.
@@ -30,6 +34,8 @@ type Issue struct {
 	Title  string
 	Body   string
 	Labels []string
+	// IsPullRequest is set when the issue is a pull request (issue_comment on a PR).
+	IsPullRequest bool
 }
 
 // PullRequest holds PR fields used by the service.
@@ -40,6 +46,13 @@ type PullRequest struct {
 	Body    string
 	HeadRef string
 	BaseRef string
+	Author  string
+	Draft   bool
+	// HeadRepoFullName and HeadCloneURL describe the repository holding the
+	// head branch; they differ from the base repository for fork PRs.
+	HeadRepoFullName    string
+	HeadCloneURL        string
+	MaintainerCanModify bool
 }
 
 // Event represents a parsed GitHub webhook event.
@@ -53,6 +66,11 @@ type Event struct {
 	Label       string
 	CommentBody string
 	Sender      string
+	// Task names the scheduled task for EventSchedule events.
+	Task string
+	// CommentID identifies the comment of issue_comment and
+	// pull_request_review_comment events.
+	CommentID int64
 }
 
 // ParseEvent parses an HTTP request into a webhook Event.
@@ -61,7 +79,7 @@ func ParseEvent(r *http.Request) (Event, error) {
 	if eventType == "" {
 		return Event{}, errors.New("missing X-GitHub-Event header")
 	}
-	if eventType != EventIssues && eventType != EventIssueComment && eventType != EventPRComment {
+	if eventType != EventIssues && eventType != EventIssueComment && eventType != EventPRComment && eventType != EventPullRequest {
 		return Event{}, errors.New("unsupported event type")
 	}
 	payload, err := io.ReadAll(r.Body)
@@ -82,20 +100,31 @@ func ParseEvent(r *http.Request) (Event, error) {
 			Labels []struct {
 				Name string `json:"name"`
 			} `json:"labels"`
+			PullRequest *struct{} `json:"pull_request"`
 		} `json:"issue"`
 		PullRequest struct {
 			Number int    `json:"number"`
 			State  string `json:"state"`
 			Title  string `json:"title"`
 			Body   string `json:"body"`
-			Head   struct {
-				Ref string `json:"ref"`
+			Draft  bool   `json:"draft"`
+			User   struct {
+				Login string `json:"login"`
+			} `json:"user"`
+			Head struct {
+				Ref  string `json:"ref"`
+				Repo struct {
+					FullName string `json:"full_name"`
+					CloneURL string `json:"clone_url"`
+				} `json:"repo"`
 			} `json:"head"`
 			Base struct {
 				Ref string `json:"ref"`
 			} `json:"base"`
+			MaintainerCanModify bool `json:"maintainer_can_modify"`
 		} `json:"pull_request"`
 		Comment struct {
+			ID   int64  `json:"id"`
 			Body string `json:"body"`
 		} `json:"comment"`
 		Repository struct {
@@ -123,6 +152,7 @@ func ParseEvent(r *http.Request) (Event, error) {
 		},
 		Label:       raw.Label.Name,
 		CommentBody: raw.Comment.Body,
+		CommentID:   raw.Comment.ID,
 		Sender:      raw.Sender.Login,
 	}
 
@@ -137,6 +167,8 @@ func ParseEvent(r *http.Request) (Event, error) {
 			Title:  raw.Issue.Title,
 			Body:   raw.Issue.Body,
 			Labels: labels,
+
+			IsPullRequest: raw.Issue.PullRequest != nil,
 		}
 	}
 
@@ -148,6 +180,12 @@ func ParseEvent(r *http.Request) (Event, error) {
 			Body:    raw.PullRequest.Body,
 			HeadRef: raw.PullRequest.Head.Ref,
 			BaseRef: raw.PullRequest.Base.Ref,
+			Author:  raw.PullRequest.User.Login,
+			Draft:   raw.PullRequest.Draft,
+
+			HeadRepoFullName:    raw.PullRequest.Head.Repo.FullName,
+			HeadCloneURL:        raw.PullRequest.Head.Repo.CloneURL,
+			MaintainerCanModify: raw.PullRequest.MaintainerCanModify,
 		}
 	}
 
@@ -0,0 +1,350 @@
+// Package janitor enforces the workspace retention policy under REPO_CLONE_BASE.
+package janitor
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"git_sonic/internal/service/workflow"
+	"git_sonic/pkg/logging"
+)
+
+// Output retention modes for expired workspaces.
+const (
+	OutputsDelete  = "delete"
+	OutputsKeep    = "keep"
+	OutputsArchive = "archive"
+)
+
+// ArchiveSubdir is the directory under the clone base holding archived outputs.
+const ArchiveSubdir = ".archive"
+
+// runningGrace is how long a workspace without a status file is treated as a
+// run in progress. Older workspaces without status are treated as abandoned.
+const runningGrace = 12 * time.Hour
+
+// workspaceName matches "<target>-<YYYYMMDD-HHMMSS>" workspace directories.
+var workspaceName = regexp.MustCompile(`^(.+)-(\d{8}-\d{6})$`)
+
+// Policy configures workspace retention. Zero values disable a rule.
+type Policy struct {
+	// KeepPerTarget keeps the newest N workspaces per issue/PR.
+	KeepPerTarget int
+	// MaxAge expires successful workspaces older than this.
+	MaxAge time.Duration
+	// FailedMaxAge expires failed workspaces older than this; defaults to MaxAge.
+	FailedMaxAge time.Duration
+	// MaxTotalBytes expires the oldest finished workspaces until the total
+	// size of the clone base drops below this limit.
+	MaxTotalBytes int64
+	// Outputs selects what happens to outputs/ of expired workspaces:
+	// OutputsDelete, OutputsKeep, or OutputsArchive.
+	Outputs string
+	// DryRun logs the workspaces that would expire without touching them.
+	DryRun bool
+}
+
+// Report summarizes a sweep. In dry-run mode Expired and FreedBytes count
+// what the sweep would have done.
+type Report struct {
+	Scanned    int
+	Expired    int
+	FreedBytes int64
+	DryRun     bool
+}
+
+// Janitor removes expired workspaces.
+type Janitor struct {
+	base   string
+	policy Policy
+	now    func() time.Time
+	logger *logging.Logger
+}
+
+// New creates a janitor for the given clone base.
+func New(base string, policy Policy) *Janitor {
+	if policy.FailedMaxAge == 0 {
+		policy.FailedMaxAge = policy.MaxAge
+	}
+	if policy.Outputs == "" {
+		policy.Outputs = OutputsKeep
+	}
+	return &Janitor{base: base, policy: policy, now: time.Now, logger: logging.Default()}
+}
+
+// Run sweeps immediately and then on every interval until ctx is done.
+func (j *Janitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		report, err := j.Sweep()
+		if err != nil {
+			j.logger.Error("workspace sweep failed", "base", j.base, "error", err)
+		} else if report.Expired > 0 {
+			j.logger.Info("workspace sweep completed",
+				"scanned", report.Scanned,
+				"expired", report.Expired,
+				"freed_bytes", report.FreedBytes,
+				"dry_run", report.DryRun,
+			)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type workspace struct {
+	name      string
+	path      string
+	target    string
+	createdAt time.Time
+	status    string
+	size      int64
+	repoSize  int64
+	pruned    bool
+}
+
+// Sweep applies the retention policy once.
+func (j *Janitor) Sweep() (Report, error) {
+	workspaces, err := j.scan()
+	if err != nil {
+		return Report{}, err
+	}
+	report := Report{Scanned: len(workspaces), DryRun: j.policy.DryRun}
+	now := j.now()
+
+	expired := map[string]bool{}
+	byTarget := map[string][]*workspace{}
+	var total int64
+	for _, ws := range workspaces {
+		total += ws.size
+		if ws.pruned || j.running(ws, now) {
+			continue
+		}
+		byTarget[ws.target] = append(byTarget[ws.target], ws)
+		maxAge := j.policy.MaxAge
+		if ws.status == workflow.RunFailed {
+			maxAge = j.policy.FailedMaxAge
+		}
+		if maxAge > 0 && now.Sub(ws.createdAt) > maxAge {
+			expired[ws.path] = true
+		}
+	}
+	if j.policy.KeepPerTarget > 0 {
+		for _, list := range byTarget {
+			sort.Slice(list, func(a, b int) bool { return list[a].createdAt.After(list[b].createdAt) })
+			for _, ws := range list[min(j.policy.KeepPerTarget, len(list)):] {
+				expired[ws.path] = true
+			}
+		}
+	}
+
+	// Workspaces are scanned oldest first, so the size limit removes the oldest runs.
+	if j.policy.MaxTotalBytes > 0 {
+		remaining := total
+		for _, ws := range workspaces {
+			if expired[ws.path] {
+				remaining -= j.reclaimable(ws)
+			}
+		}
+		for _, ws := range workspaces {
+			if remaining <= j.policy.MaxTotalBytes {
+				break
+			}
+			if ws.pruned || expired[ws.path] || j.running(ws, now) {
+				continue
+			}
+			expired[ws.path] = true
+			remaining -= j.reclaimable(ws)
+		}
+	}
+
+	for _, ws := range workspaces {
+		if !expired[ws.path] {
+			continue
+		}
+		if j.policy.DryRun {
+			j.logger.Info("would expire workspace", "workspace", ws.path, "status", ws.status, "bytes", j.reclaimable(ws))
+			report.Expired++
+			report.FreedBytes += j.reclaimable(ws)
+			continue
+		}
+		freed, err := j.expire(ws)
+		if err != nil {
+			j.logger.Warn("failed to expire workspace", "workspace", ws.path, "error", err)
+			continue
+		}
+		j.logger.Debug("expired workspace", "workspace", ws.path, "status", ws.status, "freed_bytes", freed)
+		report.Expired++
+		report.FreedBytes += freed
+	}
+	return report, nil
+}
+
+// scan lists workspaces under the clone base, oldest first.
+func (j *Janitor) scan() ([]*workspace, error) {
+	entries, err := os.ReadDir(j.base)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []*workspace
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		match := workspaceName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		createdAt, err := time.ParseInLocation("20060102-150405", match[2], time.Local)
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(j.base, entry.Name())
+		ws := &workspace{
+			name:      entry.Name(),
+			path:      path,
+			target:    match[1],
+			createdAt: createdAt,
+			status:    readStatus(path),
+			size:      dirSize(path),
+		}
+		repo := filepath.Join(path, workflow.RepoSubdir)
+		if _, err := os.Stat(repo); os.IsNotExist(err) {
+			ws.pruned = true
+		} else {
+			ws.repoSize = dirSize(repo)
+		}
+		out = append(out, ws)
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].createdAt.Before(out[b].createdAt) })
+	return out, nil
+}
+
+// running reports whether a workspace may still be in use by a worker.
+func (j *Janitor) running(ws *workspace, now time.Time) bool {
+	return ws.status == "" && now.Sub(ws.createdAt) < runningGrace
+}
+
+// reclaimable returns the bytes freed by expiring a workspace.
+func (j *Janitor) reclaimable(ws *workspace) int64 {
+	if j.policy.Outputs == OutputsKeep {
+		return ws.repoSize
+	}
+	return ws.size
+}
+
+// expire removes a workspace according to the outputs policy and returns the bytes freed.
+func (j *Janitor) expire(ws *workspace) (int64, error) {
+	switch j.policy.Outputs {
+	case OutputsKeep:
+		return ws.repoSize, os.RemoveAll(filepath.Join(ws.path, workflow.RepoSubdir))
+	case OutputsArchive:
+		archiveDir := filepath.Join(j.base, ArchiveSubdir)
+		if err := os.MkdirAll(archiveDir, 0o755); err != nil {
+			return 0, err
+		}
+		outputs := filepath.Join(ws.path, workflow.OutputsSubdir)
+		if err := archiveOutputs(outputs, filepath.Join(archiveDir, ws.name+".tar.gz")); err != nil {
+			return 0, err
+		}
+	}
+	return ws.size, os.RemoveAll(ws.path)
+}
+
+func readStatus(workDir string) string {
+	data, err := os.ReadFile(filepath.Join(workDir, workflow.OutputsSubdir, workflow.StatusFile))
+	if err != nil {
+		return ""
+	}
+	var status workflow.RunStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return ""
+	}
+	return status.Status
+}
+
+func dirSize(root string) int64 {
+	var size int64
+	_ = filepath.WalkDir(root, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// archiveOutputs writes the regular files under src into a gzipped tarball.
+// A missing src directory produces no archive.
+func archiveOutputs(src, dst string) error {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	tmp := dst + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	walkErr := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		_, err = io.Copy(tw, in)
+		return err
+	})
+	for _, closeErr := range []error{tw.Close(), gz.Close(), file.Close()} {
+		if walkErr == nil {
+			walkErr = closeErr
+		}
+	}
+	if walkErr != nil {
+		_ = os.Remove(tmp)
+		return walkErr
+	}
+	return os.Rename(tmp, dst)
+}
@@ -0,0 +1,356 @@
+// Package poller turns GitHub activity into queued jobs for deployments that
+// cannot receive webhooks. It searches for issues carrying trigger labels and
+// lists new comments since a cursor persisted on disk.
+package poller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/internal/service/queue"
+	"git_sonic/pkg/github"
+	"git_sonic/pkg/logging"
+)
+
+// Cursor sources.
+const (
+	sourceLabelPrefix    = "label:"
+	sourceComments       = "comments"
+	sourceReviewComments = "review_comments"
+)
+
+// GitHubClient is the subset of the GitHub API used for polling.
+type GitHubClient interface {
+	GetRepo(ctx context.Context, owner, repo string) (github.Repo, error)
+	GetIssue(ctx context.Context, owner, repo string, number int) (github.Issue, error)
+	SearchIssues(ctx context.Context, query string) ([]github.Issue, error)
+	ListRepoIssueComments(ctx context.Context, owner, repo string, since time.Time) ([]github.RepoComment, error)
+	ListPRReviewComments(ctx context.Context, owner, repo string, since time.Time) ([]github.RepoComment, error)
+}
+
+// Enqueuer accepts jobs; *queue.Queue implements it.
+type Enqueuer interface {
+	Enqueue(job queue.Job) error
+}
+
+// Options configures a poller.
+type Options struct {
+	// Repos lists the repositories to poll ("owner/repo").
+	Repos []string
+	// TriggerLabels are searched for on open issues.
+	TriggerLabels []string
+	// Lookback is how far back the first poll of a repository looks.
+	Lookback time.Duration
+	// CursorFile persists the cursors between restarts.
+	CursorFile string
+}
+
+// Cursor records how far a source has been polled. Seen holds the keys of
+// items updated exactly at Since, which the next inclusive query returns again.
+type Cursor struct {
+	Since time.Time `json:"since"`
+	Seen  []string  `json:"seen,omitempty"`
+}
+
+// State maps repository to source to cursor.
+type State map[string]map[string]*Cursor
+
+// Poller polls repositories and enqueues synthetic webhook events.
+type Poller struct {
+	gh     GitHubClient
+	queue  Enqueuer
+	opts   Options
+	state  State
+	now    func() time.Time
+	logger *logging.Logger
+
+	onCancel func(webhook.Event)
+}
+
+// New creates a poller, loading cursors from opts.CursorFile when it exists.
+func New(gh GitHubClient, q Enqueuer, opts Options) (*Poller, error) {
+	p := &Poller{gh: gh, queue: q, opts: opts, state: State{}, now: time.Now, logger: logging.Default()}
+	data, err := os.ReadFile(opts.CursorFile)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(data, &p.state); err != nil {
+			return nil, fmt.Errorf("poll cursor file %s is invalid: %w", opts.CursorFile, err)
+		}
+	}
+	return p, nil
+}
+
+// WithCancelHook sets a function called with every polled event before it is
+// enqueued, so that cancel requests reach in-flight runs even when the queue
+// is full. It should not block.
+func (p *Poller) WithCancelHook(hook func(webhook.Event)) *Poller {
+	p.onCancel = hook
+	return p
+}
+
+// Run polls immediately and then on every interval until ctx is done.
+func (p *Poller) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if enqueued, err := p.Poll(ctx); err != nil {
+			p.logger.Error("poll failed", "error", err)
+		} else if enqueued > 0 {
+			p.logger.Info("poll completed", "enqueued", enqueued)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll runs one polling cycle over all repositories and returns the number
+// of jobs enqueued. Cursors are saved after every repository.
+func (p *Poller) Poll(ctx context.Context) (int, error) {
+	total := 0
+	var errs []error
+	for _, fullName := range p.opts.Repos {
+		enqueued, err := p.pollRepo(ctx, fullName)
+		total += enqueued
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", fullName, err))
+		}
+		if err := p.save(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return total, errors.Join(errs...)
+}
+
+func (p *Poller) pollRepo(ctx context.Context, fullName string) (int, error) {
+	owner, name, ok := strings.Cut(fullName, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid repository %q", fullName)
+	}
+	info, err := p.gh.GetRepo(ctx, owner, name)
+	if err != nil {
+		return 0, err
+	}
+	repo := webhook.Repository{FullName: fullName, CloneURL: info.CloneURL, DefaultBranch: info.DefaultBranch}
+
+	total := 0
+	var errs []error
+	for _, label := range p.opts.TriggerLabels {
+		n, err := p.pollLabel(ctx, repo, label)
+		total += n
+		errs = append(errs, err)
+	}
+	n, err := p.pollComments(ctx, repo, owner, name)
+	total += n
+	errs = append(errs, err)
+	n, err = p.pollReviewComments(ctx, repo, owner, name)
+	total += n
+	errs = append(errs, err)
+	return total, errors.Join(errs...)
+}
+
+// pollLabel enqueues "labeled" events for open issues carrying label.
+func (p *Poller) pollLabel(ctx context.Context, repo webhook.Repository, label string) (int, error) {
+	cursor := p.cursor(repo.FullName, sourceLabelPrefix+label)
+	query := fmt.Sprintf(`repo:%s is:issue is:open label:"%s" updated:>=%s`,
+		repo.FullName, label, cursor.Since.UTC().Format(time.RFC3339))
+	issues, err := p.gh.SearchIssues(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	enqueued := 0
+	for _, issue := range issues {
+		key := fmt.Sprintf("issue-%d@%d", issue.Number, issue.UpdatedAt.Unix())
+		if cursor.seen(key) {
+			continue
+		}
+		event := webhook.Event{
+			Type:       webhook.EventIssues,
+			Action:     "labeled",
+			DeliveryID: "poll-" + key,
+			Repository: repo,
+			Issue:      &webhook.Issue{Number: issue.Number, State: issue.State, Title: issue.Title, Body: issue.Body, Labels: issue.Labels},
+			Label:      label,
+		}
+		if err := p.enqueue(event); err != nil {
+			return enqueued, err
+		}
+		cursor.advance(issue.UpdatedAt, key)
+		enqueued++
+	}
+	return enqueued, nil
+}
+
+// pollComments enqueues "created" events for new issue and PR conversation comments.
+func (p *Poller) pollComments(ctx context.Context, repo webhook.Repository, owner, name string) (int, error) {
+	cursor := p.cursor(repo.FullName, sourceComments)
+	// since is fixed for the whole listing: advancing the cursor mid-loop
+	// would make earlier-created comments look like edits.
+	since := cursor.Since
+	comments, err := p.gh.ListRepoIssueComments(ctx, owner, name, since)
+	if err != nil {
+		return 0, err
+	}
+	var processed []progress
+	defer func() { cursor.advanceAll(processed) }()
+	enqueued := 0
+	for _, comment := range comments {
+		key := fmt.Sprintf("comment-%d", comment.ID)
+		if cursor.seen(key) {
+			continue
+		}
+		// Edited comments are skipped; webhook edits are ignored as well.
+		if !comment.CreatedAt.Before(since) {
+			issue, err := p.gh.GetIssue(ctx, owner, name, comment.Number)
+			if err != nil {
+				return enqueued, err
+			}
+			event := webhook.Event{
+				Type:       webhook.EventIssueComment,
+				Action:     "created",
+				DeliveryID: "poll-" + key,
+				Repository: repo,
+				Issue: &webhook.Issue{
+					Number:        issue.Number,
+					State:         issue.State,
+					Title:         issue.Title,
+					Body:          issue.Body,
+					Labels:        issue.Labels,
+					IsPullRequest: comment.IsPullRequest,
+				},
+				CommentBody: comment.Body,
+				CommentID:   comment.ID,
+				Sender:      comment.User,
+			}
+			if err := p.enqueue(event); err != nil {
+				return enqueued, err
+			}
+			enqueued++
+		}
+		processed = append(processed, progress{comment.UpdatedAt, key})
+	}
+	return enqueued, nil
+}
+
+// pollReviewComments enqueues "created" events for new PR review comments.
+func (p *Poller) pollReviewComments(ctx context.Context, repo webhook.Repository, owner, name string) (int, error) {
+	cursor := p.cursor(repo.FullName, sourceReviewComments)
+	since := cursor.Since
+	comments, err := p.gh.ListPRReviewComments(ctx, owner, name, since)
+	if err != nil {
+		return 0, err
+	}
+	var processed []progress
+	defer func() { cursor.advanceAll(processed) }()
+	enqueued := 0
+	for _, comment := range comments {
+		key := fmt.Sprintf("review-comment-%d", comment.ID)
+		if cursor.seen(key) {
+			continue
+		}
+		if !comment.CreatedAt.Before(since) {
+			event := webhook.Event{
+				Type:        webhook.EventPRComment,
+				Action:      "created",
+				DeliveryID:  "poll-" + key,
+				Repository:  repo,
+				PullRequest: &webhook.PullRequest{Number: comment.Number},
+				CommentBody: comment.Body,
+				CommentID:   comment.ID,
+				Sender:      comment.User,
+			}
+			if err := p.enqueue(event); err != nil {
+				return enqueued, err
+			}
+			enqueued++
+		}
+		processed = append(processed, progress{comment.UpdatedAt, key})
+	}
+	return enqueued, nil
+}
+
+// enqueue passes event to the cancel hook and then to the queue.
+func (p *Poller) enqueue(event webhook.Event) error {
+	if p.onCancel != nil {
+		p.onCancel(event)
+	}
+	return p.queue.Enqueue(queue.Job{Event: event})
+}
+
+// cursor returns the cursor for a repository source, starting Lookback ago
+// for sources polled for the first time.
+func (p *Poller) cursor(fullName, source string) *Cursor {
+	sources, ok := p.state[fullName]
+	if !ok {
+		sources = map[string]*Cursor{}
+		p.state[fullName] = sources
+	}
+	cursor, ok := sources[source]
+	if !ok {
+		cursor = &Cursor{Since: p.now().Add(-p.opts.Lookback).UTC().Truncate(time.Second)}
+		sources[source] = cursor
+	}
+	return cursor
+}
+
+// save writes the cursors atomically.
+func (p *Poller) save() error {
+	data, err := json.MarshalIndent(p.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.opts.CursorFile), 0o755); err != nil {
+		return err
+	}
+	tmp := p.opts.CursorFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p.opts.CursorFile)
+}
+
+func (c *Cursor) seen(key string) bool {
+	for _, item := range c.Seen {
+		if item == key {
+			return true
+		}
+	}
+	return false
+}
+
+// progress records an item processed during a poll.
+type progress struct {
+	updated time.Time
+	key     string
+}
+
+// advanceAll moves the cursor past the items processed in one poll, in order.
+func (c *Cursor) advanceAll(items []progress) {
+	for _, item := range items {
+		c.advance(item.updated, item.key)
+	}
+}
+
+// advance moves the cursor to an item processed at updated.
+func (c *Cursor) advance(updated time.Time, key string) {
+	updated = updated.UTC()
+	switch {
+	case updated.After(c.Since):
+		c.Since = updated
+		c.Seen = []string{key}
+	case updated.Equal(c.Since):
+		c.Seen = append(c.Seen, key)
+	}
+}
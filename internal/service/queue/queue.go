@@ -21,6 +21,8 @@ type Queue struct {
 	jobs    chan Job
 	handler Handler
 	wg      sync.WaitGroup
+
+	onEnqueue func(Job)
 }
 
 // New creates a new queue.
@@ -34,6 +36,13 @@ func New(workerCount int, handler Handler) *Queue {
 	}
 }
 
+// WithEnqueueHook sets a function called with each job accepted by Enqueue.
+// It runs on the caller's goroutine and should not block.
+func (q *Queue) WithEnqueueHook(hook func(Job)) *Queue {
+	q.onEnqueue = hook
+	return q
+}
+
 // Start launches workers.
 func (q *Queue) Start(ctx context.Context, workerCount int) {
 	if workerCount < 1 {
@@ -64,6 +73,9 @@ func (q *Queue) Stop() {
 func (q *Queue) Enqueue(job Job) error {
 	select {
 	case q.jobs <- job:
+		if q.onEnqueue != nil {
+			q.onEnqueue(job)
+		}
 		return nil
 	default:
 		return errors.New("queue is full")
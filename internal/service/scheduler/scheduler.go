@@ -0,0 +1,92 @@
+// Package scheduler enqueues configured maintenance tasks on their cron schedules.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"git_sonic/internal/config"
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/internal/service/queue"
+	"git_sonic/pkg/cron"
+	"git_sonic/pkg/logging"
+)
+
+// Enqueuer accepts jobs; *queue.Queue implements it.
+type Enqueuer interface {
+	Enqueue(job queue.Job) error
+}
+
+type entry struct {
+	task     config.ScheduledTask
+	schedule cron.Schedule
+}
+
+// Scheduler turns due scheduled tasks into synthetic schedule events.
+type Scheduler struct {
+	entries []entry
+	queue   Enqueuer
+	logger  *logging.Logger
+}
+
+// New creates a scheduler for the given tasks.
+func New(tasks []config.ScheduledTask, q Enqueuer) (*Scheduler, error) {
+	s := &Scheduler{queue: q, logger: logging.Default()}
+	for _, task := range tasks {
+		schedule, err := cron.Parse(task.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("scheduled task %s: %w", task.Name, err)
+		}
+		s.entries = append(s.entries, entry{task: task, schedule: schedule})
+	}
+	return s, nil
+}
+
+// Run enqueues due tasks at the start of every minute until ctx is done.
+// Schedules are evaluated in UTC.
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		now := time.Now().UTC()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.Tick(next)
+	}
+}
+
+// Tick enqueues every task due in the minute containing t and returns the
+// number of jobs enqueued.
+func (s *Scheduler) Tick(t time.Time) int {
+	t = t.UTC().Truncate(time.Minute)
+	enqueued := 0
+	for _, entry := range s.entries {
+		if !entry.schedule.Matches(t) {
+			continue
+		}
+		if err := s.queue.Enqueue(queue.Job{Event: Event(entry.task, t)}); err != nil {
+			s.logger.Error("failed to enqueue scheduled task", "task", entry.task.Name, "repo", entry.task.Repo, "error", err)
+			continue
+		}
+		s.logger.Info("scheduled task enqueued", "task", entry.task.Name, "repo", entry.task.Repo)
+		enqueued++
+	}
+	return enqueued
+}
+
+// Event builds the synthetic event for a task run at t. The repository clone
+// URL is resolved by the engine.
+func Event(task config.ScheduledTask, t time.Time) webhook.Event {
+	return webhook.Event{
+		Type:       webhook.EventSchedule,
+		Action:     "run",
+		DeliveryID: fmt.Sprintf("schedule-%s-%s", task.Name, t.UTC().Format("20060102-1504")),
+		Repository: webhook.Repository{FullName: task.Repo},
+		Task:       task.Name,
+	}
+}
@@ -0,0 +1,198 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/pkg/github"
+	"git_sonic/pkg/logging"
+	"github.com/MimeLyc/agent-core-go/pkg/llm"
+)
+
+// AskResult is the ask-specific part of the LLM response.
+type AskResult struct {
+	Answer    string     `json:"answer"`
+	Citations []Citation `json:"citations"`
+}
+
+// Citation points at a line range backing an answer.
+type Citation struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line,omitempty"`
+}
+
+var askInstructions = strings.Join([]string{
+	"This is a question-answering run: do NOT modify any files and leave 'files' and 'patch' empty.",
+	"The question is in task_body. Use only read and search tools to investigate the working directory.",
+	"Respond with decision=proceed, a Markdown answer in 'answer', and a 'citations' array backing it.",
+	"Each citation is {\"path\": \"<file>\", \"start_line\": <n>, \"end_line\": <m>} with paths relative to the repository root.",
+	"If the repository does not contain the answer, say so instead of guessing.",
+}, "\n")
+
+// runAsk starts a question-answering workflow for an issue or PR comment.
+func (e *Engine) runAsk(ctx context.Context, event webhook.Event, number int, isPR bool, question string) error {
+	log := e.logger.With("delivery_id", event.DeliveryID, "event_type", event.Type)
+	owner, repo, err := splitFullName(event.Repository.FullName)
+	if err != nil {
+		return err
+	}
+	// Only a runner restricted to read-only tools may answer; the main runner
+	// can modify the workspace and run arbitrary commands.
+	if e.askLLM == nil {
+		log.Info("skipping ask: no read-only runner", "number", number)
+		comment := fmt.Sprintf("@%s `%s` is not supported for the configured agent.", event.Sender, e.cfg.AskCommand)
+		return e.gh.CreateIssueComment(ctx, owner, repo, number, comment)
+	}
+	if strings.TrimSpace(question) == "" {
+		log.Debug("skipping ask: empty question", "number", number)
+		comment := fmt.Sprintf("@%s add your question after `%s`.", event.Sender, e.cfg.AskCommand)
+		return e.gh.CreateIssueComment(ctx, owner, repo, number, comment)
+	}
+	e.acknowledge(ctx, event)
+
+	wfLog := e.logger.StartWorkflow("ask",
+		"number", number,
+		"repo", event.Repository.FullName,
+		"pull_request", isPR,
+		"sender", event.Sender,
+	)
+	err = e.handleAsk(ctx, event, owner, repo, number, isPR, question, wfLog)
+	wfLog.EndWorkflow(err)
+	return err
+}
+
+// handleAsk answers a question about the repository with a comment. The
+// clone is only read; nothing is committed or pushed.
+func (e *Engine) handleAsk(ctx context.Context, event webhook.Event, owner, repo string, number int, isPR bool, question string, log *logging.Logger) (err error) {
+	contextReq := llm.Request{
+		Mode:         "ask",
+		RepoFullName: event.Repository.FullName,
+		TaskBody:     question,
+		CommentBody:  event.CommentBody,
+		Requirements: "Answer the question about the codebase, citing file paths and line ranges. Do not change any files.",
+	}
+
+	// Step 1: Get issue or PR details
+	var pr github.PR
+	if isPR {
+		done := log.Step("get-pr-details", "pr", number)
+		pr, err = e.gh.GetPR(ctx, owner, repo, number)
+		if err != nil {
+			done(err)
+			return log.WrapError("get-pr-details", "GetPR", err)
+		}
+		contextReq.PRNumber, contextReq.PRTitle, contextReq.PRBody = pr.Number, pr.Title, pr.Body
+		contextReq.PRHeadRef, contextReq.PRBaseRef = pr.HeadRef, pr.BaseRef
+		done(nil)
+	} else {
+		done := log.Step("get-issue-details", "issue", number)
+		issue, err := e.gh.GetIssue(ctx, owner, repo, number)
+		if err != nil {
+			done(err)
+			return log.WrapError("get-issue-details", "GetIssue", err)
+		}
+		contextReq.IssueNumber, contextReq.IssueTitle, contextReq.IssueBody = issue.Number, issue.Title, issue.Body
+		done(nil)
+	}
+
+	// Step 2: Prepare workspace
+	done := log.Step("prepare-workspace")
+	workDir, err := e.prepareWorkspace(ctx, event.Repository, fmt.Sprintf("ask-%d", number), log)
+	if err != nil {
+		done(err)
+		return err // Already wrapped
+	}
+	defer func() { e.writeRunStatus(workDir, err) }()
+	repDir := repoDir(workDir)
+	contextReq.RepoPath = repDir
+	done(nil)
+
+	// Step 3: Checkout PR head
+	if isPR {
+		done = log.Step("checkout-branch", "branch", pr.HeadRef)
+		if err := e.git.SetRemoteAuth(ctx, repDir, e.cfg.GitHubToken); err != nil {
+			done(err)
+			return log.WrapError("checkout-branch", "SetRemoteAuth", err)
+		}
+		if _, err := e.checkoutPRHead(ctx, repDir, event.Repository.FullName, pr); err != nil {
+			done(err)
+			return log.WrapError("checkout-branch", "checkoutPRHead", err)
+		}
+		done(nil)
+	}
+
+	// Step 4: Prepare LLM prompt
+	done = log.Step("prepare-llm-prompt")
+	request, err := e.preparePrompt(workDir, contextReq)
+	if err != nil {
+		done(err)
+		return log.WrapError("prepare-llm-prompt", "preparePrompt", err)
+	}
+	done(nil)
+
+	// Step 5: Run LLM with the read-only runner
+	done = log.Step("run-llm")
+	result, err := e.askLLM.Run(ctx, request, repDir)
+	e.writeArtifacts(workDir, request, result, err)
+	if err != nil {
+		done(err)
+		return log.WrapError("run-llm", "Run", err)
+	}
+	done(nil)
+
+	// Step 6: Post answer
+	done = log.Step("post-answer")
+	answer, _ := decodeExtended(request.OutputPath, result, func(a AskResult) bool { return a.Answer != "" })
+	answer.Answer = fallback(answer.Answer, fallback(result.Response.Summary, result.Response.NeedsInfoComment))
+	answer.Citations = existingCitations(repDir, answer.Citations)
+	if err := e.gh.CreateIssueComment(ctx, owner, repo, number, askComment(event.Sender, answer)); err != nil {
+		done(err)
+		return log.WrapError("post-answer", "CreateIssueComment", err)
+	}
+	done(nil)
+	return nil
+}
+
+// existingCitations drops citations whose file does not exist in the clone.
+func existingCitations(repDir string, citations []Citation) []Citation {
+	var out []Citation
+	for _, citation := range citations {
+		paths := existingFiles(repDir, []string{citation.Path})
+		if len(paths) == 0 {
+			continue
+		}
+		citation.Path = paths[0]
+		out = append(out, citation)
+	}
+	return out
+}
+
+func askComment(sender string, answer AskResult) string {
+	var sb strings.Builder
+	if sender != "" {
+		fmt.Fprintf(&sb, "@%s ", sender)
+	}
+	sb.WriteString(fallback(strings.TrimSpace(answer.Answer), "I could not find an answer to this question in the repository."))
+	if len(answer.Citations) > 0 {
+		sb.WriteString("\n\n**References:**\n")
+		for _, citation := range answer.Citations {
+			sb.WriteString("- `" + citation.String() + "`\n")
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// String formats the citation as path:Lstart-Lend.
+func (c Citation) String() string {
+	switch {
+	case c.StartLine <= 0:
+		return c.Path
+	case c.EndLine <= c.StartLine:
+		return fmt.Sprintf("%s:L%d", c.Path, c.StartLine)
+	default:
+		return fmt.Sprintf("%s:L%d-L%d", c.Path, c.StartLine, c.EndLine)
+	}
+}
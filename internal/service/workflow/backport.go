@@ -0,0 +1,298 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/pkg/github"
+	"git_sonic/pkg/gitutil"
+	"git_sonic/pkg/logging"
+)
+
+// backportResult records the outcome of backporting to one target branch.
+type backportResult struct {
+	Target   string
+	PR       github.PR
+	Resolved bool
+	Err      error
+}
+
+// handleBackportComment handles a backport command on a merged PR.
+func (e *Engine) handleBackportComment(ctx context.Context, event webhook.Event) error {
+	wfLog := e.logger.StartWorkflow("pr-backport",
+		"pr", event.Issue.Number,
+		"repo", event.Repository.FullName,
+		"sender", event.Sender,
+	)
+	err := e.handleBackport(ctx, event, wfLog)
+	wfLog.EndWorkflow(err)
+	return err
+}
+
+func (e *Engine) handleBackport(ctx context.Context, event webhook.Event, log *logging.Logger) (err error) {
+	// Step 1: Parse repository info and targets
+	done := log.Step("parse-repo-info")
+	owner, repo, err := splitFullName(event.Repository.FullName)
+	if err != nil {
+		done(err)
+		return log.WrapError("parse-repo-info", "splitFullName", err)
+	}
+	targets := backportTargets(event.CommentBody, e.cfg.BackportCommand)
+	done(nil)
+
+	var workDir string
+	defer func() {
+		var wfErr *logging.WorkflowError
+		if errors.As(err, &wfErr) {
+			target := failureTarget{number: event.Issue.Number, retry: commandRetryHint}
+			e.reportFailure(context.WithoutCancel(ctx), owner, repo, target, err, e.jobDetails(event, event.Issue.Number, workDir, log), log)
+		}
+	}()
+
+	// Backports push branches and open PRs, so they need write access like plan approvals
+	done = log.Step("check-permission", "sender", event.Sender)
+	permission, err := e.gh.GetCollaboratorPermission(ctx, owner, repo, event.Sender)
+	if err != nil {
+		done(err)
+		return log.WrapError("check-permission", "GetCollaboratorPermission", err)
+	}
+	done(nil)
+	if permission != "admin" && permission != "write" {
+		log.Warn("backport by unauthorized user", "sender", event.Sender, "permission", permission)
+		comment := fmt.Sprintf("@%s only users with write access can request backports.", event.Sender)
+		return e.gh.CreateIssueComment(ctx, owner, repo, event.Issue.Number, comment)
+	}
+	if len(targets) == 0 {
+		usage := fmt.Sprintf("Usage: `%s <branch> [<branch>...]`, e.g. `%s release-1.1 release-1.2`.", e.cfg.BackportCommand, e.cfg.BackportCommand)
+		return e.gh.CreateIssueComment(ctx, owner, repo, event.Issue.Number, usage)
+	}
+	for _, target := range targets {
+		if !validBranchName(target) {
+			return e.gh.CreateIssueComment(ctx, owner, repo, event.Issue.Number, fmt.Sprintf("Backport skipped: `%s` is not a valid branch name.", target))
+		}
+	}
+	e.acknowledge(ctx, event)
+
+	// Step 2: Get PR details
+	done = log.Step("get-pr-details", "pr", event.Issue.Number)
+	pr, err := e.gh.GetPR(ctx, owner, repo, event.Issue.Number)
+	if err != nil {
+		done(err)
+		return log.WrapError("get-pr-details", "GetPR", err)
+	}
+	done(nil)
+	if !pr.Merged || pr.MergeCommitSHA == "" {
+		log.Info("PR is not merged, skipping backport", "state", pr.State)
+		return e.gh.CreateIssueComment(ctx, owner, repo, pr.Number, "Backport skipped: only merged pull requests can be backported.")
+	}
+
+	// Step 3: Prepare workspace
+	done = log.Step("prepare-workspace")
+	workDir, err = e.prepareWorkspace(ctx, event.Repository, fmt.Sprintf("backport-%d", pr.Number), log)
+	if err != nil {
+		done(err)
+		return err // Already wrapped
+	}
+	defer func() { e.writeRunStatus(workDir, err) }()
+	repDir := repoDir(workDir)
+	done(nil)
+
+	// Step 4: Set remote auth
+	done = log.Step("set-remote-auth")
+	if err := e.git.SetRemoteAuth(ctx, repDir, e.cfg.GitHubToken); err != nil {
+		done(err)
+		return log.WrapError("set-remote-auth", "SetRemoteAuth", err)
+	}
+	done(nil)
+
+	// Step 5: Fetch the merge commit (it may be missing from shallow clones)
+	done = log.Step("fetch-merge-commit", "sha", pr.MergeCommitSHA)
+	if err := e.git.Fetch(ctx, repDir, "origin", pr.MergeCommitSHA); err != nil {
+		done(err)
+		return log.WrapError("fetch-merge-commit", "Fetch", err)
+	}
+	done(nil)
+
+	// Step 6: Find the commits the PR landed
+	done = log.Step("find-pr-commits")
+	commits := e.backportCommits(ctx, repDir, pr, log)
+	log.Info("commits to backport", "count", len(commits))
+	done(nil)
+
+	// Step 7: Backport to each target; failures do not stop the remaining targets
+	results := make([]backportResult, 0, len(targets))
+	var errs []error
+	for _, target := range targets {
+		result := e.backportTo(ctx, owner, repo, workDir, pr, commits, target, event.Sender, log)
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("backport to %s: %w", target, result.Err))
+		}
+		results = append(results, result)
+	}
+
+	// Step 8: Post summary comment
+	done = log.Step("post-completion-comment")
+	if err := e.gh.CreateIssueComment(ctx, owner, repo, pr.Number, backportComment(results)); err != nil {
+		done(err)
+		return log.WrapError("post-completion-comment", "CreateIssueComment", err)
+	}
+	done(nil)
+
+	return errors.Join(errs...)
+}
+
+// backportCommits returns the commits to cherry-pick for a merged PR, oldest
+// first. Merge and squash merges carry the whole PR in one commit, which is
+// picked on its own. A rebase merge lands the PR's commits rewritten one by
+// one, so the PR's own commits are picked instead; when they cannot be found
+// the merge commit is used.
+func (e *Engine) backportCommits(ctx context.Context, repDir string, pr github.PR, log *logging.Logger) []string {
+	single := []string{pr.MergeCommitSHA}
+	parents, err := e.git.CommitParents(ctx, repDir, pr.MergeCommitSHA)
+	if err != nil || len(parents) > 1 || pr.HeadSHA == "" {
+		return single
+	}
+	if err := e.git.Fetch(ctx, repDir, "origin", fmt.Sprintf("pull/%d/head", pr.Number)); err != nil {
+		log.Warn("failed to fetch the PR head, backporting the merge commit", "error", err)
+		return single
+	}
+	base, err := e.git.MergeBase(ctx, repDir, pr.HeadSHA, pr.MergeCommitSHA)
+	if err != nil {
+		log.Warn("failed to find where the PR branched off, backporting the merge commit", "error", err)
+		return single
+	}
+	// A squash merge adds a single commit after the branch point.
+	landed, err := e.git.CommitsBetween(ctx, repDir, base, pr.MergeCommitSHA)
+	if err != nil || len(landed) <= 1 {
+		return single
+	}
+	commits, err := e.git.CommitsBetween(ctx, repDir, base, pr.HeadSHA)
+	if err != nil || len(commits) == 0 {
+		log.Warn("failed to list the PR commits, backporting the merge commit", "error", err)
+		return single
+	}
+	return commits
+}
+
+// backportTo cherry-picks commits onto a new branch from target and opens a
+// backport PR.
+func (e *Engine) backportTo(ctx context.Context, owner, repo, workDir string, pr github.PR, commits []string, target, sender string, log *logging.Logger) backportResult {
+	repDir := repoDir(workDir)
+	result := backportResult{Target: target}
+
+	done := log.Step("backport", "target", target)
+	if err := e.git.Fetch(ctx, repDir, "origin", target); err != nil {
+		done(err)
+		result.Err = err
+		return result
+	}
+	branch, err := e.prBranchName(owner+"/"+repo, pr, sender, target)
+	if err == nil {
+		branch, err = e.unusedBranchName(ctx, repDir, branch)
+	}
+	if err != nil {
+		done(err)
+		result.Err = err
+		return result
+	}
+	log.Info("using branch", "branch", branch)
+	if err := e.git.CheckoutBranch(ctx, repDir, branch, "origin/"+target); err != nil {
+		done(err)
+		result.Err = err
+		return result
+	}
+	// LLM conflict resolutions must respect the target branch's CODEOWNERS
+	owners := e.loadCodeOwners(ctx, repDir, "origin/"+target, log)
+	task := fmt.Sprintf("Backport of #%d (%s) to %s.\n\n%s", pr.Number, pr.Title, target, pr.Body)
+	for i, commit := range commits {
+		pickErr := e.git.CherryPick(ctx, repDir, commit)
+		if err := e.resolveInProgress(ctx, workDir, pickErr, conflictOps{
+			stage:  fmt.Sprintf("backport-%s-%d-conflicts", slugify(target), i+1),
+			repo:   owner + "/" + repo,
+			owners: owners,
+			resume: func() error { return e.git.ContinueCherryPick(ctx, repDir) },
+			abort:  func() error { return e.git.AbortCherryPick(ctx, repDir) },
+		}, task, log); err != nil {
+			done(err)
+			result.Err = err
+			return result
+		}
+		// Only conflicts that were actually resolved count.
+		var conflict *gitutil.ConflictError
+		if errors.As(pickErr, &conflict) {
+			result.Resolved = true
+		}
+	}
+	if err := e.git.Push(ctx, repDir, branch); err != nil {
+		done(err)
+		result.Err = err
+		return result
+	}
+	body := fmt.Sprintf("Backport of #%d to `%s`.\n\nCherry-picked from %s.", pr.Number, target, strings.Join(commits, ", "))
+	if result.Resolved {
+		body += "\n\nConflicts were resolved automatically; please review them carefully."
+	}
+	backport, err := e.gh.CreatePR(ctx, owner, repo, github.PRRequest{
+		Title: fmt.Sprintf("[%s] %s", target, pr.Title),
+		Body:  body,
+		Head:  branch,
+		Base:  target,
+	})
+	if err != nil {
+		done(err)
+		result.Err = err
+		return result
+	}
+	log.Info("backport PR created", "target", target, "pr", backport.Number, "url", backport.URL)
+	done(nil)
+	result.PR = backport
+	return result
+}
+
+// backportTargets returns the branches listed after the backport command. It
+// returns nil when the command is absent and an empty slice when no branches follow it.
+func backportTargets(body, command string) []string {
+	if command == "" {
+		return nil
+	}
+	for _, line := range strings.Split(body, "\n") {
+		fields := strings.Fields(line)
+		for i, field := range fields {
+			if field != command {
+				continue
+			}
+			targets := []string{}
+			for _, target := range fields[i+1:] {
+				if !contains(target, targets) {
+					targets = append(targets, target)
+				}
+			}
+			return targets
+		}
+	}
+	return nil
+}
+
+func backportComment(results []backportResult) string {
+	var sb strings.Builder
+	sb.WriteString("Backport results:\n\n")
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			var conflict *gitutil.ConflictError
+			if errors.As(result.Err, &conflict) {
+				fmt.Fprintf(&sb, "- `%s`: failed, cherry-pick conflicts in %s need manual backporting\n", result.Target, "`"+strings.Join(conflict.Files, "`, `")+"`")
+			} else {
+				fmt.Fprintf(&sb, "- `%s`: failed: %s\n", result.Target, gitutil.RedactText(result.Err.Error()))
+			}
+		case result.Resolved:
+			fmt.Fprintf(&sb, "- `%s`: %s (conflicts resolved automatically)\n", result.Target, result.PR.URL)
+		default:
+			fmt.Fprintf(&sb, "- `%s`: %s\n", result.Target, result.PR.URL)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,214 @@
+package workflow
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"git_sonic/internal/config"
+	"git_sonic/pkg/github"
+)
+
+// maxSlugLength bounds the slugified issue title used in branch names.
+const maxSlugLength = 40
+
+// baseBranchSection is the issue form heading holding the base branch.
+const baseBranchSection = "base branch"
+
+// Base branch sources, in order of precedence.
+const (
+	baseSourceLabel   = "label"
+	baseSourceBody    = "issue-body"
+	baseSourceRepo    = "repo-settings"
+	baseSourceDefault = "default"
+)
+
+// Branch kinds, passed to branch name templates as .Kind.
+const (
+	branchKindIssue    = "issue"
+	branchKindFollowUp = "follow-up"
+	branchKindBackport = "backport"
+)
+
+// defaultBranchTemplates name the branches of each kind when neither
+// BRANCH_TEMPLATE nor the repository's branch_template is set.
+var defaultBranchTemplates = map[string]string{
+	branchKindIssue:    config.DefaultBranchTemplate,
+	branchKindFollowUp: "llm/pr-{{.Number}}-{{.Timestamp}}",
+	branchKindBackport: "llm/backport-{{.Number}}-{{.Target}}-{{.Timestamp}}",
+}
+
+// branchData is the data available to branch name templates. Number and Slug
+// describe the issue, or the PR for follow-up and backport branches; Target
+// is the target branch of a backport.
+type branchData struct {
+	Kind      string
+	Number    int
+	Slug      string
+	Sender    string
+	Target    string
+	Timestamp string
+}
+
+var (
+	slugInvalid   = regexp.MustCompile(`[^a-z0-9]+`)
+	refComponent  = regexp.MustCompile(`^[A-Za-z0-9._/-]+$`)
+	refForbidden  = []string{"..", "//", "/.", ".lock/"}
+	bodyFieldLine = regexp.MustCompile(`(?i)^(?:[-*]\s*)?\**base[ _-]branch\**\s*:\s*(.+)$`)
+)
+
+// issueBranchName renders the branch name for an issue from the repository's
+// branch template and validates the result.
+func (e *Engine) issueBranchName(repoFullName string, issue github.Issue, sender string) (string, error) {
+	return e.branchName(repoFullName, branchData{Kind: branchKindIssue, Number: issue.Number, Slug: slugify(issue.Title), Sender: slugify(sender)})
+}
+
+// prBranchName renders the name of a follow-up (target empty) or backport
+// branch for a PR.
+func (e *Engine) prBranchName(repoFullName string, pr github.PR, sender, target string) (string, error) {
+	data := branchData{Kind: branchKindFollowUp, Number: pr.Number, Slug: slugify(pr.Title), Sender: slugify(sender)}
+	if target != "" {
+		data.Kind, data.Target = branchKindBackport, target
+	}
+	return e.branchName(repoFullName, data)
+}
+
+// branchName renders a branch name from the repository's branch template, or
+// the default template of data.Kind when none is configured, and validates
+// the result.
+func (e *Engine) branchName(repoFullName string, data branchData) (string, error) {
+	text := e.cfg.ForRepo(repoFullName).BranchTemplate
+	if text == "" && e.cfg.BranchTemplate != config.DefaultBranchTemplate {
+		text = e.cfg.BranchTemplate
+	}
+	if text == "" {
+		text = defaultBranchTemplates[data.Kind]
+	}
+	tmpl, err := template.New("branch").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("branch template is invalid: %w", err)
+	}
+	var sb strings.Builder
+	data.Timestamp = e.now().Format("20060102-150405")
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("branch template failed: %w", err)
+	}
+	name := strings.Trim(sb.String(), "/-")
+	if !validBranchName(name) {
+		return "", fmt.Errorf("branch template produced invalid branch name %q", name)
+	}
+	return name, nil
+}
+
+// maxBranchSuffix bounds the numeric suffixes tried by unusedBranchName.
+const maxBranchSuffix = 100
+
+// unusedBranchName returns name, or name with a "-2", "-3", ... suffix when a
+// branch of that name already exists on origin. Templates without a timestamp
+// render the same name on every retry.
+func (e *Engine) unusedBranchName(ctx context.Context, repDir, name string) (string, error) {
+	for i := 1; i <= maxBranchSuffix; i++ {
+		candidate := name
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-%d", name, i)
+		}
+		exists, err := e.git.RemoteBranchExists(ctx, repDir, "origin", candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("branches %s through %s-%d already exist", name, name, maxBranchSuffix)
+}
+
+// requestedBaseBranch returns the base branch selected for an issue by a base
+// label, a "Base branch:" field in the issue body, or the repository settings,
+// in that order of precedence. An empty branch means the default branch.
+func (e *Engine) requestedBaseBranch(repoFullName string, issue github.Issue) (branch, source string, err error) {
+	switch {
+	case e.baseFromLabels(issue.Labels) != "":
+		branch, source = e.baseFromLabels(issue.Labels), baseSourceLabel
+	case baseFromBody(issue.Body) != "":
+		branch, source = baseFromBody(issue.Body), baseSourceBody
+	case e.cfg.ForRepo(repoFullName).BaseBranch != "":
+		branch, source = e.cfg.ForRepo(repoFullName).BaseBranch, baseSourceRepo
+	default:
+		return "", baseSourceDefault, nil
+	}
+	if !validBranchName(branch) {
+		return "", source, fmt.Errorf("base branch %q from %s is not a valid branch name", branch, source)
+	}
+	return branch, source, nil
+}
+
+// baseFromLabels returns the branch named by the first base label, if any.
+func (e *Engine) baseFromLabels(labels []string) string {
+	prefix := e.cfg.BaseLabelPrefix
+	if prefix == "" {
+		return ""
+	}
+	for _, label := range labels {
+		if len(label) > len(prefix) && strings.EqualFold(label[:len(prefix)], prefix) {
+			return strings.TrimSpace(label[len(prefix):])
+		}
+	}
+	return ""
+}
+
+// baseFromBody returns the base branch from an issue body field. Both an
+// inline "Base branch: release-1.2" line and an issue form section
+// ("### Base branch" followed by the value) are recognized.
+func baseFromBody(body string) string {
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	inSection := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if match := bodyFieldLine.FindStringSubmatch(line); match != nil {
+			return strings.Trim(match[1], "`* ")
+		}
+		if strings.HasPrefix(line, "#") {
+			inSection = strings.EqualFold(strings.TrimSpace(strings.TrimLeft(line, "#")), baseBranchSection)
+			continue
+		}
+		if inSection && line != "" {
+			if line == "_No response_" {
+				return ""
+			}
+			return strings.Trim(line, "`")
+		}
+	}
+	return ""
+}
+
+// slugify lowercases text and replaces runs of other characters with "-".
+func slugify(text string) string {
+	slug := strings.Trim(slugInvalid.ReplaceAllString(strings.ToLower(text), "-"), "-")
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+	return slug
+}
+
+// validBranchName reports whether name is a safe git branch name. It is
+// stricter than git check-ref-format: names must not start with "-" so they
+// cannot be mistaken for options.
+func validBranchName(name string) bool {
+	if name == "" || len(name) > 200 || !refComponent.MatchString(name) {
+		return false
+	}
+	if strings.HasPrefix(name, "-") || strings.HasPrefix(name, "/") || strings.HasPrefix(name, ".") ||
+		strings.HasSuffix(name, "/") || strings.HasSuffix(name, ".") || strings.HasSuffix(name, ".lock") {
+		return false
+	}
+	for _, bad := range refForbidden {
+		if strings.Contains(name+"/", bad) {
+			return false
+		}
+	}
+	return true
+}
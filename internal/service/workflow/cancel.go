@@ -0,0 +1,212 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/pkg/logging"
+)
+
+// CancelledError is the cause of a run that was cancelled while in flight.
+type CancelledError struct {
+	// Reason says what cancelled the run, e.g. "the issue was closed".
+	Reason string
+}
+
+func (e *CancelledError) Error() string {
+	return "run cancelled: " + e.Reason
+}
+
+// cancellation returns the reason ctx was cancelled, or nil if it was not
+// cancelled through the run registry.
+func cancellation(ctx context.Context) *CancelledError {
+	var cancelled *CancelledError
+	if errors.As(context.Cause(ctx), &cancelled) {
+		return cancelled
+	}
+	return nil
+}
+
+// runRegistry tracks the cancel functions of in-flight runs by target
+// ("owner/repo#number").
+type runRegistry struct {
+	mu   sync.Mutex
+	next int
+	runs map[string]map[int]context.CancelCauseFunc
+}
+
+func newRunRegistry() *runRegistry {
+	return &runRegistry{runs: map[string]map[int]context.CancelCauseFunc{}}
+}
+
+func runTarget(repoFullName string, number int) string {
+	return fmt.Sprintf("%s#%d", repoFullName, number)
+}
+
+// track returns a context for a run on target that cancel can stop, and the
+// release function to call when the run ends. A nil registry tracks nothing.
+func (r *runRegistry) track(ctx context.Context, target string) (context.Context, func()) {
+	if r == nil {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := r.next
+	r.next++
+	if r.runs[target] == nil {
+		r.runs[target] = map[int]context.CancelCauseFunc{}
+	}
+	r.runs[target][id] = cancel
+	return ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.runs[target], id)
+		if len(r.runs[target]) == 0 {
+			delete(r.runs, target)
+		}
+		cancel(nil)
+	}
+}
+
+// active reports whether a run on target is in flight.
+func (r *runRegistry) active(target string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.runs[target]) > 0
+}
+
+// cancel stops the runs on target with cause and returns how many there were.
+func (r *runRegistry) cancel(target string, cause error) int {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, cancel := range r.runs[target] {
+		cancel(cause)
+	}
+	return len(r.runs[target])
+}
+
+// HandleCancel cancels the run in flight on the event's issue or PR when the
+// issue is closed, its trigger or in-progress label is removed, or a user
+// with write access comments the cancel command. It is meant to be called
+// when the event arrives, whether or not it can be enqueued: the worker that
+// would handle the event may be the one busy with the run. It reports whether
+// a run was cancelled.
+func (e *Engine) HandleCancel(ctx context.Context, event webhook.Event) bool {
+	var number int
+	switch {
+	case event.Issue != nil:
+		number = event.Issue.Number
+	case event.PullRequest != nil:
+		number = event.PullRequest.Number
+	default:
+		return false
+	}
+	target := runTarget(event.Repository.FullName, number)
+	if !e.runs.active(target) {
+		return false
+	}
+	log := e.logger.With("delivery_id", event.DeliveryID, "target", target)
+
+	reason, err := e.cancelReason(ctx, event)
+	if err != nil {
+		log.Warn("failed to check cancel request", "error", err)
+		return false
+	}
+	if reason == "" {
+		return false
+	}
+	n := e.runs.cancel(target, &CancelledError{Reason: reason})
+	log.Info("run cancelled", "reason", reason, "runs", n)
+	if n == 0 {
+		return false
+	}
+	// The cancel command's own job does nothing, so its comment gets both
+	// reactions here.
+	e.forRepository(event.Repository.FullName).acknowledge(ctx, event)
+	e.ReportResult(ctx, event, nil)
+	return true
+}
+
+// cancelReason returns why event cancels the run on its target, or "" if it
+// does not.
+func (e *Engine) cancelReason(ctx context.Context, event webhook.Event) (string, error) {
+	switch event.Type {
+	case webhook.EventIssues:
+		if event.Issue == nil {
+			return "", nil
+		}
+		switch event.Action {
+		case "closed":
+			return "the issue was closed", nil
+		case "unlabeled":
+			// The engine swaps labels in a single update, so its own removals
+			// arrive with the next status label already set.
+			labels := event.Issue.Labels
+			if event.Label == e.cfg.InProgressLabel && event.Label != "" &&
+				!contains(e.cfg.DoneLabel, labels) && !contains(e.cfg.NeedsInfoLabel, labels) && !contains(e.cfg.PlanPendingLabel, labels) &&
+				!contains(e.cfg.FailedLabel, labels) {
+				return fmt.Sprintf("@%s removed the `%s` label", event.Sender, event.Label), nil
+			}
+			if contains(event.Label, e.cfg.TriggerLabels) && !contains(e.cfg.InProgressLabel, labels) && !contains(e.cfg.FailedLabel, labels) {
+				return fmt.Sprintf("@%s removed the `%s` label", event.Sender, event.Label), nil
+			}
+		}
+		return "", nil
+	case webhook.EventIssueComment, webhook.EventPRComment:
+		if event.Action != "created" {
+			return "", nil
+		}
+		if _, ok := commandArgs(event.CommentBody, e.cfg.CancelCommand); !ok {
+			return "", nil
+		}
+		owner, repo, err := splitFullName(event.Repository.FullName)
+		if err != nil {
+			return "", err
+		}
+		permission, err := e.gh.GetCollaboratorPermission(ctx, owner, repo, event.Sender)
+		if err != nil {
+			return "", err
+		}
+		if permission != "admin" && permission != "write" {
+			e.logger.Warn("cancel by unauthorized user", "sender", event.Sender, "permission", permission)
+			return "", nil
+		}
+		return fmt.Sprintf("@%s requested it with `%s`", event.Sender, e.cfg.CancelCommand), nil
+	}
+	return "", nil
+}
+
+// reportCancelled resets the status labels of a cancelled issue run and posts
+// a comment explaining the cancellation. The labels are re-read first, since
+// the run and the people who cancelled it may have changed them.
+func (e *Engine) reportCancelled(ctx context.Context, owner, repo string, number int, issueRun bool, cancelled *CancelledError, log *logging.Logger) {
+	log.Info("run cancelled", "reason", cancelled.Reason)
+	if issueRun {
+		issue, err := e.gh.GetIssue(ctx, owner, repo, number)
+		if err != nil {
+			log.Warn("failed to read labels after cancellation", "error", err)
+		} else {
+			labelsToRemove := append([]string{e.cfg.InProgressLabel}, e.cfg.TriggerLabels...)
+			if err := e.gh.SetIssueLabels(ctx, owner, repo, number, updateProgressLabels(issue.Labels, "", labelsToRemove...)); err != nil {
+				log.Warn("failed to reset labels after cancellation", "error", err)
+			}
+		}
+	}
+	comment := fmt.Sprintf("Run cancelled: %s. No further changes will be made.", cancelled.Reason)
+	if issueRun && len(e.cfg.TriggerLabels) > 0 {
+		comment += fmt.Sprintf(" Add the `%s` label to start a new run.", e.cfg.TriggerLabels[0])
+	}
+	if err := e.gh.CreateIssueComment(ctx, owner, repo, number, comment); err != nil {
+		log.Warn("failed to post cancellation comment", "error", err)
+	}
+}
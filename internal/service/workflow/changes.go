@@ -0,0 +1,212 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"git_sonic/pkg/logging"
+)
+
+// Change artifacts written to the outputs subdirectory.
+const (
+	// ChangesDiffFile holds the diff of the changes a run made.
+	ChangesDiffFile = "changes.diff"
+	// ChangesStatsFile holds the per-file statistics of that diff.
+	ChangesStatsFile = "changes.json"
+)
+
+// FileStat counts the lines a change adds to and removes from one file.
+type FileStat struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Binary  bool   `json:"binary,omitempty"`
+}
+
+// DiffStats summarizes a diff.
+type DiffStats struct {
+	Files   []FileStat `json:"files"`
+	Added   int        `json:"added"`
+	Removed int        `json:"removed"`
+}
+
+// Lines returns the number of changed lines.
+func (s DiffStats) Lines() int {
+	return s.Added + s.Removed
+}
+
+// Paths returns the paths of the changed files.
+func (s DiffStats) Paths() []string {
+	paths := make([]string, 0, len(s.Files))
+	for _, f := range s.Files {
+		paths = append(paths, f.Path)
+	}
+	return paths
+}
+
+// diffFile is one file section of a unified git diff.
+type diffFile struct {
+	path    string
+	deleted bool
+	binary  bool
+	added   []string
+	removed []string
+}
+
+// parseDiff splits a unified git diff into files and their changed lines.
+func parseDiff(diff string) []diffFile {
+	var files []diffFile
+	var current *diffFile
+	inHunk := false
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, diffFile{path: diffPath(line)})
+			current = &files[len(files)-1]
+			inHunk = false
+		case current == nil:
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case !inHunk:
+			switch {
+			case strings.HasPrefix(line, "deleted file mode"):
+				current.deleted = true
+			case strings.HasPrefix(line, "Binary files "), line == "GIT binary patch":
+				current.binary = true
+			}
+		case strings.HasPrefix(line, "+"):
+			current.added = append(current.added, line[1:])
+		case strings.HasPrefix(line, "-"):
+			current.removed = append(current.removed, line[1:])
+		}
+	}
+	return files
+}
+
+// parseDiffStats counts added and removed lines per file in a unified git diff.
+func parseDiffStats(diff string) DiffStats {
+	var stats DiffStats
+	for _, f := range parseDiff(diff) {
+		stats.Files = append(stats.Files, FileStat{Path: f.path, Added: len(f.added), Removed: len(f.removed), Binary: f.binary})
+		stats.Added += len(f.added)
+		stats.Removed += len(f.removed)
+	}
+	return stats
+}
+
+// diffPath returns the destination path of a "diff --git a/x b/y" header.
+func diffPath(header string) string {
+	paths := strings.TrimPrefix(header, "diff --git ")
+	if i := strings.LastIndex(paths, " b/"); i >= 0 {
+		return paths[i+3:]
+	}
+	return paths
+}
+
+// recordChanges writes the diff from the merge-base of base and HEAD to the
+// working tree, and its statistics, to outputs/ and returns both. Diffing
+// against the merge-base rather than HEAD covers commits the agent made itself.
+func (e *Engine) recordChanges(ctx context.Context, workDir, base string, log *logging.Logger) (string, DiffStats, error) {
+	repDir := repoDir(workDir)
+	mergeBase, err := e.git.MergeBase(ctx, repDir, base, "HEAD")
+	if err != nil {
+		return "", DiffStats{}, err
+	}
+	diff, err := e.git.Diff(ctx, repDir, mergeBase)
+	if err != nil {
+		return "", DiffStats{}, err
+	}
+	stats := parseDiffStats(diff)
+	outDir := outputsDir(workDir)
+	if err := e.writeFile(filepath.Join(outDir, ChangesDiffFile), []byte(diff)); err != nil {
+		log.Warn("failed to write diff artifact", "error", err)
+	}
+	if data, err := json.MarshalIndent(stats, "", "  "); err == nil {
+		_ = e.writeFile(filepath.Join(outDir, ChangesStatsFile), data)
+	}
+	log.Info("changes recorded", "files", len(stats.Files), "added", stats.Added, "removed", stats.Removed)
+	return diff, stats, nil
+}
+
+// readChangeStats loads the statistics written by recordChanges, if any.
+func readChangeStats(workDir string) *DiffStats {
+	data, err := os.ReadFile(filepath.Join(outputsDir(workDir), ChangesStatsFile))
+	if err != nil {
+		return nil
+	}
+	var stats DiffStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil
+	}
+	return &stats
+}
+
+// changeLimitExceeded explains which MAX_CHANGED_* limit stats exceed, or
+// returns "" when they are within limits.
+func (e *Engine) changeLimitExceeded(stats DiffStats) string {
+	if max := e.cfg.MaxChangedFiles; max > 0 && len(stats.Files) > max {
+		return fmt.Sprintf("The proposed change touches %d files, more than the limit of %d. Please split the issue into smaller pieces or narrow its scope.", len(stats.Files), max)
+	}
+	if max := e.cfg.MaxChangedLines; max > 0 && stats.Lines() > max {
+		return fmt.Sprintf("The proposed change modifies %d lines, more than the limit of %d. Please split the issue into smaller pieces or narrow its scope.", stats.Lines(), max)
+	}
+	return ""
+}
+
+// changesSummaryStart opens the summary rendered by changesSummary.
+const changesSummaryStart = "<details>\n<summary>Changed "
+
+// changesSummary renders a collapsible table of changed files for PR bodies.
+func changesSummary(stats DiffStats) string {
+	if len(stats.Files) == 0 {
+		return ""
+	}
+	noun := "files"
+	if len(stats.Files) == 1 {
+		noun = "file"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%d %s (+%d −%d)</summary>\n\n", changesSummaryStart, len(stats.Files), noun, stats.Added, stats.Removed)
+	b.WriteString("| File | Added | Removed |\n|------|------:|--------:|\n")
+	for _, f := range stats.Files {
+		if f.Binary {
+			fmt.Fprintf(&b, "| `%s` | binary | |\n", f.Path)
+			continue
+		}
+		fmt.Fprintf(&b, "| `%s` | %d | %d |\n", f.Path, f.Added, f.Removed)
+	}
+	b.WriteString("\n</details>")
+	return b.String()
+}
+
+// withChangesSummary appends the changed files summary to a PR body,
+// replacing the one an earlier run added.
+func withChangesSummary(body string, stats DiffStats) string {
+	body = stripChangesSummary(body)
+	summary := changesSummary(stats)
+	if summary == "" {
+		return body
+	}
+	return strings.TrimSpace(strings.TrimSpace(body) + "\n\n" + summary)
+}
+
+// stripChangesSummary removes a summary added by withChangesSummary from body.
+func stripChangesSummary(body string) string {
+	start := strings.Index(body, changesSummaryStart)
+	if start < 0 {
+		return body
+	}
+	end := strings.Index(body[start:], "</details>")
+	if end < 0 {
+		return body
+	}
+	rest := strings.TrimSpace(body[start+end+len("</details>"):])
+	if rest == "" {
+		return strings.TrimSpace(body[:start])
+	}
+	return strings.TrimSpace(strings.TrimSpace(body[:start]) + "\n\n" + rest)
+}
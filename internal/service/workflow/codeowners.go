@@ -0,0 +1,70 @@
+package workflow
+
+import (
+	"context"
+	"strings"
+
+	"git_sonic/pkg/codeowners"
+	"git_sonic/pkg/logging"
+)
+
+// loadCodeOwners reads CODEOWNERS from the base ref of the change (e.g.
+// origin/main) rather than from the working tree, which the agent or a PR
+// head may have changed. A missing or unreadable file yields an empty
+// ruleset.
+func (e *Engine) loadCodeOwners(ctx context.Context, repDir, base string, log *logging.Logger) codeowners.Ruleset {
+	owners, err := codeowners.LoadFrom(func(path string) (string, bool, error) {
+		return e.git.ShowFile(ctx, repDir, base, path)
+	})
+	if err != nil {
+		log.Warn("failed to load CODEOWNERS", "error", err)
+		return codeowners.Ruleset{}
+	}
+	if !owners.Empty() {
+		log.Debug("loaded CODEOWNERS", "path", owners.Path, "rules", len(owners.Rules))
+	}
+	return owners
+}
+
+// protectedChanges returns the changed paths owned by the repository's protected
+// owners. Changes to any CODEOWNERS file are always protected, since they could
+// otherwise hand protected paths to someone else.
+func (e *Engine) protectedChanges(repoFullName string, owners codeowners.Ruleset, files []string) []string {
+	protected := e.cfg.ForRepo(repoFullName).ProtectedOwners
+	if len(protected) == 0 {
+		return nil
+	}
+	var out, rest []string
+	for _, file := range files {
+		if codeowners.IsCodeOwnersFile(file) {
+			out = append(out, file)
+		} else {
+			rest = append(rest, file)
+		}
+	}
+	return append(out, owners.PathsOwnedBy(rest, protected)...)
+}
+
+// requestCodeOwnerReviews requests reviews from the owners of the changed files.
+// Failures are logged and do not fail the workflow.
+func (e *Engine) requestCodeOwnerReviews(ctx context.Context, owner, repo string, prNumber int, owners codeowners.Ruleset, files []string, log *logging.Logger) {
+	users, teams := codeowners.SplitReviewers(owners.OwnersOf(files))
+	if len(users) == 0 && len(teams) == 0 {
+		log.Debug("no CODEOWNERS reviewers for changed files")
+		return
+	}
+	if err := e.gh.RequestReviewers(ctx, owner, repo, prNumber, users, teams); err != nil {
+		log.Warn("failed to request reviewers", "users", users, "teams", teams, "error", err)
+		return
+	}
+	log.Info("requested reviewers", "users", users, "teams", teams)
+}
+
+func protectedPathsComment(paths []string) string {
+	var sb strings.Builder
+	sb.WriteString("Automation stopped: the proposed changes touch paths owned by protected code owners, which must be changed by a human.\n\n")
+	for _, path := range paths {
+		sb.WriteString("- `" + path + "`\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,180 @@
+package workflow
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"git_sonic/pkg/codeowners"
+	"git_sonic/pkg/gitutil"
+	"git_sonic/pkg/logging"
+	"github.com/MimeLyc/agent-core-go/pkg/llm"
+)
+
+// maxConflictRounds bounds LLM conflict resolution passes per rebase.
+const maxConflictRounds = 3
+
+// pushWithRebase pushes branch to remote after rebasing onto the latest remote
+// branch. Rejected pushes are retried after another rebase; pushes are never forced.
+// Conflict resolutions must pass the guardrails of repoFullName, with owners
+// read from the base branch.
+// Returns *gitutil.ConflictError when the rebase needs manual intervention.
+func (e *Engine) pushWithRebase(ctx context.Context, workDir, repoFullName, remote, branch, task string, owners codeowners.Ruleset, log *logging.Logger) error {
+	repDir := repoDir(workDir)
+	attempts := max(e.cfg.PushMaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		if err := e.rebaseOnRemote(ctx, workDir, repoFullName, remote, branch, task, owners, log); err != nil {
+			return err
+		}
+		err := e.git.PushTo(ctx, repDir, remote, branch)
+		if err == nil {
+			return nil
+		}
+		if !gitutil.IsPushRejected(err) || attempt >= attempts {
+			return err
+		}
+		log.Warn("push rejected, rebasing onto remote branch and retrying", "remote", remote, "branch", branch, "attempt", attempt)
+	}
+}
+
+// rebaseOnRemote fetches <remote>/<branch> and rebases the local branch onto it.
+// Conflicts are resolved by the LLM when enabled; otherwise, or when
+// resolution fails, the rebase is aborted and the conflict returned.
+func (e *Engine) rebaseOnRemote(ctx context.Context, workDir, repoFullName, remote, branch, task string, owners codeowners.Ruleset, log *logging.Logger) error {
+	repDir := repoDir(workDir)
+	if err := e.git.Fetch(ctx, repDir, remote, branch); err != nil {
+		return err
+	}
+	err := e.git.Rebase(ctx, repDir, remote+"/"+branch)
+	return e.resolveInProgress(ctx, workDir, err, conflictOps{
+		stage:  "conflicts",
+		repo:   repoFullName,
+		owners: owners,
+		resume: func() error { return e.git.ContinueRebase(ctx, repDir) },
+		abort:  func() error { return e.git.AbortRebase(ctx, repDir) },
+	}, task, log)
+}
+
+// conflictOps continues or aborts a merge-like operation stopped on conflicts.
+type conflictOps struct {
+	// stage prefixes the outputs/ directories holding resolution artifacts.
+	stage string
+	// repo and owners select the guardrails resolutions must pass.
+	repo   string
+	owners codeowners.Ruleset
+	resume func() error
+	abort  func() error
+}
+
+// resolveInProgress resolves the conflicts of an operation that returned err,
+// continuing it after each LLM resolution round. When LLM resolution is
+// disabled or fails, the operation is aborted and the conflict returned.
+func (e *Engine) resolveInProgress(ctx context.Context, workDir string, err error, ops conflictOps, task string, log *logging.Logger) error {
+	for round := 1; err != nil; round++ {
+		var conflict *gitutil.ConflictError
+		if !errors.As(err, &conflict) {
+			return err
+		}
+		log.Warn("operation stopped on conflicts", "operation", conflict.Op, "files", conflict.Files, "round", round)
+		if !e.cfg.ConflictResolutionLLM || round > maxConflictRounds {
+			_ = ops.abort()
+			return conflict
+		}
+		if resolveErr := e.resolveConflicts(ctx, workDir, conflict, task, ops, round, log); resolveErr != nil {
+			log.Warn("LLM conflict resolution failed", "error", resolveErr)
+			_ = ops.abort()
+			return conflict
+		}
+		err = ops.resume()
+	}
+	return nil
+}
+
+// resolveConflicts asks the LLM to resolve conflict markers in the conflicted
+// files and stages the result. The staged commit must then pass the same
+// policy rules and CODEOWNERS protections as any agent change. Artifacts are
+// written to outputs/<stage>-<round>.
+func (e *Engine) resolveConflicts(ctx context.Context, workDir string, conflict *gitutil.ConflictError, task string, ops conflictOps, round int, log *logging.Logger) error {
+	repDir := repoDir(workDir)
+	outDir := filepath.Join(outputsDir(workDir), fmt.Sprintf("%s-%d", ops.stage, round))
+	contextReq := llm.Request{
+		Mode:     "resolve_conflicts",
+		RepoPath: repDir,
+		Metadata: map[string]string{
+			"operation":        conflict.Op,
+			"conflicted_files": strings.Join(conflict.Files, ","),
+		},
+		TaskBody:     task,
+		Requirements: "Resolve the merge conflicts so that both the upstream changes and the intent of the automated change are preserved.",
+	}
+	request, err := e.preparePromptIn(outDir, repDir, contextReq)
+	if err != nil {
+		return err
+	}
+	result, err := e.llm.Run(ctx, request, repDir)
+	e.writeArtifactsTo(outDir, request, result, err)
+	if err != nil {
+		return err
+	}
+	if result.Response.Decision != llm.DecisionProceed {
+		return fmt.Errorf("LLM declined to resolve conflicts: %s", result.Response.Decision)
+	}
+	if err := e.applyChanges(ctx, workDir, result.Response, log); err != nil {
+		return err
+	}
+	for _, file := range conflict.Files {
+		if hasConflictMarkers(filepath.Join(repDir, file)) {
+			return fmt.Errorf("conflict markers remain in %s", file)
+		}
+	}
+	if err := e.git.MarkResolved(ctx, repDir, conflict.Files); err != nil {
+		return err
+	}
+	// Diff only once nothing is unmerged: marking files intent-to-add would
+	// drop their conflict state.
+	diff, err := e.git.Diff(ctx, repDir, "HEAD")
+	if err != nil {
+		return err
+	}
+	if violations := e.policyViolations(ops.repo, repDir, diff); len(violations) > 0 {
+		return fmt.Errorf("conflict resolution breaks guardrail rules: %s", strings.Join(policyRules(violations), ", "))
+	}
+	if blocked := e.protectedChanges(ops.repo, ops.owners, parseDiffStats(diff).Paths()); len(blocked) > 0 {
+		return fmt.Errorf("conflict resolution changes protected paths: %s", strings.Join(blocked, ", "))
+	}
+	log.Info("LLM resolved conflicts", "files", conflict.Files, "round", round)
+	return nil
+}
+
+// hasConflictMarkers reports whether a file still contains merge conflict markers.
+// Deleted files have none.
+func hasConflictMarkers(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "<<<<<<< ") || strings.HasPrefix(line, ">>>>>>> ") {
+			return true
+		}
+	}
+	return false
+}
+
+func conflictComment(branch string, conflict *gitutil.ConflictError) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Automation could not push to `%s`: the branch changed while the agent was running and rebasing onto it conflicts in:\n\n", branch)
+	for _, file := range conflict.Files {
+		sb.WriteString("- `" + file + "`\n")
+	}
+	sb.WriteString("\nManual intervention is needed. Resolve the conflicts or re-run the command once the branch is stable.")
+	return sb.String()
+}
@@ -0,0 +1,291 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"git_sonic/pkg/github"
+	"git_sonic/pkg/gitutil"
+	"git_sonic/pkg/logging"
+	"git_sonic/pkg/redact"
+)
+
+// Dry-run artifacts written to the outputs subdirectory.
+const (
+	// DryRunFile lists the actions a dry run skipped.
+	DryRunFile = "dry_run.json"
+	// DryRunDiffFile holds the patches that would have been pushed.
+	DryRunDiffFile = "dry_run.diff"
+)
+
+// Action is a push or GitHub write that a dry run recorded instead of performing.
+type Action struct {
+	Kind      string   `json:"kind"`
+	Repo      string   `json:"repo,omitempty"`
+	Number    int      `json:"number,omitempty"`
+	Branch    string   `json:"branch,omitempty"`
+	Base      string   `json:"base,omitempty"`
+	Title     string   `json:"title,omitempty"`
+	Body      string   `json:"body,omitempty"`
+	Labels    []string `json:"labels,omitempty"`
+	Users     []string `json:"users,omitempty"`
+	Teams     []string `json:"teams,omitempty"`
+	Event     string   `json:"event,omitempty"`
+	Comments  int      `json:"comments,omitempty"`
+	DiffBytes int      `json:"diff_bytes,omitempty"`
+}
+
+// Action kinds.
+const (
+	ActionComment   = "comment"
+	ActionLabels    = "set_labels"
+	ActionCreatePR  = "create_pr"
+	ActionUpdatePR  = "update_pr_body"
+	ActionAssignees = "add_assignees"
+	ActionReviewers = "request_reviewers"
+	ActionReview    = "submit_review"
+	ActionPush      = "push"
+)
+
+const (
+	// ActionEditComment is an edit of an existing comment, such as the status comment.
+	ActionEditComment = "edit_comment"
+	// ActionReaction is a reaction added to a triggering comment.
+	ActionReaction = "add_reaction"
+)
+
+// dryRunRecorder collects the actions skipped during one run.
+type dryRunRecorder struct {
+	log      *logging.Logger
+	redactor *redact.Redactor
+	mu       sync.Mutex
+	actions  []Action
+	diffs    []string
+}
+
+func (r *dryRunRecorder) record(action Action, diff string) {
+	r.log.Info("dry run: skipped "+action.Kind, "repo", action.Repo, "number", action.Number, "branch", action.Branch)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if diff != "" {
+		action.DiffBytes = len(diff)
+		r.diffs = append(r.diffs, diff)
+	}
+	r.actions = append(r.actions, action)
+}
+
+// Actions returns the actions recorded so far.
+func (r *dryRunRecorder) Actions() []Action {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Action(nil), r.actions...)
+}
+
+// write stores the recorded actions and patches in outDir.
+func (r *dryRunRecorder) write(outDir string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.actions, "", "  ")
+	if err == nil {
+		_ = os.WriteFile(filepath.Join(outDir, DryRunFile), r.redactor.Bytes(data), 0o644)
+	}
+	if len(r.diffs) > 0 {
+		_ = os.WriteFile(filepath.Join(outDir, DryRunDiffFile), []byte(r.redactor.String(strings.Join(r.diffs, "\n"))), 0o644)
+	}
+}
+
+// dryRunFor reports whether dry-run mode applies to a repository.
+func (e *Engine) dryRunFor(repoFullName string) bool {
+	if dryRun := e.cfg.ForRepo(repoFullName).DryRun; dryRun != nil {
+		return *dryRun
+	}
+	return e.cfg.DryRun
+}
+
+// forRepository returns the engine a workflow for repoFullName runs with. In
+// dry-run mode it is a copy whose pushes and GitHub writes are recorded in the
+// run's outputs instead of being performed; reads and local git operations
+// are unchanged. The clone keeps no credentials, so nothing run in it can
+// push, and the dry-run runner replaces the main one when set.
+func (e *Engine) forRepository(repoFullName string) *Engine {
+	if !e.dryRunFor(repoFullName) {
+		return e
+	}
+	run := *e
+	run.dryRun = &dryRunRecorder{log: e.logger.With("dry_run", true, "repo", repoFullName), redactor: e.redactor}
+	run.gh = dryRunGitHub{GitHubClient: e.gh, rec: run.dryRun}
+	if e.redactor != nil {
+		run.gh = redactingGitHub{GitHubClient: run.gh, r: e.redactor}
+	}
+	run.git = &dryRunGit{
+		GitClient: e.git,
+		rec:       run.dryRun,
+		token:     e.cfg.GitHubToken,
+		remotes:   map[string]string{},
+		bases:     map[string]string{},
+	}
+	if e.dryRunLLM != nil {
+		run.llm = e.dryRunLLM
+	}
+	return &run
+}
+
+type dryRunGitHub struct {
+	GitHubClient
+	rec *dryRunRecorder
+}
+
+func (d dryRunGitHub) CreateIssueComment(ctx context.Context, owner, repo string, number int, body string) error {
+	d.rec.record(Action{Kind: ActionComment, Repo: owner + "/" + repo, Number: number, Body: body}, "")
+	return nil
+}
+
+// CreateIssueCommentID returns 0: no comment exists to be edited later.
+func (d dryRunGitHub) CreateIssueCommentID(ctx context.Context, owner, repo string, number int, body string) (int64, error) {
+	return 0, d.CreateIssueComment(ctx, owner, repo, number, body)
+}
+
+func (d dryRunGitHub) UpdateIssueComment(ctx context.Context, owner, repo string, commentID int64, body string) error {
+	d.rec.record(Action{Kind: ActionEditComment, Repo: owner + "/" + repo, Body: body}, "")
+	return nil
+}
+
+func (d dryRunGitHub) CreateIssueCommentReaction(ctx context.Context, owner, repo string, commentID int64, content string) error {
+	d.rec.record(Action{Kind: ActionReaction, Repo: owner + "/" + repo, Body: content}, "")
+	return nil
+}
+
+func (d dryRunGitHub) CreateReviewCommentReaction(ctx context.Context, owner, repo string, commentID int64, content string) error {
+	d.rec.record(Action{Kind: ActionReaction, Repo: owner + "/" + repo, Body: content}, "")
+	return nil
+}
+
+func (d dryRunGitHub) SetIssueLabels(ctx context.Context, owner, repo string, number int, labels []string) error {
+	d.rec.record(Action{Kind: ActionLabels, Repo: owner + "/" + repo, Number: number, Labels: labels}, "")
+	return nil
+}
+
+func (d dryRunGitHub) CreatePR(ctx context.Context, owner, repo string, req github.PRRequest) (github.PR, error) {
+	d.rec.record(Action{Kind: ActionCreatePR, Repo: owner + "/" + repo, Branch: req.Head, Base: req.Base, Title: req.Title, Body: req.Body}, "")
+	return github.PR{Title: req.Title, Body: req.Body, State: "open", HeadRef: req.Head, BaseRef: req.Base, URL: "(dry run)"}, nil
+}
+
+func (d dryRunGitHub) UpdatePRBody(ctx context.Context, owner, repo string, number int, body string) error {
+	d.rec.record(Action{Kind: ActionUpdatePR, Repo: owner + "/" + repo, Number: number, Body: body}, "")
+	return nil
+}
+
+func (d dryRunGitHub) AddAssignees(ctx context.Context, owner, repo string, number int, assignees []string) error {
+	d.rec.record(Action{Kind: ActionAssignees, Repo: owner + "/" + repo, Number: number, Users: assignees}, "")
+	return nil
+}
+
+func (d dryRunGitHub) RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers, teamReviewers []string) error {
+	d.rec.record(Action{Kind: ActionReviewers, Repo: owner + "/" + repo, Number: number, Users: reviewers, Teams: teamReviewers}, "")
+	return nil
+}
+
+func (d dryRunGitHub) CreatePRReview(ctx context.Context, owner, repo string, number int, review github.ReviewRequest) error {
+	d.rec.record(Action{Kind: ActionReview, Repo: owner + "/" + repo, Number: number, Event: review.Event, Body: review.Body, Comments: len(review.Comments)}, "")
+	return nil
+}
+
+// dryRunGit keeps credentials out of the clone's remotes, adding them only for
+// the duration of a fetch, and records pushes with their diff against the
+// branch they were cut from.
+type dryRunGit struct {
+	GitClient
+	rec   *dryRunRecorder
+	token string
+	// remotes holds the authenticated URLs of remotes added during the run.
+	remotes map[string]string
+	// bases maps branches checked out during the run to the ref they were
+	// cut from; current is the branch checked out last.
+	bases   map[string]string
+	current string
+}
+
+func (d *dryRunGit) CloneWithOptions(ctx context.Context, repoURL, dir string, opts gitutil.CloneOptions) error {
+	if err := d.GitClient.CloneWithOptions(ctx, repoURL, dir, opts); err != nil {
+		return err
+	}
+	return d.GitClient.ClearRemoteAuth(ctx, dir, "origin")
+}
+
+// SetRemoteAuth leaves origin without credentials; Fetch adds them as needed.
+func (d *dryRunGit) SetRemoteAuth(ctx context.Context, dir, token string) error {
+	return nil
+}
+
+func (d *dryRunGit) AddRemote(ctx context.Context, dir, name, remoteURL string) error {
+	if err := d.GitClient.AddRemote(ctx, dir, name, remoteURL); err != nil {
+		return err
+	}
+	d.remotes[name] = remoteURL
+	return d.GitClient.ClearRemoteAuth(ctx, dir, name)
+}
+
+// Fetch fetches with the remote's credentials and removes them afterwards.
+func (d *dryRunGit) Fetch(ctx context.Context, dir, remote, ref string) (err error) {
+	authURL, added := d.remotes[remote]
+	switch {
+	case added:
+		err = d.GitClient.AddRemote(ctx, dir, remote, authURL)
+	case remote == "origin" && d.token != "":
+		err = d.GitClient.SetRemoteAuth(ctx, dir, d.token)
+	default:
+		return d.GitClient.Fetch(ctx, dir, remote, ref)
+	}
+	defer func() {
+		if clearErr := d.GitClient.ClearRemoteAuth(ctx, dir, remote); err == nil {
+			err = clearErr
+		}
+	}()
+	if err != nil {
+		return err
+	}
+	return d.GitClient.Fetch(ctx, dir, remote, ref)
+}
+
+func (d *dryRunGit) CheckoutBranch(ctx context.Context, dir, branch, base string) error {
+	if err := d.GitClient.CheckoutBranch(ctx, dir, branch, base); err != nil {
+		return err
+	}
+	if base == "" {
+		base = d.bases[d.current]
+	}
+	d.bases[branch] = base
+	d.current = branch
+	return nil
+}
+
+func (d *dryRunGit) Push(ctx context.Context, dir, branch string) error {
+	return d.PushTo(ctx, dir, "origin", branch)
+}
+
+// PushTo records the patch the push would have added to the branch's base.
+func (d *dryRunGit) PushTo(ctx context.Context, dir, remote, branch string) error {
+	diff, err := d.pushDiff(ctx, dir, branch)
+	if err != nil {
+		d.rec.log.Warn("dry run: diff of pushed branch unavailable", "branch", branch, "error", err)
+	}
+	d.rec.record(Action{Kind: ActionPush, Branch: remote + "/" + branch}, diff)
+	return nil
+}
+
+func (d *dryRunGit) pushDiff(ctx context.Context, dir, branch string) (string, error) {
+	base, ok := d.bases[branch]
+	if !ok || base == "" {
+		return "", fmt.Errorf("branch %s was not checked out from a known base", branch)
+	}
+	mergeBase, err := d.GitClient.MergeBase(ctx, dir, base, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return d.GitClient.Diff(ctx, dir, mergeBase)
+}
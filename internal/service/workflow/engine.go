@@ -15,6 +15,7 @@ import (
 	"git_sonic/pkg/github"
 	"git_sonic/pkg/gitutil"
 	"git_sonic/pkg/logging"
+	"git_sonic/pkg/redact"
 	"github.com/MimeLyc/agent-core-go/pkg/instructions"
 	"github.com/MimeLyc/agent-core-go/pkg/llm"
 )
@@ -24,23 +25,51 @@ type GitHubClient interface {
 	GetIssue(ctx context.Context, owner, repo string, number int) (github.Issue, error)
 	ListIssueComments(ctx context.Context, owner, repo string, number int) ([]github.Comment, error)
 	CreateIssueComment(ctx context.Context, owner, repo string, number int, body string) error
+	CreateIssueCommentID(ctx context.Context, owner, repo string, number int, body string) (int64, error)
+	UpdateIssueComment(ctx context.Context, owner, repo string, commentID int64, body string) error
+	CreateIssueCommentReaction(ctx context.Context, owner, repo string, commentID int64, content string) error
+	CreateReviewCommentReaction(ctx context.Context, owner, repo string, commentID int64, content string) error
 	SetIssueLabels(ctx context.Context, owner, repo string, number int, labels []string) error
 	CreatePR(ctx context.Context, owner, repo string, req github.PRRequest) (github.PR, error)
 	UpdatePRBody(ctx context.Context, owner, repo string, number int, body string) error
 	AddAssignees(ctx context.Context, owner, repo string, number int, assignees []string) error
 	GetRepo(ctx context.Context, owner, repo string) (github.Repo, error)
 	GetPR(ctx context.Context, owner, repo string, number int) (github.PR, error)
+	ListOpenPRs(ctx context.Context, owner, repo string) ([]github.PR, error)
+	RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers, teamReviewers []string) error
+	GetCollaboratorPermission(ctx context.Context, owner, repo, user string) (string, error)
+	ListRepoLabels(ctx context.Context, owner, repo string) ([]string, error)
+	GetPRDiff(ctx context.Context, owner, repo string, number int) (string, error)
+	CreatePRReview(ctx context.Context, owner, repo string, number int, review github.ReviewRequest) error
 }
 
 // GitClient defines git operations needed by the engine.
 type GitClient interface {
-	Clone(ctx context.Context, repoURL, dir string) error
+	CloneWithOptions(ctx context.Context, repoURL, dir string, opts gitutil.CloneOptions) error
+	SyncMirror(ctx context.Context, repoURL, mirrorDir string) error
 	CheckoutBranch(ctx context.Context, dir, branch, base string) error
+	RemoteBranchExists(ctx context.Context, dir, remote, branch string) (bool, error)
 	CommitAll(ctx context.Context, dir, message string) error
 	Push(ctx context.Context, dir, branch string) error
+	PushTo(ctx context.Context, dir, remote, branch string) error
+	AddRemote(ctx context.Context, dir, name, remoteURL string) error
+	Fetch(ctx context.Context, dir, remote, ref string) error
+	Rebase(ctx context.Context, dir, upstream string) error
+	ContinueRebase(ctx context.Context, dir string) error
+	AbortRebase(ctx context.Context, dir string) error
+	CherryPick(ctx context.Context, dir, commit string) error
+	CommitParents(ctx context.Context, dir, commit string) ([]string, error)
+	MergeBase(ctx context.Context, dir, a, b string) (string, error)
+	ShowFile(ctx context.Context, dir, rev, path string) (string, bool, error)
+	CommitsBetween(ctx context.Context, dir, base, head string) ([]string, error)
+	ContinueCherryPick(ctx context.Context, dir string) error
+	AbortCherryPick(ctx context.Context, dir string) error
+	MarkResolved(ctx context.Context, dir string, files []string) error
 	SetRemoteAuth(ctx context.Context, dir, token string) error
+	ClearRemoteAuth(ctx context.Context, dir, remote string) error
 	ApplyPatch(ctx context.Context, dir, patch string) error
-	HasChanges(ctx context.Context, dir string) (bool, error)
+	ChangedFiles(ctx context.Context, dir string) ([]string, error)
+	Diff(ctx context.Context, dir, rev string) (string, error)
 }
 
 // LLMRunner executes LLM requests.
@@ -54,6 +83,26 @@ const (
 	RepoSubdir = "repo"
 	// OutputsSubdir is the subdirectory within the workspace for intermediate outputs
 	OutputsSubdir = "outputs"
+	// StatusFile is written to the outputs subdirectory when a run finishes
+	StatusFile = "status.json"
+)
+
+// RunStatus records the outcome of a finished workflow run in its workspace.
+type RunStatus struct {
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	FinishedAt time.Time `json:"finished_at"`
+	// Changes summarizes the diff the run produced, when it got that far.
+	Changes *DiffStats `json:"changes,omitempty"`
+	// DryRun runs list the pushes and GitHub writes they skipped.
+	DryRun  bool     `json:"dry_run,omitempty"`
+	Actions []Action `json:"actions,omitempty"`
+}
+
+// Run status values.
+const (
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
 )
 
 // Engine executes webhook workflows.
@@ -62,24 +111,59 @@ type Engine struct {
 	gh     GitHubClient
 	git    GitClient
 	llm    LLMRunner
-	now    func() time.Time
-	logger *logging.Logger
+	askLLM LLMRunner
+	// dryRunLLM replaces llm in dry-run mode; it has no tools that reach
+	// remotes or the GitHub API.
+	dryRunLLM LLMRunner
+	// redactor scrubs credentials from artifacts and GitHub posts.
+	redactor *redact.Redactor
+	// dryRun records skipped writes when the engine runs in dry-run mode.
+	dryRun  *dryRunRecorder
+	now     func() time.Time
+	logger  *logging.Logger
+	mirrors *keyedMutex
+	tasks   *keyedMutex
+	// runs holds the cancel functions of in-flight issue and PR runs.
+	runs *runRegistry
+	// acks holds the triggering comments acknowledged by a started run.
+	acks *acknowledgements
 }
 
 // NewEngine creates a new workflow engine.
 func NewEngine(cfg config.Config, gh GitHubClient, git GitClient, llmRunner LLMRunner) *Engine {
+	redactor := redact.New(cfg.Secrets()...)
 	return &Engine{
-		cfg:    cfg,
-		gh:     gh,
-		git:    git,
-		llm:    llmRunner,
-		now:    time.Now,
-		logger: logging.Default(),
+		cfg:      cfg,
+		gh:       redactingGitHub{GitHubClient: gh, r: redactor},
+		redactor: redactor,
+		git:      git,
+		llm:      llmRunner,
+		now:      time.Now,
+		logger:   logging.Default(),
+		mirrors:  newKeyedMutex(),
+		tasks:    newKeyedMutex(),
+		runs:     newRunRegistry(),
+		acks:     newAcknowledgements(),
 	}
 }
 
+// WithReadOnlyRunner sets the runner used for question answering. It should
+// only expose read and search tools; ask commands are refused when unset.
+func (e *Engine) WithReadOnlyRunner(runner LLMRunner) *Engine {
+	e.askLLM = runner
+	return e
+}
+
+// WithDryRunRunner sets the runner used in dry-run mode. It should not expose
+// tools that push or write to GitHub; the main runner is used when unset.
+func (e *Engine) WithDryRunRunner(runner LLMRunner) *Engine {
+	e.dryRunLLM = runner
+	return e
+}
+
 // HandleIssueLabel handles issue label events.
 func (e *Engine) HandleIssueLabel(ctx context.Context, event webhook.Event) error {
+	e = e.forRepository(event.Repository.FullName)
 	log := e.logger.With("delivery_id", event.DeliveryID, "event_type", event.Type)
 
 	if event.Type != webhook.EventIssues {
@@ -116,6 +200,7 @@ func (e *Engine) HandleIssueLabel(ctx context.Context, event webhook.Event) erro
 
 // HandleIssueComment handles issue comment events.
 func (e *Engine) HandleIssueComment(ctx context.Context, event webhook.Event) error {
+	e = e.forRepository(event.Repository.FullName)
 	log := e.logger.With("delivery_id", event.DeliveryID, "event_type", event.Type)
 
 	if event.Type != webhook.EventIssueComment {
@@ -126,10 +211,27 @@ func (e *Engine) HandleIssueComment(ctx context.Context, event webhook.Event) er
 		log.Debug("skipping event: action is not created", "action", event.Action)
 		return nil
 	}
+	if isStatusComment(event.CommentBody) {
+		log.Debug("skipping event: status comment")
+		return nil
+	}
 	if event.Issue == nil {
 		log.Warn("skipping event: missing issue payload")
 		return errors.New("missing issue payload")
 	}
+	if _, ok := commandArgs(event.CommentBody, e.cfg.CancelCommand); ok {
+		log.Debug("skipping event: cancel commands are handled when enqueued")
+		return nil
+	}
+	if event.Issue.IsPullRequest && backportTargets(event.CommentBody, e.cfg.BackportCommand) != nil {
+		return e.handleBackportComment(ctx, event)
+	}
+	if _, ok := commandArgs(event.CommentBody, e.cfg.ReviewCommand); ok && event.Issue.IsPullRequest {
+		return e.runReview(ctx, event, event.Issue.Number, "command")
+	}
+	if question, ok := commandArgs(event.CommentBody, e.cfg.AskCommand); ok {
+		return e.runAsk(ctx, event, event.Issue.Number, event.Issue.IsPullRequest, question)
+	}
 	if event.Issue.State != "open" {
 		log.Debug("skipping event: issue is not open", "issue", event.Issue.Number, "state", event.Issue.State)
 		return nil
@@ -147,6 +249,7 @@ func (e *Engine) HandleIssueComment(ctx context.Context, event webhook.Event) er
 
 // HandlePRComment handles PR comment optimization events.
 func (e *Engine) HandlePRComment(ctx context.Context, event webhook.Event) error {
+	e = e.forRepository(event.Repository.FullName)
 	log := e.logger.With("delivery_id", event.DeliveryID, "event_type", event.Type)
 
 	if event.Type != webhook.EventPRComment {
@@ -157,10 +260,24 @@ func (e *Engine) HandlePRComment(ctx context.Context, event webhook.Event) error
 		log.Debug("skipping event: action is not created", "action", event.Action)
 		return nil
 	}
+	if isStatusComment(event.CommentBody) {
+		log.Debug("skipping event: status comment")
+		return nil
+	}
 	if event.PullRequest == nil {
 		log.Warn("skipping event: missing pull request payload")
 		return errors.New("missing pull request payload")
 	}
+	if _, ok := commandArgs(event.CommentBody, e.cfg.CancelCommand); ok {
+		log.Debug("skipping event: cancel commands are handled when enqueued")
+		return nil
+	}
+	if _, ok := commandArgs(event.CommentBody, e.cfg.ReviewCommand); ok {
+		return e.runReview(ctx, event, event.PullRequest.Number, "command")
+	}
+	if question, ok := commandArgs(event.CommentBody, e.cfg.AskCommand); ok {
+		return e.runAsk(ctx, event, event.PullRequest.Number, true, question)
+	}
 	slash := findSlashCommand(event.CommentBody, e.cfg.PRSlashCommands)
 	if slash == "" {
 		log.Debug("skipping event: no slash command found", "pr", event.PullRequest.Number)
@@ -179,7 +296,10 @@ func (e *Engine) HandlePRComment(ctx context.Context, event webhook.Event) error
 	return err
 }
 
-func (e *Engine) handlePROptimize(ctx context.Context, event webhook.Event, slash string, log *logging.Logger) error {
+func (e *Engine) handlePROptimize(ctx context.Context, event webhook.Event, slash string, log *logging.Logger) (err error) {
+	ctx, release := e.runs.track(ctx, runTarget(event.Repository.FullName, event.PullRequest.Number))
+	defer release()
+
 	// Step 1: Parse repository info
 	done := log.Step("parse-repo-info")
 	owner, repo, err := splitFullName(event.Repository.FullName)
@@ -202,14 +322,37 @@ func (e *Engine) handlePROptimize(ctx context.Context, event webhook.Event, slas
 		return nil
 	}
 	done(nil)
+	e.acknowledge(ctx, event)
+
+	var workDir string
+	status := e.startStatus(ctx, owner, repo, pr.Number, event.DeliveryID, prStatusSteps, log)
+	defer func() {
+		if cancelled := cancellation(ctx); cancelled != nil {
+			ctx := context.WithoutCancel(ctx)
+			status.stop(ctx, "Cancelled", "See the comment below.")
+			e.reportCancelled(ctx, owner, repo, pr.Number, false, cancelled, log)
+			err = nil
+			return
+		}
+		var wfErr *logging.WorkflowError
+		if errors.As(err, &wfErr) {
+			ctx := context.WithoutCancel(ctx)
+			target := failureTarget{number: pr.Number, status: status, retry: commandRetryHint}
+			e.reportFailure(ctx, owner, repo, target, err, e.jobDetails(event, pr.Number, workDir, log), log)
+			return
+		}
+		status.close(ctx, err)
+	}()
 
 	// Step 3: Prepare workspace
+	status.advance(ctx, stageCloning)
 	done = log.Step("prepare-workspace")
-	workDir, err := e.prepareWorkspace(ctx, event.Repository, fmt.Sprintf("pr-%d", pr.Number), log)
+	workDir, err = e.prepareWorkspace(ctx, event.Repository, fmt.Sprintf("pr-%d", pr.Number), log)
 	if err != nil {
 		done(err)
 		return err // Already wrapped
 	}
+	defer func() { e.writeRunStatus(workDir, err) }()
 	repDir := repoDir(workDir)
 	log.Info("workspace prepared", "workdir", workDir, "repodir", repDir)
 	done(nil)
@@ -222,12 +365,16 @@ func (e *Engine) handlePROptimize(ctx context.Context, event webhook.Event, slas
 	}
 	done(nil)
 
-	// Step 5: Checkout branch
-	done = log.Step("checkout-branch", "branch", pr.HeadRef)
-	baseRef := "origin/" + pr.HeadRef
-	if err := e.git.CheckoutBranch(ctx, repDir, pr.HeadRef, baseRef); err != nil {
+	// CODEOWNERS comes from the base branch: the PR head could drop its own protections
+	owners := e.loadCodeOwners(ctx, repDir, "origin/"+pr.BaseRef, log)
+
+	// Step 5: Checkout branch (from the fork when the head lives there)
+	fork := pr.IsFork(event.Repository.FullName)
+	done = log.Step("checkout-branch", "branch", pr.HeadRef, "fork", fork)
+	headRemote, err := e.checkoutPRHead(ctx, repDir, event.Repository.FullName, pr)
+	if err != nil {
 		done(err)
-		return log.WrapError("checkout-branch", "CheckoutBranch", err)
+		return log.WrapError("checkout-branch", "checkoutPRHead", err)
 	}
 	done(nil)
 
@@ -254,12 +401,12 @@ func (e *Engine) handlePROptimize(ctx context.Context, event webhook.Event, slas
 	done(nil)
 
 	// Step 7: Run LLM
+	status.advance(ctx, stageRunning)
 	done = log.Step("run-llm")
 	result, err := e.llm.Run(ctx, request, repDir)
 	e.writeArtifacts(workDir, request, result, err)
 	if err != nil {
 		done(err)
-		_ = e.gh.CreateIssueComment(ctx, owner, repo, pr.Number, "Automation failed: "+err.Error())
 		return log.WrapError("run-llm", "Run", err)
 	}
 	log.Info("LLM completed", "decision", result.Response.Decision)
@@ -275,10 +422,12 @@ func (e *Engine) handlePROptimize(ctx context.Context, event webhook.Event, slas
 		if comment == "" {
 			comment = "Automation stopped without changes."
 		}
+		status.stop(ctx, "Stopped without changes", "See the comment below.")
 		return e.gh.CreateIssueComment(ctx, owner, repo, pr.Number, comment)
 	}
 
 	// Step 9: Apply changes
+	status.advance(ctx, stageVerifying)
 	done = log.Step("apply-changes", "files_count", len(result.Response.Files), "has_patch", result.Response.Patch != "")
 	if err := e.applyChanges(ctx, workDir, result.Response, log); err != nil {
 		done(err)
@@ -286,7 +435,44 @@ func (e *Engine) handlePROptimize(ctx context.Context, event webhook.Event, slas
 	}
 	done(nil)
 
-	// Step 10: Commit changes
+	// Step 10: Record the diff against the PR head the run started from,
+	// including commits the agent made itself, and enforce change size limits
+	done = log.Step("record-changes")
+	diff, stats, err := e.recordChanges(ctx, workDir, headRemote+"/"+pr.HeadRef, log)
+	if err != nil {
+		done(err)
+		return log.WrapError("record-changes", "Diff", err)
+	}
+	if reason := e.changeLimitExceeded(stats); reason != "" {
+		log.Warn("change exceeds size limits", "files", len(stats.Files), "lines", stats.Lines())
+		done(nil)
+		status.stop(ctx, "Stopped by guardrails", "See the comment below.")
+		return e.gh.CreateIssueComment(ctx, owner, repo, pr.Number, reason)
+	}
+	done(nil)
+
+	// Step 11: Check guardrail policies
+	done = log.Step("check-policy")
+	if violations := e.policyViolations(event.Repository.FullName, repDir, diff); len(violations) > 0 {
+		log.Warn("change violates policy", "rules", policyRules(violations))
+		done(nil)
+		status.stop(ctx, "Stopped by guardrails", "See the comment below.")
+		return e.gh.CreateIssueComment(ctx, owner, repo, pr.Number, policyComment(violations))
+	}
+	done(nil)
+
+	// Step 12: Check protected paths (CODEOWNERS)
+	done = log.Step("check-protected-paths")
+	if blocked := e.protectedChanges(event.Repository.FullName, owners, stats.Paths()); len(blocked) > 0 {
+		log.Warn("changes touch protected paths", "paths", blocked)
+		done(nil)
+		status.stop(ctx, "Stopped by guardrails", "See the comment below.")
+		return e.gh.CreateIssueComment(ctx, owner, repo, pr.Number, protectedPathsComment(blocked))
+	}
+	done(nil)
+
+	// Step 13: Commit changes
+	status.advance(ctx, stagePublishing)
 	done = log.Step("commit-changes")
 	commitMsg := fallback(result.Response.CommitMessage, fmt.Sprintf("Optimize PR #%d", pr.Number))
 	if err := e.git.CommitAll(ctx, repDir, commitMsg); err != nil {
@@ -295,38 +481,57 @@ func (e *Engine) handlePROptimize(ctx context.Context, event webhook.Event, slas
 	}
 	done(nil)
 
-	// Step 11: Push changes
-	done = log.Step("push-changes", "branch", pr.HeadRef)
-	if err := e.git.Push(ctx, repDir, pr.HeadRef); err != nil {
+	// Forks that do not allow maintainer edits get a follow-up PR instead of a push.
+	if fork && !pr.MaintainerCanModify {
+		followUpURL, err := e.openForkFollowUp(ctx, owner, repo, pr, result.Response, stats, slash, event.Sender, repDir, log)
+		if err != nil {
+			return err
+		}
+		if followUpURL == "" {
+			status.succeed(ctx, "Changes pushed to a branch", "")
+		} else {
+			status.succeed(ctx, "Follow-up pull request opened", followUpURL)
+		}
+		return nil
+	}
+
+	// Step 14: Rebase onto the latest remote branch and push changes (never forced)
+	done = log.Step("push-changes", "branch", pr.HeadRef, "remote", headRemote)
+	if err := e.pushWithRebase(ctx, workDir, event.Repository.FullName, headRemote, pr.HeadRef, commitMsg, owners, log); err != nil {
 		done(err)
+		var conflict *gitutil.ConflictError
+		if errors.As(err, &conflict) {
+			_ = e.gh.CreateIssueComment(ctx, owner, repo, pr.Number, conflictComment(pr.HeadRef, conflict))
+		}
 		return log.WrapError("push-changes", "Push", err)
 	}
 	done(nil)
 
-	// Step 12: Update PR body
+	// Step 15: Update PR body
 	done = log.Step("update-pr-body")
 	newBody := result.Response.PRBody
 	if newBody == "" {
 		newBody = appendSlashContext(pr.Body, slash)
 	}
+	newBody = withChangesSummary(newBody, stats)
 	if err := e.gh.UpdatePRBody(ctx, owner, repo, pr.Number, newBody); err != nil {
 		done(err)
 		return log.WrapError("update-pr-body", "UpdatePRBody", err)
 	}
 	done(nil)
 
-	// Step 13: Post completion comment
+	// Step 16: Report completion in the status comment
 	done = log.Step("post-completion-comment")
-	if err := e.gh.CreateIssueComment(ctx, owner, repo, pr.Number, "Automation applied: "+slash); err != nil {
-		done(err)
-		return log.WrapError("post-completion-comment", "CreateIssueComment", err)
-	}
+	status.succeed(ctx, "Applied "+slash, "")
 	done(nil)
 
 	return nil
 }
 
-func (e *Engine) handleIssue(ctx context.Context, event webhook.Event, requireLabeler bool, log *logging.Logger) error {
+func (e *Engine) handleIssue(ctx context.Context, event webhook.Event, requireLabeler bool, log *logging.Logger) (err error) {
+	ctx, release := e.runs.track(ctx, runTarget(event.Repository.FullName, event.Issue.Number))
+	defer release()
+
 	// Step 1: Parse repository info
 	done := log.Step("parse-repo-info")
 	owner, repo, err := splitFullName(event.Repository.FullName)
@@ -357,16 +562,62 @@ func (e *Engine) handleIssue(ctx context.Context, event webhook.Event, requireLa
 		done(err)
 		return log.WrapError("get-issue-comments", "ListIssueComments", err)
 	}
+	comments = withoutStatusComments(comments, e.cfg.BotLogin)
 	log.Info("fetched comments", "count", len(comments))
 	done(nil)
 
+	// Plan mode: a plan is posted first; a plan-pending issue proceeds only when a comment approves it
+	var approvedPlan, planEdits string
+	planning := false
+	if e.planModeFor(event.Repository.FullName) {
+		planning = event.Type != webhook.EventIssueComment || !contains(e.cfg.PlanPendingLabel, issue.Labels)
+		if !planning {
+			done = log.Step("check-plan-approval")
+			approvedPlan, planEdits, err = e.planApproval(ctx, owner, repo, event, comments, log)
+			if err != nil {
+				done(err)
+				return log.WrapError("check-plan-approval", "planApproval", err)
+			}
+			done(nil)
+			if approvedPlan == "" {
+				return nil
+			}
+		}
+	}
+
+	e.acknowledge(ctx, event)
+
+	var workDir string
+	// inProgress is set while the issue carries the in-progress label set by this run
+	inProgress := false
+	status := e.startStatus(ctx, owner, repo, issue.Number, event.DeliveryID, issueStatusSteps, log)
+	defer func() {
+		if cancelled := cancellation(ctx); cancelled != nil {
+			ctx := context.WithoutCancel(ctx)
+			status.stop(ctx, "Cancelled", "See the comment below.")
+			e.reportCancelled(ctx, owner, repo, issue.Number, true, cancelled, log)
+			err = nil
+			return
+		}
+		var wfErr *logging.WorkflowError
+		if errors.As(err, &wfErr) {
+			ctx := context.WithoutCancel(ctx)
+			target := failureTarget{number: issue.Number, status: status, inProgress: inProgress, retry: e.issueRetryHint(inProgress)}
+			e.reportFailure(ctx, owner, repo, target, err, e.jobDetails(event, issue.Number, workDir, log), log)
+			return
+		}
+		status.close(ctx, err)
+	}()
+
 	// Step 4: Prepare workspace
+	status.advance(ctx, stageCloning)
 	done = log.Step("prepare-workspace")
-	workDir, err := e.prepareWorkspace(ctx, event.Repository, fmt.Sprintf("issue-%d", issue.Number), log)
+	workDir, err = e.prepareWorkspace(ctx, event.Repository, fmt.Sprintf("issue-%d", issue.Number), log)
 	if err != nil {
 		done(err)
 		return err // Already wrapped
 	}
+	defer func() { e.writeRunStatus(workDir, err) }()
 	repDir := repoDir(workDir)
 	log.Info("workspace prepared", "workdir", workDir, "repodir", repDir)
 	done(nil)
@@ -379,37 +630,57 @@ func (e *Engine) handleIssue(ctx context.Context, event webhook.Event, requireLa
 	}
 	done(nil)
 
-	// Step 6: Get default branch
-	done = log.Step("get-default-branch")
-	defaultBranch := event.Repository.DefaultBranch
-	if defaultBranch == "" {
+	// Step 6: Resolve base branch (base label, issue body field, repo settings, default branch)
+	done = log.Step("resolve-base-branch")
+	baseBranch, baseSource, err := e.requestedBaseBranch(event.Repository.FullName, issue)
+	if err != nil {
+		done(err)
+		return log.WrapError("resolve-base-branch", "requestedBaseBranch", err)
+	}
+	if baseBranch == "" {
+		baseBranch = event.Repository.DefaultBranch
+	}
+	if baseBranch == "" {
 		repoInfo, err := e.gh.GetRepo(ctx, owner, repo)
 		if err != nil {
 			done(err)
-			return log.WrapError("get-default-branch", "GetRepo", err)
+			return log.WrapError("resolve-base-branch", "GetRepo", err)
 		}
-		defaultBranch = repoInfo.DefaultBranch
+		baseBranch = repoInfo.DefaultBranch
 	}
-	log.Info("using default branch", "branch", defaultBranch)
+	log.Info("using base branch", "branch", baseBranch, "source", baseSource)
 	done(nil)
 
-	// Step 7: Checkout new branch (include full timestamp to avoid conflicts on retry)
-	branch := fmt.Sprintf("llm/issue-%d-%s", issue.Number, e.now().Format("20060102-150405"))
-	done = log.Step("checkout-branch", "branch", branch, "base", defaultBranch)
-	if err := e.git.CheckoutBranch(ctx, repDir, branch, "origin/"+defaultBranch); err != nil {
+	// Step 7: Checkout new branch (the default template includes a full timestamp to avoid conflicts on retry)
+	done = log.Step("checkout-branch", "base", baseBranch)
+	branch, err := e.issueBranchName(event.Repository.FullName, issue, event.Sender)
+	if err != nil {
+		done(err)
+		return log.WrapError("checkout-branch", "issueBranchName", err)
+	}
+	if branch, err = e.unusedBranchName(ctx, repDir, branch); err != nil {
+		done(err)
+		return log.WrapError("checkout-branch", "unusedBranchName", err)
+	}
+	log.Info("using branch", "branch", branch)
+	if err := e.git.CheckoutBranch(ctx, repDir, branch, "origin/"+baseBranch); err != nil {
 		done(err)
 		return log.WrapError("checkout-branch", "CheckoutBranch", err)
 	}
 	done(nil)
 
+	// CODEOWNERS comes from the base branch so that the agent cannot rewrite its own protections
+	owners := e.loadCodeOwners(ctx, repDir, "origin/"+baseBranch, log)
+
 	// Step 8: Update issue labels to in-progress (remove all other status labels including triggers)
 	done = log.Step("update-labels-in-progress")
-	labelsToRemove := append([]string{e.cfg.DoneLabel, e.cfg.NeedsInfoLabel}, e.cfg.TriggerLabels...)
+	labelsToRemove := append([]string{e.cfg.DoneLabel, e.cfg.NeedsInfoLabel, e.cfg.PlanPendingLabel, e.cfg.FailedLabel}, e.cfg.TriggerLabels...)
 	labels := updateProgressLabels(issue.Labels, e.cfg.InProgressLabel, labelsToRemove...)
 	if err := e.gh.SetIssueLabels(ctx, owner, repo, issue.Number, labels); err != nil {
 		done(err)
 		return log.WrapError("update-labels-in-progress", "SetIssueLabels", err)
 	}
+	inProgress = true
 	done(nil)
 
 	// Step 9: Prepare LLM prompt
@@ -424,8 +695,20 @@ func (e *Engine) handleIssue(ctx context.Context, event webhook.Event, requireLa
 		IssueLabels:   issue.Labels,
 		IssueComments: toLLMComments(comments),
 		CommentBody:   event.CommentBody,
+		Metadata:      map[string]string{"base_branch": baseBranch},
 		Requirements:  "Address the issue by implementing a fix and preparing a PR.",
 	}
+	switch {
+	case planning:
+		contextReq.Mode = "plan"
+		contextReq.Requirements = "Write an implementation plan for the issue for human review. Do not change any files."
+	case approvedPlan != "":
+		contextReq.Metadata["approved_plan"] = approvedPlan
+		if planEdits != "" {
+			contextReq.Metadata["plan_edits"] = planEdits
+		}
+		contextReq.Requirements = "Implement the approved plan in metadata.approved_plan, applying any reviewer edits in metadata.plan_edits, and prepare a PR."
+	}
 	request, err := e.preparePrompt(workDir, contextReq)
 	if err != nil {
 		done(err)
@@ -434,12 +717,12 @@ func (e *Engine) handleIssue(ctx context.Context, event webhook.Event, requireLa
 	done(nil)
 
 	// Step 10: Run LLM
+	status.advance(ctx, stageRunning)
 	done = log.Step("run-llm")
 	result, err := e.llm.Run(ctx, request, repDir)
 	e.writeArtifacts(workDir, request, result, err)
 	if err != nil {
 		done(err)
-		_ = e.gh.CreateIssueComment(ctx, owner, repo, issue.Number, "Automation failed: "+err.Error())
 		return log.WrapError("run-llm", "Run", err)
 	}
 	log.Info("LLM completed", "decision", result.Response.Decision, "files_count", len(result.Response.Files))
@@ -455,19 +738,18 @@ func (e *Engine) handleIssue(ctx context.Context, event webhook.Event, requireLa
 		if comment == "" {
 			comment = "More information is required before automation can proceed."
 		}
-		mentions := mentionParticipants(issue.Author, comments)
-		if mentions != "" {
-			comment = comment + "\n\n" + mentions
-		}
-		if e.cfg.NeedsInfoLabel != "" {
-			labelsToRemove := append([]string{e.cfg.InProgressLabel, e.cfg.DoneLabel}, e.cfg.TriggerLabels...)
-			labels := updateProgressLabels(issue.Labels, e.cfg.NeedsInfoLabel, labelsToRemove...)
-			_ = e.gh.SetIssueLabels(ctx, owner, repo, issue.Number, labels)
-		}
-		return e.gh.CreateIssueComment(ctx, owner, repo, issue.Number, comment)
+		status.stop(ctx, "More information needed", "See the comment below.")
+		return e.requestMoreInfo(ctx, owner, repo, issue, comments, comment)
+	}
+
+	// Plan mode: post the plan and wait for approval instead of changing files
+	if planning {
+		status.stop(ctx, "Plan ready for review", "See the comment below.")
+		return e.postPlan(ctx, owner, repo, issue, result.Response, log)
 	}
 
 	// Step 12: Apply changes (write files or apply patch)
+	status.advance(ctx, stageVerifying)
 	done = log.Step("apply-changes", "files_count", len(result.Response.Files), "has_patch", result.Response.Patch != "")
 	if err := e.applyChanges(ctx, workDir, result.Response, log); err != nil {
 		done(err)
@@ -475,32 +757,67 @@ func (e *Engine) handleIssue(ctx context.Context, event webhook.Event, requireLa
 	}
 	done(nil)
 
-	// Step 13: Check for changes
-	done = log.Step("check-for-changes")
-	hasChanges, err := e.git.HasChanges(ctx, repDir)
+	// Step 13: Record the diff against the base branch, including commits the
+	// agent made itself
+	done = log.Step("record-changes")
+	diff, stats, err := e.recordChanges(ctx, workDir, "origin/"+baseBranch, log)
 	if err != nil {
 		done(err)
-		return log.WrapError("check-for-changes", "HasChanges", err)
+		return log.WrapError("record-changes", "Diff", err)
 	}
-	if !hasChanges {
+	if len(stats.Files) == 0 {
 		log.Warn("no file changes detected")
 		done(nil)
-		return e.gh.CreateIssueComment(ctx, owner, repo, issue.Number,
-			"Automation completed but no file changes were made. The LLM indicated it would make changes but none were detected.")
+		status.stop(ctx, "No changes made", "The agent said it would make changes, but none were detected.")
+		return nil
+	}
+	changedFiles := stats.Paths()
+	done(nil)
+
+	// Step 14: Enforce change size limits
+	done = log.Step("check-change-limits")
+	if reason := e.changeLimitExceeded(stats); reason != "" {
+		log.Warn("change exceeds size limits", "files", len(stats.Files), "lines", stats.Lines())
+		done(nil)
+		status.stop(ctx, "Stopped by guardrails", "See the comment below.")
+		return e.requestMoreInfo(ctx, owner, repo, issue, comments, reason)
+	}
+	done(nil)
+
+	// Step 15: Check guardrail policies
+	done = log.Step("check-policy")
+	if violations := e.policyViolations(event.Repository.FullName, repDir, diff); len(violations) > 0 {
+		log.Warn("change violates policy", "rules", policyRules(violations))
+		done(nil)
+		status.stop(ctx, "Stopped by guardrails", "See the comment below.")
+		return e.requestMoreInfo(ctx, owner, repo, issue, comments, policyComment(violations))
+	}
+	done(nil)
+
+	// Step 16: Check protected paths (CODEOWNERS)
+	done = log.Step("check-protected-paths")
+	if blocked := e.protectedChanges(event.Repository.FullName, owners, changedFiles); len(blocked) > 0 {
+		log.Warn("changes touch protected paths", "paths", blocked)
+		done(nil)
+		status.stop(ctx, "Stopped by guardrails", "See the comment below.")
+		return e.requestMoreInfo(ctx, owner, repo, issue, comments, protectedPathsComment(blocked))
 	}
-	log.Info("changes detected")
 	done(nil)
 
-	// Step 14: Commit changes
+	// Step 17: Commit changes
+	status.advance(ctx, stagePublishing)
 	done = log.Step("commit-changes")
 	commitMessage := fallback(result.Response.CommitMessage, fmt.Sprintf("Resolve issue #%d", issue.Number))
+	if e.cfg.CommitCoAuthorRequester {
+		commitMessage = addCoAuthor(commitMessage, issue.Author, issue.AuthorID)
+	}
 	if err := e.git.CommitAll(ctx, repDir, commitMessage); err != nil {
 		done(err)
 		return log.WrapError("commit-changes", "CommitAll", err)
 	}
 	done(nil)
 
-	// Step 15: Push changes
+	// Step 18: Push changes
 	done = log.Step("push-changes", "branch", branch)
 	if err := e.git.Push(ctx, repDir, branch); err != nil {
 		done(err)
@@ -508,11 +825,11 @@ func (e *Engine) handleIssue(ctx context.Context, event webhook.Event, requireLa
 	}
 	done(nil)
 
-	// Step 16: Create PR
+	// Step 19: Create PR
 	done = log.Step("create-pr")
 	prTitle := fallback(result.Response.PRTitle, fmt.Sprintf("Resolve issue #%d", issue.Number))
-	prBody := fallback(result.Response.PRBody, fmt.Sprintf("Resolves #%d", issue.Number))
-	pr, err := e.gh.CreatePR(ctx, owner, repo, github.PRRequest{Title: prTitle, Body: prBody, Head: branch, Base: defaultBranch})
+	prBody := withChangesSummary(fallback(result.Response.PRBody, fmt.Sprintf("Resolves #%d", issue.Number)), stats)
+	pr, err := e.gh.CreatePR(ctx, owner, repo, github.PRRequest{Title: prTitle, Body: prBody, Head: branch, Base: baseBranch})
 	if err != nil {
 		done(err)
 		return log.WrapError("create-pr", "CreatePR", err)
@@ -520,7 +837,7 @@ func (e *Engine) handleIssue(ctx context.Context, event webhook.Event, requireLa
 	log.Info("PR created", "pr_number", pr.Number, "pr_url", pr.URL)
 	done(nil)
 
-	// Step 17: Add assignees (optional)
+	// Step 20: Add assignees (optional)
 	if requireLabeler && event.Sender != "" {
 		done = log.Step("add-assignees", "assignee", event.Sender)
 		if err := e.gh.AddAssignees(ctx, owner, repo, pr.Number, []string{event.Sender}); err != nil {
@@ -529,28 +846,47 @@ func (e *Engine) handleIssue(ctx context.Context, event webhook.Event, requireLa
 		done(nil)
 	}
 
-	// Step 18: Update labels to done (remove all other status labels including triggers)
+	// Step 21: Request CODEOWNERS reviews (optional)
+	if e.cfg.RequestCodeOwnerReviews && !owners.Empty() {
+		done = log.Step("request-reviewers")
+		e.requestCodeOwnerReviews(ctx, owner, repo, pr.Number, owners, changedFiles, log)
+		done(nil)
+	}
+
+	// Step 22: Update labels to done (remove all other status labels including triggers)
 	done = log.Step("update-labels-done")
-	labelsToRemove = append([]string{e.cfg.InProgressLabel, e.cfg.NeedsInfoLabel}, e.cfg.TriggerLabels...)
+	labelsToRemove = append([]string{e.cfg.InProgressLabel, e.cfg.NeedsInfoLabel, e.cfg.PlanPendingLabel, e.cfg.FailedLabel}, e.cfg.TriggerLabels...)
 	labels = updateProgressLabels(issue.Labels, e.cfg.DoneLabel, labelsToRemove...)
 	if err := e.gh.SetIssueLabels(ctx, owner, repo, issue.Number, labels); err != nil {
 		done(err)
 		return log.WrapError("update-labels-done", "SetIssueLabels", err)
 	}
+	inProgress = false
 	done(nil)
 
-	// Step 19: Post completion comment
+	// Step 23: Report the PR in the status comment
 	done = log.Step("post-completion-comment")
-	comment := fmt.Sprintf("Automation completed. PR: %s", pr.URL)
-	if err := e.gh.CreateIssueComment(ctx, owner, repo, issue.Number, comment); err != nil {
-		done(err)
-		return log.WrapError("post-completion-comment", "CreateIssueComment", err)
-	}
+	status.succeed(ctx, "Pull request opened", pr.URL)
 	done(nil)
 
 	return nil
 }
 
+// requestMoreInfo posts a needs-info comment mentioning the issue participants
+// and moves the issue to the needs-info label.
+func (e *Engine) requestMoreInfo(ctx context.Context, owner, repo string, issue github.Issue, comments []github.Comment, comment string) error {
+	mentions := mentionParticipants(issue.Author, comments)
+	if mentions != "" {
+		comment = comment + "\n\n" + mentions
+	}
+	if e.cfg.NeedsInfoLabel != "" {
+		labelsToRemove := append([]string{e.cfg.InProgressLabel, e.cfg.DoneLabel, e.cfg.PlanPendingLabel, e.cfg.FailedLabel}, e.cfg.TriggerLabels...)
+		labels := updateProgressLabels(issue.Labels, e.cfg.NeedsInfoLabel, labelsToRemove...)
+		_ = e.gh.SetIssueLabels(ctx, owner, repo, issue.Number, labels)
+	}
+	return e.gh.CreateIssueComment(ctx, owner, repo, issue.Number, comment)
+}
+
 // applyChanges writes files from the response or applies a patch.
 // Files are written to the repo subdirectory within the workspace.
 func (e *Engine) applyChanges(ctx context.Context, workDir string, resp llm.Response, log *logging.Logger) error {
@@ -587,11 +923,7 @@ func (e *Engine) applyChanges(ctx context.Context, workDir string, resp llm.Resp
 // Returns the workspace root directory. The repository is cloned into workDir/repo/
 // and intermediate outputs are stored in workDir/outputs/.
 func (e *Engine) prepareWorkspace(ctx context.Context, repo webhook.Repository, prefix string, log *logging.Logger) (string, error) {
-	base := e.cfg.RepoCloneBase
-	if base == "" {
-		base = "./workdir"
-	}
-	workDir := filepath.Join(base, fmt.Sprintf("%s-%s", prefix, e.now().Format("20060102-150405")))
+	workDir := filepath.Join(e.cloneBase(), fmt.Sprintf("%s-%s", prefix, e.now().Format("20060102-150405")))
 
 	log.Debug("creating workdir", "path", workDir)
 	if err := os.MkdirAll(workDir, 0o755); err != nil {
@@ -623,8 +955,7 @@ func (e *Engine) prepareWorkspace(ctx context.Context, repo webhook.Repository,
 	}
 
 	// Clone into the repo subdirectory
-	log.Debug("cloning repository", "url", gitutil.RedactToken(cloneURL), "target", repoDir)
-	if err := e.git.Clone(ctx, cloneURL, repoDir); err != nil {
+	if err := e.cloneRepository(ctx, repo, cloneURL, repoDir, log); err != nil {
 		return "", log.WrapError("prepare-workspace", "Clone", err)
 	}
 
@@ -642,15 +973,19 @@ func outputsDir(workDir string) string {
 }
 
 func (e *Engine) writeArtifacts(workDir string, req llm.Request, result llm.RunResult, runErr error) {
-	outDir := outputsDir(workDir)
+	e.writeArtifactsTo(outputsDir(workDir), req, result, runErr)
+}
+
+// writeArtifactsTo writes the prompt, raw LLM output and run log into outDir.
+func (e *Engine) writeArtifactsTo(outDir string, req llm.Request, result llm.RunResult, runErr error) {
 	promptPath := filepath.Join(outDir, "prompt.md")
 	llmOutPath := filepath.Join(outDir, "llm_output.json")
 	logPath := filepath.Join(outDir, "run.log")
 	if strings.TrimSpace(req.Prompt) != "" {
-		_ = os.WriteFile(promptPath, []byte(req.Prompt), 0o644)
+		_ = e.writeFile(promptPath, []byte(req.Prompt))
 	}
 	if result.Stdout != "" {
-		_ = os.WriteFile(llmOutPath, []byte(result.Stdout), 0o644)
+		_ = e.writeFile(llmOutPath, []byte(result.Stdout))
 	}
 	if runErr != nil || result.Stderr != "" {
 		msg := result.Stderr
@@ -658,16 +993,47 @@ func (e *Engine) writeArtifacts(workDir string, req llm.Request, result llm.RunR
 			// Include full error with stack trace if available
 			msg = msg + "\n" + fmt.Sprintf("%+v", runErr)
 		}
-		_ = os.WriteFile(logPath, []byte(strings.TrimSpace(msg)), 0o644)
+		_ = e.writeFile(logPath, []byte(strings.TrimSpace(msg)))
+	}
+}
+
+// writeRunStatus records the run outcome so that workspace retention can tell
+// finished runs from running ones and keep failed runs longer.
+func (e *Engine) writeRunStatus(workDir string, runErr error) {
+	status := RunStatus{Status: RunSucceeded, FinishedAt: e.now()}
+	if runErr != nil {
+		status.Status = RunFailed
+		status.Error = runErr.Error()
+	}
+	status.Changes = readChangeStats(workDir)
+	if e.dryRun != nil {
+		status.DryRun = true
+		status.Actions = e.dryRun.Actions()
+		e.dryRun.write(outputsDir(workDir))
+	}
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return
 	}
+	_ = e.writeFile(filepath.Join(outputsDir(workDir), StatusFile), data)
 }
 
 func (e *Engine) preparePrompt(workDir string, contextReq llm.Request) (llm.Request, error) {
-	outDir := outputsDir(workDir)
-	repDir := repoDir(workDir)
+	return e.preparePromptIn(outputsDir(workDir), repoDir(workDir), contextReq)
+}
 
+// preparePromptIn writes the context, repository instructions and prompt into
+// outDir. Secondary LLM runs (e.g. conflict resolution) use their own outDir so
+// that the artifacts of the main run are kept.
+func (e *Engine) preparePromptIn(outDir, repDir string, contextReq llm.Request) (llm.Request, error) {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return llm.Request{}, fmt.Errorf("create outputs dir: %w", err)
+	}
 	contextName := "context.json"
 	contextPath := filepath.Join(outDir, contextName)
+	// The LLM gets the same redacted context as the file, since runners may
+	// read the request fields directly.
+	contextReq = e.redactRequest(contextReq)
 	contextData, err := json.MarshalIndent(contextReq, "", "  ")
 	if err != nil {
 		return llm.Request{}, fmt.Errorf("marshal context: %w", err)
@@ -679,14 +1045,17 @@ func (e *Engine) preparePrompt(workDir string, contextReq llm.Request) (llm.Requ
 	instructions := buildRepoInstructions(repDir)
 	instructionsName := "repo_instructions.md"
 	instructionsPath := filepath.Join(outDir, instructionsName)
-	if err := os.WriteFile(instructionsPath, []byte(instructions), 0o644); err != nil {
+	if err := e.writeFile(instructionsPath, []byte(instructions)); err != nil {
 		return llm.Request{}, fmt.Errorf("write instructions file: %w", err)
 	}
 	outputName := "llm_response.json"
 	outputPath := filepath.Join(outDir, outputName)
 	prompt := buildPrompt(contextName, instructionsName, outputName)
+	if extra := modeInstructions(contextReq.Mode); extra != "" {
+		prompt = prompt + "\n\n" + extra
+	}
 	promptPath := filepath.Join(outDir, "prompt.md")
-	if err := os.WriteFile(promptPath, []byte(prompt), 0o644); err != nil {
+	if err := e.writeFile(promptPath, []byte(prompt)); err != nil {
 		return llm.Request{}, fmt.Errorf("write prompt file: %w", err)
 	}
 	contextReq.Prompt = prompt
@@ -739,6 +1108,34 @@ func buildPrompt(contextName, instructionsName, outputName string) string {
 	}, "\n")
 }
 
+// modeInstructions returns prompt instructions specific to a request mode.
+func modeInstructions(mode string) string {
+	switch mode {
+	case "triage":
+		return triageInstructions
+	case "review":
+		return reviewInstructions
+	case "ask":
+		return askInstructions
+	case "plan":
+		return strings.Join([]string{
+			"This is the planning phase: do NOT modify any files and leave 'files' and 'patch' empty.",
+			"Investigate the repository and respond with decision=proceed and a complete implementation plan in 'summary', formatted as Markdown.",
+			"The plan should list the files to change, the approach, risks, and how the change will be tested.",
+			"A human reviews the plan before any implementation starts.",
+		}, "\n")
+	case "resolve_conflicts":
+		return strings.Join([]string{
+			"A git operation (metadata.operation, e.g. rebase or cherry-pick) stopped on merge conflicts. The conflicted files are listed in metadata.conflicted_files and contain conflict markers.",
+			"task_body describes the automated change being replayed onto the updated branch.",
+			"Resolve every conflict and return the COMPLETE resolved content of each conflicted file in 'files'.",
+			"The resolved files must not contain any conflict markers (<<<<<<<, =======, >>>>>>>).",
+			"If the conflicts cannot be resolved safely, respond with decision=stop.",
+		}, "\n")
+	}
+	return ""
+}
+
 func issueMode(event webhook.Event) string {
 	if event.Type == webhook.EventIssueComment {
 		return "issue_comment"
@@ -831,6 +1228,19 @@ func appendSlashContext(body, slash string) string {
 	return strings.TrimSpace(body) + "\n\nAutomated optimization triggered by: " + slash
 }
 
+// addCoAuthor appends a Co-authored-by trailer crediting a GitHub user via
+// their noreply address. Bot accounts and missing users are skipped.
+func addCoAuthor(message, login string, userID int64) string {
+	if login == "" || strings.HasSuffix(login, "[bot]") {
+		return message
+	}
+	trailer := fmt.Sprintf("Co-authored-by: %s <%s>", login, config.NoreplyEmail(login, userID))
+	if strings.Contains(message, trailer) {
+		return message
+	}
+	return strings.TrimRight(message, "\n") + "\n\n" + trailer
+}
+
 func fallback(value, def string) string {
 	if strings.TrimSpace(value) == "" {
 		return def
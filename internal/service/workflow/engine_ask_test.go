@@ -0,0 +1,34 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAskCommentListsExistingCitations(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	answer := AskResult{
+		Answer: "It starts in main.",
+		Citations: []Citation{
+			{Path: "/main.go", StartLine: 3, EndLine: 9},
+			{Path: "main.go", StartLine: 4},
+			{Path: "missing.go", StartLine: 1, EndLine: 2},
+		},
+	}
+	answer.Citations = existingCitations(dir, answer.Citations)
+	comment := askComment("asker", answer)
+
+	for _, want := range []string{"@asker It starts in main.", "`main.go:L3-L9`", "`main.go:L4`"} {
+		if !strings.Contains(comment, want) {
+			t.Fatalf("expected %q in comment:\n%s", want, comment)
+		}
+	}
+	if strings.Contains(comment, "missing.go") {
+		t.Fatalf("citation to a missing file should be dropped:\n%s", comment)
+	}
+}
@@ -0,0 +1,258 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"git_sonic/internal/config"
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/pkg/github"
+	"git_sonic/pkg/gitutil"
+	"git_sonic/pkg/logging"
+	"github.com/MimeLyc/agent-core-go/pkg/llm"
+)
+
+func TestBackportTargets(t *testing.T) {
+	cases := []struct {
+		body string
+		want []string
+	}{
+		{"/ai-backport release-1.1 release-1.2", []string{"release-1.1", "release-1.2"}},
+		{"Thanks!\n/ai-backport release-1.1 release-1.1\nmore text", []string{"release-1.1"}},
+		{"/ai-backport", []string{}},
+		{"/ai-backporting release-1.1", nil},
+		{"no command here", nil},
+	}
+	for _, tc := range cases {
+		if got := backportTargets(tc.body, "/ai-backport"); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("backportTargets(%q) = %#v, want %#v", tc.body, got, tc.want)
+		}
+	}
+}
+
+type backportGitHub struct {
+	GitHubClient
+	permission string
+	comments   []string
+	prs        []github.PRRequest
+}
+
+func (g *backportGitHub) GetCollaboratorPermission(ctx context.Context, owner, repo, user string) (string, error) {
+	return g.permission, nil
+}
+
+func (g *backportGitHub) CreateIssueComment(ctx context.Context, owner, repo string, number int, body string) error {
+	g.comments = append(g.comments, body)
+	return nil
+}
+
+func (g *backportGitHub) CreatePR(ctx context.Context, owner, repo string, req github.PRRequest) (github.PR, error) {
+	g.prs = append(g.prs, req)
+	return github.PR{Number: 100 + len(g.prs)}, nil
+}
+
+type backportGit struct {
+	GitClient
+	parents   []string
+	landed    []string
+	conflicts map[string]bool
+	// owners is the target branch CODEOWNERS; diff is the staged resolution.
+	owners  string
+	diff    string
+	picked  []string
+	aborted bool
+	pushed  []string
+	// taken is a branch name that already exists on origin.
+	taken string
+}
+
+func (g *backportGit) Fetch(ctx context.Context, dir, remote, ref string) error { return nil }
+
+func (g *backportGit) CheckoutBranch(ctx context.Context, dir, branch, base string) error {
+	return nil
+}
+
+func (g *backportGit) Push(ctx context.Context, dir, branch string) error {
+	g.pushed = append(g.pushed, branch)
+	return nil
+}
+
+// RemoteBranchExists reports the first backport branch name as taken.
+func (g *backportGit) RemoteBranchExists(ctx context.Context, dir, remote, branch string) (bool, error) {
+	return g.taken != "" && branch == g.taken, nil
+}
+
+func (g *backportGit) ShowFile(ctx context.Context, dir, rev, path string) (string, bool, error) {
+	return g.owners, g.owners != "" && path == "CODEOWNERS", nil
+}
+
+func (g *backportGit) MarkResolved(ctx context.Context, dir string, files []string) error { return nil }
+
+func (g *backportGit) Diff(ctx context.Context, dir, rev string) (string, error) { return g.diff, nil }
+
+func (g *backportGit) CommitParents(ctx context.Context, dir, commit string) ([]string, error) {
+	return g.parents, nil
+}
+
+func (g *backportGit) MergeBase(ctx context.Context, dir, a, b string) (string, error) {
+	return "base", nil
+}
+
+func (g *backportGit) CommitsBetween(ctx context.Context, dir, base, head string) ([]string, error) {
+	if head == "merge" {
+		return g.landed, nil
+	}
+	return []string{"c1", "c2", head}, nil
+}
+
+func (g *backportGit) CherryPick(ctx context.Context, dir, commit string) error {
+	g.picked = append(g.picked, commit)
+	if g.conflicts[commit] {
+		return &gitutil.ConflictError{Op: "cherry-pick", Files: []string{"main.go"}, Err: errors.New("conflict")}
+	}
+	return nil
+}
+
+func (g *backportGit) AbortCherryPick(ctx context.Context, dir string) error {
+	g.aborted = true
+	return nil
+}
+
+func newBackportEngine(gh *backportGitHub, git *backportGit) *Engine {
+	return &Engine{gh: gh, git: git, now: func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }}
+}
+
+func TestBackportRequiresWriteAccess(t *testing.T) {
+	gh := &backportGitHub{permission: "read"}
+	e := newBackportEngine(gh, &backportGit{})
+	e.cfg.BackportCommand = "/ai-backport"
+	event := webhook.Event{
+		Sender:      "mallory",
+		CommentBody: "/ai-backport release-1.1",
+		Issue:       &webhook.Issue{Number: 7},
+		Repository:  webhook.Repository{FullName: "org/repo"},
+	}
+	if err := e.handleBackport(context.Background(), event, logging.Default()); err != nil {
+		t.Fatalf("handleBackport() error = %v", err)
+	}
+	if len(gh.comments) != 1 || !strings.Contains(gh.comments[0], "@mallory only users with write access") {
+		t.Fatalf("expected a permission comment, got %v", gh.comments)
+	}
+	if len(gh.prs) != 0 {
+		t.Fatalf("expected no backport PRs, got %v", gh.prs)
+	}
+}
+
+func TestBackportCommitsPicksPRCommitsForRebaseMerges(t *testing.T) {
+	e := newBackportEngine(&backportGitHub{}, &backportGit{parents: []string{"p1"}, landed: []string{"r1", "r2", "merge"}})
+	pr := github.PR{Number: 7, HeadSHA: "head", MergeCommitSHA: "merge"}
+	if got, want := e.backportCommits(context.Background(), "", pr, logging.Default()), []string{"c1", "c2", "head"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("backportCommits() = %v, want %v", got, want)
+	}
+
+	// A squash merge lands one commit that already holds the whole PR.
+	e = newBackportEngine(&backportGitHub{}, &backportGit{parents: []string{"p1"}, landed: []string{"merge"}})
+	if got, want := e.backportCommits(context.Background(), "", pr, logging.Default()), []string{"merge"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("backportCommits() = %v, want %v", got, want)
+	}
+
+	e = newBackportEngine(&backportGitHub{}, &backportGit{parents: []string{"p1", "p2"}})
+	if got, want := e.backportCommits(context.Background(), "", pr, logging.Default()), []string{"merge"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("backportCommits() = %v, want %v", got, want)
+	}
+}
+
+func TestBackportToReportsUnresolvedConflicts(t *testing.T) {
+	gh := &backportGitHub{}
+	git := &backportGit{conflicts: map[string]bool{"c2": true}}
+	e := newBackportEngine(gh, git)
+	pr := github.PR{Number: 7, Title: "Fix parser"}
+
+	result := e.backportTo(context.Background(), "org", "repo", t.TempDir(), pr, []string{"c1", "c2", "c3"}, "release-1.1", "maintainer", logging.Default())
+	if result.Err == nil {
+		t.Fatal("expected the conflict to fail the backport")
+	}
+	if result.Resolved {
+		t.Fatal("expected unresolved conflicts not to be reported as resolved")
+	}
+	if !git.aborted {
+		t.Fatal("expected the cherry-pick to be aborted")
+	}
+	if want := []string{"c1", "c2"}; !reflect.DeepEqual(git.picked, want) {
+		t.Fatalf("picked %v, want %v", git.picked, want)
+	}
+	if len(gh.prs) != 0 {
+		t.Fatalf("expected no backport PR, got %v", gh.prs)
+	}
+}
+
+func TestBackportToPicksEveryCommit(t *testing.T) {
+	gh := &backportGitHub{}
+	git := &backportGit{}
+	e := newBackportEngine(gh, git)
+	pr := github.PR{Number: 7, Title: "Fix parser"}
+
+	result := e.backportTo(context.Background(), "org", "repo", t.TempDir(), pr, []string{"c1", "c2"}, "release-1.1", "maintainer", logging.Default())
+	if result.Err != nil || result.Resolved {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if want := []string{"c1", "c2"}; !reflect.DeepEqual(git.picked, want) {
+		t.Fatalf("picked %v, want %v", git.picked, want)
+	}
+	if len(gh.prs) != 1 || !strings.Contains(gh.prs[0].Body, "Cherry-picked from c1, c2.") {
+		t.Fatalf("unexpected backport PRs: %v", gh.prs)
+	}
+}
+
+// resolvingLLM resolves every conflict by rewriting main.go.
+type resolvingLLM struct{}
+
+func (resolvingLLM) Run(ctx context.Context, req llm.Request, workDir string) (llm.RunResult, error) {
+	return llm.RunResult{Response: llm.Response{Decision: llm.DecisionProceed, Files: map[string]string{"main.go": "resolved\n"}}}, nil
+}
+
+func TestBackportToRejectsResolutionsTouchingProtectedPaths(t *testing.T) {
+	gh := &backportGitHub{}
+	git := &backportGit{
+		conflicts: map[string]bool{"c1": true},
+		owners:    "/auth/ @org/security\n",
+		diff:      "diff --git a/main.go b/main.go\n+resolved\ndiff --git a/auth/token.go b/auth/token.go\n+leak\n",
+	}
+	e := newBackportEngine(gh, git)
+	e.llm = resolvingLLM{}
+	e.cfg = config.Config{ConflictResolutionLLM: true, RepoSettings: map[string]config.RepoSettings{
+		"org/repo": {ProtectedOwners: []string{"@org/security"}},
+	}}
+	pr := github.PR{Number: 7, Title: "Fix parser"}
+
+	result := e.backportTo(context.Background(), "org", "repo", t.TempDir(), pr, []string{"c1"}, "release-1.1", "maintainer", logging.Default())
+	if result.Err == nil || result.Resolved {
+		t.Fatalf("expected the resolution to be rejected, got %+v", result)
+	}
+	if !git.aborted {
+		t.Fatal("expected the cherry-pick to be aborted")
+	}
+	if len(gh.prs) != 0 {
+		t.Fatalf("expected no backport PR, got %v", gh.prs)
+	}
+}
+
+func TestBackportBranchUsesTemplateAndSkipsTakenNames(t *testing.T) {
+	gh := &backportGitHub{}
+	git := &backportGit{taken: "bot/7-fix-parser-backport"}
+	e := newBackportEngine(gh, git)
+	e.cfg = config.Config{BranchTemplate: "bot/{{.Number}}-{{.Slug}}-{{.Kind}}"}
+	pr := github.PR{Number: 7, Title: "Fix parser"}
+
+	result := e.backportTo(context.Background(), "org", "repo", t.TempDir(), pr, []string{"c1"}, "release-1.1", "maintainer", logging.Default())
+	if result.Err != nil {
+		t.Fatalf("unexpected error: %v", result.Err)
+	}
+	if want := []string{"bot/7-fix-parser-backport-2"}; !reflect.DeepEqual(git.pushed, want) {
+		t.Fatalf("pushed %v, want %v", git.pushed, want)
+	}
+}
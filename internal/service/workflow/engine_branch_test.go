@@ -0,0 +1,105 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"git_sonic/internal/config"
+	"git_sonic/pkg/github"
+)
+
+func TestIssueBranchNameTemplate(t *testing.T) {
+	e := &Engine{
+		cfg: config.Config{BranchTemplate: "fix/{{.Number}}-{{.Slug}}-{{.Sender}}"},
+		now: func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) },
+	}
+	issue := github.Issue{Number: 7, Title: "Crash when saving: Ünicode / paths!"}
+	got, err := e.issueBranchName("o/r", issue, "Alice")
+	if err != nil {
+		t.Fatalf("issueBranchName: %v", err)
+	}
+	if want := "fix/7-crash-when-saving-nicode-paths-alice"; got != want {
+		t.Fatalf("issueBranchName() = %q, want %q", got, want)
+	}
+
+	e.cfg.BranchTemplate = ""
+	got, err = e.issueBranchName("o/r", issue, "alice")
+	if err != nil {
+		t.Fatalf("issueBranchName: %v", err)
+	}
+	if want := "llm/issue-7-20240102-030405"; got != want {
+		t.Fatalf("default issueBranchName() = %q, want %q", got, want)
+	}
+}
+
+func TestRequestedBaseBranchPrecedence(t *testing.T) {
+	e := &Engine{cfg: config.Config{
+		BaseLabelPrefix: "base:",
+		RepoSettings:    map[string]config.RepoSettings{"o/r": {BaseBranch: "develop"}},
+	}}
+	cases := []struct {
+		name       string
+		issue      github.Issue
+		wantBranch string
+		wantSource string
+	}{
+		{"label", github.Issue{Labels: []string{"bug", "base:release-1.2"}, Body: "Base branch: release-1.1"}, "release-1.2", baseSourceLabel},
+		{"inline body field", github.Issue{Body: "Steps...\n**Base branch:** `release-1.1`\n"}, "release-1.1", baseSourceBody},
+		{"issue form section", github.Issue{Body: "### Base branch\n\nrelease-1.0\n\n### Details\n"}, "release-1.0", baseSourceBody},
+		{"repo settings", github.Issue{Body: "### Base branch\n\n_No response_\n"}, "develop", baseSourceRepo},
+	}
+	for _, tc := range cases {
+		branch, source, err := e.requestedBaseBranch("o/r", tc.issue)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if branch != tc.wantBranch || source != tc.wantSource {
+			t.Fatalf("%s: got (%q, %q), want (%q, %q)", tc.name, branch, source, tc.wantBranch, tc.wantSource)
+		}
+	}
+
+	if branch, _, _ := e.requestedBaseBranch("other/repo", github.Issue{}); branch != "" {
+		t.Fatalf("expected default branch for unconfigured repo, got %q", branch)
+	}
+	if _, _, err := e.requestedBaseBranch("o/r", github.Issue{Labels: []string{"base:--upload-pack=x"}}); err == nil {
+		t.Fatal("expected invalid base branch to be rejected")
+	}
+}
+
+type branchGit struct {
+	GitClient
+	existing map[string]bool
+}
+
+func (g *branchGit) RemoteBranchExists(ctx context.Context, dir, remote, branch string) (bool, error) {
+	return g.existing[remote+"/"+branch], nil
+}
+
+func TestUnusedBranchNameAppendsSuffix(t *testing.T) {
+	git := &branchGit{existing: map[string]bool{}}
+	e := &Engine{git: git}
+	got, err := e.unusedBranchName(context.Background(), "", "fix/7")
+	if err != nil || got != "fix/7" {
+		t.Fatalf("unusedBranchName() = %q, %v; want fix/7", got, err)
+	}
+
+	git.existing["origin/fix/7"] = true
+	git.existing["origin/fix/7-2"] = true
+	got, err = e.unusedBranchName(context.Background(), "", "fix/7")
+	if err != nil || got != "fix/7-3" {
+		t.Fatalf("unusedBranchName() = %q, %v; want fix/7-3", got, err)
+	}
+}
+
+func TestIsTaskBranchMatchesOnlyTheNamedTask(t *testing.T) {
+	branch := taskBranch("deps", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	if branch != "llm/task-deps-20240102-030405" || !isTaskBranch(branch, "deps") {
+		t.Fatalf("expected %q to be a deps task branch", branch)
+	}
+	for _, other := range []string{"llm/task-deps-major-20240102-030405", "llm/task-deps-20240102-030405-2", "llm/task-deps-notes"} {
+		if isTaskBranch(other, "deps") {
+			t.Errorf("expected %q not to be a deps task branch", other)
+		}
+	}
+}
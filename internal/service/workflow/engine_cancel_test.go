@@ -0,0 +1,122 @@
+package workflow
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"git_sonic/internal/config"
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/pkg/github"
+	"git_sonic/pkg/logging"
+)
+
+// labelGitHub keeps an issue's labels and records comments.
+type labelGitHub struct {
+	GitHubClient
+	labels   []string
+	sets     int
+	comments []string
+}
+
+func (g *labelGitHub) GetIssue(ctx context.Context, owner, repo string, number int) (github.Issue, error) {
+	return github.Issue{Number: number, Labels: append([]string(nil), g.labels...)}, nil
+}
+
+func (g *labelGitHub) SetIssueLabels(ctx context.Context, owner, repo string, number int, labels []string) error {
+	g.labels = labels
+	g.sets++
+	return nil
+}
+
+func (g *labelGitHub) CreateIssueComment(ctx context.Context, owner, repo string, number int, body string) error {
+	g.comments = append(g.comments, body)
+	return nil
+}
+
+func TestCancelReasonLabelRemoval(t *testing.T) {
+	e := &Engine{
+		cfg: config.Config{
+			TriggerLabels:   []string{"ai-ready"},
+			InProgressLabel: "ai-in-progress",
+			DoneLabel:       "ai-done",
+			NeedsInfoLabel:  "ai-needs-info",
+			FailedLabel:     "ai-failed",
+		},
+		logger: logging.Default(),
+	}
+	cases := []struct {
+		name   string
+		action string
+		label  string
+		labels []string
+		cancel bool
+	}{
+		{"issue closed", "closed", "", []string{"ai-in-progress"}, true},
+		{"in-progress removed by a user", "unlabeled", "ai-in-progress", []string{"bug"}, true},
+		{"in-progress swapped for done", "unlabeled", "ai-in-progress", []string{"ai-done"}, false},
+		{"in-progress swapped for needs-info", "unlabeled", "ai-in-progress", []string{"ai-needs-info"}, false},
+		{"trigger removed before the run started", "unlabeled", "ai-ready", nil, true},
+		{"trigger swapped for in-progress", "unlabeled", "ai-ready", []string{"ai-in-progress"}, false},
+		{"in-progress swapped for failed", "unlabeled", "ai-in-progress", []string{"ai-failed"}, false},
+		{"other label removed", "unlabeled", "bug", nil, false},
+		{"label added", "labeled", "ai-ready", []string{"ai-ready"}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			event := webhook.Event{
+				Type:   webhook.EventIssues,
+				Action: tc.action,
+				Label:  tc.label,
+				Sender: "octocat",
+				Issue:  &webhook.Issue{Number: 1, Labels: tc.labels},
+			}
+			reason, err := e.cancelReason(context.Background(), event)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (reason != "") != tc.cancel {
+				t.Fatalf("expected cancel=%v, got reason %q", tc.cancel, reason)
+			}
+		})
+	}
+}
+
+func TestRunRegistryCancelsTrackedRuns(t *testing.T) {
+	runs := newRunRegistry()
+	ctx, release := runs.track(context.Background(), "org/repo#1")
+
+	if !runs.active("org/repo#1") || runs.active("org/repo#2") {
+		t.Fatal("expected only org/repo#1 to be active")
+	}
+	if n := runs.cancel("org/repo#1", &CancelledError{Reason: "test"}); n != 1 {
+		t.Fatalf("expected one cancelled run, got %d", n)
+	}
+	if cancelled := cancellation(ctx); cancelled == nil || cancelled.Reason != "test" {
+		t.Fatalf("expected cancellation cause, got %v", context.Cause(ctx))
+	}
+	release()
+	if runs.active("org/repo#1") {
+		t.Fatal("expected the run to be released")
+	}
+}
+
+func TestReportCancelledRereadsLabels(t *testing.T) {
+	// The run set ai-in-progress and someone added priority after it started.
+	gh := &labelGitHub{labels: []string{"bug", "ai-in-progress", "priority"}}
+	e := &Engine{
+		cfg: config.Config{TriggerLabels: []string{"ai-ready"}, InProgressLabel: "ai-in-progress"},
+		gh:  gh,
+	}
+	e.reportCancelled(context.Background(), "org", "repo", 1, true, &CancelledError{Reason: "the issue was closed"}, logging.Default())
+
+	sort.Strings(gh.labels)
+	if want := []string{"bug", "priority"}; !reflect.DeepEqual(gh.labels, want) {
+		t.Fatalf("expected labels %v, got %v", want, gh.labels)
+	}
+	if len(gh.comments) != 1 || !strings.Contains(gh.comments[0], "Add the `ai-ready` label") {
+		t.Fatalf("unexpected comments %q", gh.comments)
+	}
+}
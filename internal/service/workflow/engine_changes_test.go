@@ -0,0 +1,118 @@
+package workflow
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git_sonic/internal/config"
+	"git_sonic/pkg/logging"
+)
+
+// changesGit answers MergeBase and Diff for recordChanges.
+type changesGit struct {
+	GitClient
+	mergeBaseArgs []string
+	diffRev       string
+}
+
+func (g *changesGit) MergeBase(ctx context.Context, dir, a, b string) (string, error) {
+	g.mergeBaseArgs = []string{a, b}
+	return "base-sha", nil
+}
+
+func (g *changesGit) Diff(ctx context.Context, dir, rev string) (string, error) {
+	g.diffRev = rev
+	return reviewDiff, nil
+}
+
+func TestParseDiffStats(t *testing.T) {
+	diff := reviewDiff + `diff --git a/logo.png b/logo.png
+new file mode 100644
+index 0000000..3333333
+GIT binary patch
+literal 4
+LcmZQzWMT#Y01f~L
+`
+	stats := parseDiffStats(diff)
+	if len(stats.Files) != 3 {
+		t.Fatalf("expected 3 files, got %+v", stats.Files)
+	}
+	if f := stats.Files[0]; f.Path != "main.go" || f.Added != 2 || f.Removed != 1 {
+		t.Fatalf("unexpected main.go stats: %+v", f)
+	}
+	if f := stats.Files[1]; f.Path != "gone.go" || f.Added != 0 || f.Removed != 1 {
+		t.Fatalf("unexpected gone.go stats: %+v", f)
+	}
+	if f := stats.Files[2]; f.Path != "logo.png" || !f.Binary || f.Added != 0 {
+		t.Fatalf("unexpected logo.png stats: %+v", f)
+	}
+	if stats.Added != 2 || stats.Removed != 2 || stats.Lines() != 4 {
+		t.Fatalf("unexpected totals: %+v", stats)
+	}
+}
+
+func TestChangeLimitExceeded(t *testing.T) {
+	stats := parseDiffStats(reviewDiff)
+	e := &Engine{cfg: config.Config{}}
+	if reason := e.changeLimitExceeded(stats); reason != "" {
+		t.Fatalf("expected no limit by default, got %q", reason)
+	}
+	e.cfg.MaxChangedFiles = 1
+	if reason := e.changeLimitExceeded(stats); !strings.Contains(reason, "2 files") {
+		t.Fatalf("expected file limit to fire, got %q", reason)
+	}
+	e.cfg = config.Config{MaxChangedLines: 3}
+	if reason := e.changeLimitExceeded(stats); !strings.Contains(reason, "4 lines") {
+		t.Fatalf("expected line limit to fire, got %q", reason)
+	}
+}
+
+func TestWithChangesSummary(t *testing.T) {
+	body := withChangesSummary("Fixes the bug.", parseDiffStats(reviewDiff))
+	for _, want := range []string{"Fixes the bug.\n\n<details>", "Changed 2 files (+2 −2)", "| `main.go` | 2 | 1 |", "</details>"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in body:\n%s", want, body)
+		}
+	}
+	if got := withChangesSummary("Body", DiffStats{}); got != "Body" {
+		t.Fatalf("expected body unchanged without files, got %q", got)
+	}
+}
+
+func TestRecordChangesDiffsAgainstMergeBase(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.MkdirAll(outputsDir(workDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	git := &changesGit{}
+	e := &Engine{git: git}
+	diff, stats, err := e.recordChanges(context.Background(), workDir, "origin/main", logging.Default())
+	if err != nil {
+		t.Fatalf("recordChanges: %v", err)
+	}
+	if strings.Join(git.mergeBaseArgs, " ") != "origin/main HEAD" || git.diffRev != "base-sha" {
+		t.Fatalf("expected a diff against the merge-base of origin/main and HEAD, got merge-base %v and diff %q", git.mergeBaseArgs, git.diffRev)
+	}
+	if diff != reviewDiff || len(stats.Files) != 2 {
+		t.Fatalf("unexpected diff %q or stats %+v", diff, stats)
+	}
+	if data, err := os.ReadFile(filepath.Join(outputsDir(workDir), ChangesDiffFile)); err != nil || string(data) != reviewDiff {
+		t.Fatalf("expected diff artifact, got %q (%v)", data, err)
+	}
+}
+
+func TestWithChangesSummaryReplacesEarlierSummary(t *testing.T) {
+	first := withChangesSummary("Fixes the bug.", parseDiffStats(reviewDiff))
+	body := withChangesSummary(first+"\n\nAutomated optimization triggered by: /fix", DiffStats{Files: []FileStat{{Path: "other.go", Added: 1}}, Added: 1})
+	if strings.Count(body, "<details>") != 1 || strings.Contains(body, "main.go") {
+		t.Fatalf("expected the earlier summary to be replaced:\n%s", body)
+	}
+	for _, want := range []string{"Fixes the bug.\n\nAutomated optimization triggered by: /fix\n\n<details>", "Changed 1 file (+1 −0)"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in body:\n%s", want, body)
+		}
+	}
+}
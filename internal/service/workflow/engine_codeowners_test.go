@@ -0,0 +1,42 @@
+package workflow
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"git_sonic/internal/config"
+	"git_sonic/pkg/logging"
+)
+
+// showGit serves files by "rev:path" from a map.
+type showGit struct {
+	GitClient
+	files map[string]string
+}
+
+func (g *showGit) ShowFile(ctx context.Context, dir, rev, path string) (string, bool, error) {
+	content, ok := g.files[rev+":"+path]
+	return content, ok, nil
+}
+
+func TestProtectedChangesUseBaseCodeOwners(t *testing.T) {
+	git := &showGit{files: map[string]string{
+		"origin/main:CODEOWNERS": "/auth/ @org/security\n",
+		// The PR head (or the agent) rewrote CODEOWNERS to drop the protection.
+		"HEAD:CODEOWNERS": "* @someone\n",
+	}}
+	e := &Engine{git: git, cfg: config.Config{RepoSettings: map[string]config.RepoSettings{
+		"org/repo": {ProtectedOwners: []string{"@org/security"}},
+	}}}
+	owners := e.loadCodeOwners(context.Background(), "repo", "origin/main", logging.Default())
+
+	got := e.protectedChanges("org/repo", owners, []string{"CODEOWNERS", "auth/token.go", "main.go"})
+	if want := []string{"CODEOWNERS", "auth/token.go"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("protectedChanges = %v, want %v", got, want)
+	}
+
+	if got := e.protectedChanges("org/other", owners, []string{".github/CODEOWNERS"}); got != nil {
+		t.Fatalf("expected no protection without protected owners, got %v", got)
+	}
+}
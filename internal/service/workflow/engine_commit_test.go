@@ -0,0 +1,20 @@
+package workflow
+
+import "testing"
+
+func TestAddCoAuthorUsesNoreplyAddress(t *testing.T) {
+	got := addCoAuthor("Fix bug\n", "alice", 42)
+	want := "Fix bug\n\nCo-authored-by: alice <42+alice@users.noreply.github.com>"
+	if got != want {
+		t.Fatalf("addCoAuthor() = %q, want %q", got, want)
+	}
+	if again := addCoAuthor(got, "alice", 42); again != got {
+		t.Fatalf("expected trailer not to be duplicated, got %q", again)
+	}
+}
+
+func TestAddCoAuthorSkipsBots(t *testing.T) {
+	if got := addCoAuthor("Fix bug", "dependabot[bot]", 1); got != "Fix bug" {
+		t.Fatalf("expected bot author to be skipped, got %q", got)
+	}
+}
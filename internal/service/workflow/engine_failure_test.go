@@ -0,0 +1,117 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"git_sonic/internal/config"
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/pkg/github"
+	"git_sonic/pkg/logging"
+)
+
+func TestFailureSummary(t *testing.T) {
+	log := logging.Default().StartWorkflow("test")
+	log.Step("push-branch")(nil)
+	err := log.WrapError("push-branch", "Push", errors.New("remote rejected\nhint: fetch first"))
+
+	step, summary := failureSummary(err)
+	if step != "push-branch" || summary != "Push: remote rejected …" {
+		t.Fatalf("unexpected summary: step=%q summary=%q", step, summary)
+	}
+
+	_, summary = failureSummary(errors.New(strings.Repeat("é", maxFailureSummary)))
+	if !strings.HasSuffix(summary, "…") || len(summary) > maxFailureSummary+len("…") || !strings.HasPrefix(summary, "é") {
+		t.Fatalf("expected a truncated summary, got %d bytes", len(summary))
+	}
+	for _, r := range summary {
+		if r == '�' {
+			t.Fatal("summary was cut inside a rune")
+		}
+	}
+}
+
+func TestJobDetailsFallsBackToWorkspace(t *testing.T) {
+	e := &Engine{}
+	event := webhook.Event{DeliveryID: "d-1", Repository: webhook.Repository{FullName: "org/repo"}}
+	got := e.jobDetails(event, 3, "/tmp/work/issue-3-20260101-000000", logging.Default())
+	want := "delivery `d-1`, workspace `issue-3-20260101-000000` (see `outputs/status.json` and `outputs/run.log`)"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func failureTestEngine(gh GitHubClient) *Engine {
+	return &Engine{
+		cfg: config.Config{TriggerLabels: []string{"ai-ready"}, InProgressLabel: "ai-in-progress", FailedLabel: "ai-failed"},
+		gh:  gh,
+	}
+}
+
+func TestReportFailureSwapsOnlyTheInProgressLabel(t *testing.T) {
+	// Someone added priority while the run was in flight.
+	gh := &labelGitHub{labels: []string{"bug", "ai-in-progress", "priority"}}
+	e := failureTestEngine(gh)
+	log := logging.Default().StartWorkflow("test")
+	err := log.WrapError("push-changes", "Push", errors.New("remote rejected"))
+
+	e.reportFailure(context.Background(), "org", "repo", failureTarget{number: 3, inProgress: true, retry: e.issueRetryHint(true)}, err, "", log)
+
+	sort.Strings(gh.labels)
+	if want := []string{"ai-failed", "bug", "priority"}; !reflect.DeepEqual(gh.labels, want) {
+		t.Fatalf("expected labels %v, got %v", want, gh.labels)
+	}
+	if len(gh.comments) != 1 || !strings.Contains(gh.comments[0], "The issue is labeled `ai-failed`. Add the `ai-ready` label to retry.") {
+		t.Fatalf("unexpected comments %q", gh.comments)
+	}
+}
+
+func TestReportFailureBeforeInProgressKeepsLabels(t *testing.T) {
+	gh := &labelGitHub{labels: []string{"ai-ready"}}
+	e := failureTestEngine(gh)
+	log := logging.Default().StartWorkflow("test")
+	err := log.WrapError("checkout-branch", "CheckoutBranch", errors.New("no such branch"))
+
+	e.reportFailure(context.Background(), "org", "repo", failureTarget{number: 3, retry: e.issueRetryHint(false)}, err, "", log)
+
+	if gh.sets != 0 {
+		t.Fatalf("expected labels to be left alone, got %v", gh.labels)
+	}
+	if len(gh.comments) != 1 || strings.Contains(gh.comments[0], "ai-failed") || !strings.Contains(gh.comments[0], "Remove and add the `ai-ready` label again to retry.") {
+		t.Fatalf("unexpected comments %q", gh.comments)
+	}
+}
+
+// unreadableIssueGitHub fails to read issues.
+type unreadableIssueGitHub struct {
+	labelGitHub
+}
+
+func (g *unreadableIssueGitHub) GetIssue(ctx context.Context, owner, repo string, number int) (github.Issue, error) {
+	return github.Issue{}, errors.New("502 bad gateway")
+}
+
+func TestTriageFailureIsReported(t *testing.T) {
+	gh := &unreadableIssueGitHub{}
+	e := failureTestEngine(gh)
+	event := webhook.Event{
+		Type:       webhook.EventIssues,
+		Action:     "opened",
+		Repository: webhook.Repository{FullName: "org/repo"},
+		Issue:      &webhook.Issue{Number: 4},
+	}
+
+	if err := e.handleTriage(context.Background(), event, logging.Default().StartWorkflow("issue-triage")); err == nil {
+		t.Fatal("expected triage to fail")
+	}
+	if len(gh.comments) != 1 || !strings.Contains(gh.comments[0], "Automation failed at step `get-issue-details`") || !strings.Contains(gh.comments[0], "> GetIssue: 502 bad gateway") {
+		t.Fatalf("unexpected comments %q", gh.comments)
+	}
+	if gh.sets != 0 {
+		t.Fatalf("expected triage failures to leave labels alone, got %v", gh.labels)
+	}
+}
@@ -0,0 +1,79 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"git_sonic/pkg/github"
+	"git_sonic/pkg/logging"
+	"github.com/MimeLyc/agent-core-go/pkg/llm"
+)
+
+type forkGitHub struct {
+	GitHubClient
+	comments []string
+}
+
+func (g *forkGitHub) CreatePR(ctx context.Context, owner, repo string, req github.PRRequest) (github.PR, error) {
+	return github.PR{}, errors.New("Resource not accessible by integration")
+}
+
+func (g *forkGitHub) CreateIssueComment(ctx context.Context, owner, repo string, number int, body string) error {
+	g.comments = append(g.comments, body)
+	return nil
+}
+
+type pushGit struct {
+	GitClient
+	pushed []string
+}
+
+func (g *pushGit) CheckoutBranch(ctx context.Context, dir, branch, base string) error { return nil }
+
+func (g *pushGit) RemoteBranchExists(ctx context.Context, dir, remote, branch string) (bool, error) {
+	return false, nil
+}
+
+func (g *pushGit) Push(ctx context.Context, dir, branch string) error {
+	g.pushed = append(g.pushed, branch)
+	return nil
+}
+
+func TestForkFollowUpFallsBackToBranchLink(t *testing.T) {
+	gh := &forkGitHub{}
+	git := &pushGit{}
+	e := &Engine{gh: gh, git: git, now: func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }}
+	pr := github.PR{
+		Number:           7,
+		HeadRef:          "feature",
+		Author:           "contributor",
+		URL:              "https://github.com/org/repo/pull/7",
+		HeadRepoFullName: "contributor/repo",
+	}
+
+	url, err := e.openForkFollowUp(context.Background(), "org", "repo", pr, llm.Response{}, DiffStats{}, "/ai-fix", "maintainer", t.TempDir(), logging.Default())
+	if err != nil {
+		t.Fatalf("expected the fallback to succeed, got %v", err)
+	}
+	if url != "" {
+		t.Fatalf("expected no follow-up PR URL, got %q", url)
+	}
+	if len(git.pushed) != 1 || git.pushed[0] != "llm/pr-7-20260102-030405" {
+		t.Fatalf("unexpected pushes: %v", git.pushed)
+	}
+	if len(gh.comments) != 1 {
+		t.Fatalf("expected one comment, got %d", len(gh.comments))
+	}
+	for _, want := range []string{
+		"pushed to `llm/pr-7-20260102-030405`",
+		"https://github.com/contributor/repo/compare/feature...org:repo:llm/pr-7-20260102-030405",
+		"@contributor",
+	} {
+		if !strings.Contains(gh.comments[0], want) {
+			t.Fatalf("expected comment to contain %q, got %q", want, gh.comments[0])
+		}
+	}
+}
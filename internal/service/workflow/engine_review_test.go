@@ -0,0 +1,57 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+)
+
+const reviewDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@
+ package main
+-func old() {}
++func f() {}
++++counter
+ // end
+diff --git a/gone.go b/gone.go
+deleted file mode 100644
+--- a/gone.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package gone
+`
+
+func TestCommentableLines(t *testing.T) {
+	lines := commentableLines(reviewDiff)
+	for _, line := range []int{1, 2, 3, 4} {
+		if !lines["main.go"][line] {
+			t.Fatalf("expected main.go:%d to be commentable, got %v", line, lines["main.go"])
+		}
+	}
+	if lines["main.go"][5] || len(lines) != 1 {
+		t.Fatalf("unexpected commentable lines: %v", lines)
+	}
+}
+
+func TestBuildReviewMovesCommentsOutsideDiffToBody(t *testing.T) {
+	review := ReviewResult{
+		Summary: "Looks mostly fine.",
+		ReviewComments: []ReviewComment{
+			{Path: "main.go", Line: 2, Body: "f needs a doc comment"},
+			{Path: "main.go", Line: 40, Body: "unrelated"},
+			{Path: "main.go", Line: 3, Body: " "},
+		},
+	}
+	req := buildReview(review, commentableLines(reviewDiff), "abc123")
+	if req.CommitID != "abc123" || req.Event != "COMMENT" {
+		t.Fatalf("unexpected review metadata: %+v", req)
+	}
+	if len(req.Comments) != 1 || req.Comments[0].Line != 2 || req.Comments[0].Side != "RIGHT" {
+		t.Fatalf("unexpected inline comments: %+v", req.Comments)
+	}
+	if !strings.Contains(req.Body, "`main.go:40`: unrelated") {
+		t.Fatalf("expected out-of-diff comment in body, got %q", req.Body)
+	}
+}
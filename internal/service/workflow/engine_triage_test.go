@@ -0,0 +1,68 @@
+package workflow
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MimeLyc/agent-core-go/pkg/llm"
+)
+
+func TestParseTriageFromStdoutAndEnvelope(t *testing.T) {
+	raw := `{"decision":"proceed","summary":"Crash on save","classification":"Bug","labels":["BUG","nope"],"relevant_files":["main.go"],"estimate":"small"}`
+	triage := parseTriage("", llm.RunResult{Stdout: "noise " + raw})
+	if triage.Classification != TriageBug || triage.Estimate != "small" || triage.Summary != "Crash on save" {
+		t.Fatalf("unexpected triage from stdout: %+v", triage)
+	}
+
+	envelope := `{"choices":[{"message":{"content":` + jsonString(raw) + `}}]}`
+	if triage := parseTriage("", llm.RunResult{Stdout: envelope}); triage.Classification != TriageBug {
+		t.Fatalf("unexpected triage from API envelope: %+v", triage)
+	}
+
+	fallbackResult := parseTriage("", llm.RunResult{Response: llm.Response{Summary: "just a summary"}})
+	if fallbackResult.Classification != "" || fallbackResult.Summary != "just a summary" {
+		t.Fatalf("unexpected fallback triage: %+v", fallbackResult)
+	}
+}
+
+func TestTriageFiltersLabelsAndFiles(t *testing.T) {
+	if got := knownLabels([]string{"BUG", "nope", "bug"}, []string{"bug", "enhancement"}); !reflect.DeepEqual(got, []string{"bug"}) {
+		t.Fatalf("knownLabels() = %v", got)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got := existingFiles(dir, []string{"/main.go", "missing.go", "../etc/passwd"})
+	if !reflect.DeepEqual(got, []string{"main.go"}) {
+		t.Fatalf("existingFiles() = %v", got)
+	}
+}
+
+func TestTriageLabelsDropsReservedLabels(t *testing.T) {
+	e := &Engine{}
+	e.cfg.TriggerLabels = []string{"ai-ready"}
+	e.cfg.InProgressLabel = "ai-in-progress"
+	e.cfg.DoneLabel = "ai-done"
+	e.cfg.NeedsInfoLabel = "ai-needs-info"
+	e.cfg.PlanPendingLabel = "ai-plan-pending"
+	e.cfg.FailedLabel = "ai-failed"
+	e.cfg.BaseLabelPrefix = "base:"
+
+	repoLabels := []string{"bug", "AI-Ready", "ai-in-progress", "ai-done", "ai-needs-info", "ai-plan-pending", "ai-failed", "base:release-1.2", "enhancement"}
+	allowed := e.triageLabels(repoLabels)
+	if want := []string{"bug", "enhancement"}; !reflect.DeepEqual(allowed, want) {
+		t.Fatalf("triageLabels() = %v, want %v", allowed, want)
+	}
+	if got := knownLabels([]string{"ai-ready", "base:release-1.2", "bug"}, allowed); !reflect.DeepEqual(got, []string{"bug"}) {
+		t.Fatalf("knownLabels() = %v", got)
+	}
+}
+
+func jsonString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
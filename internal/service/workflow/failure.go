@@ -0,0 +1,164 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/pkg/logging"
+)
+
+// maxFailureSummary caps the error text posted to GitHub; the full error stays
+// in the logs and the run status file.
+const maxFailureSummary = 300
+
+// failureSummary returns the step a run failed at and a short, single-line
+// description of the error fit for posting on GitHub.
+func failureSummary(err error) (step, summary string) {
+	var wfErr *logging.WorkflowError
+	if errors.As(err, &wfErr) {
+		step = wfErr.Step
+		summary = fmt.Sprint(wfErr.Err)
+		if wfErr.Op != "" {
+			summary = wfErr.Op + ": " + summary
+		}
+	} else {
+		summary = err.Error()
+	}
+	summary = strings.TrimSpace(summary)
+	if i := strings.IndexByte(summary, '\n'); i >= 0 {
+		summary = strings.TrimSpace(summary[:i]) + " …"
+	}
+	if len(summary) > maxFailureSummary {
+		cut := maxFailureSummary
+		for cut > 0 && !utf8.RuneStart(summary[cut]) {
+			cut--
+		}
+		summary = summary[:cut] + "…"
+	}
+	return step, summary
+}
+
+// jobDetails describes where to find the logs of a failed run: the rendered
+// JOB_DETAILS_URL when set, otherwise the delivery ID and workspace.
+func (e *Engine) jobDetails(event webhook.Event, number int, workDir string, log *logging.Logger) string {
+	workspace := ""
+	if workDir != "" {
+		workspace = filepath.Base(workDir)
+	}
+	if e.cfg.JobDetailsURL != "" {
+		tmpl, err := template.New("job").Parse(e.cfg.JobDetailsURL)
+		if err == nil {
+			var sb strings.Builder
+			err = tmpl.Execute(&sb, struct {
+				DeliveryID string
+				Repo       string
+				Number     int
+				Workspace  string
+			}{event.DeliveryID, event.Repository.FullName, number, workspace})
+			if err == nil {
+				return sb.String()
+			}
+		}
+		log.Warn("invalid job details URL", "error", err)
+	}
+	var parts []string
+	if event.DeliveryID != "" {
+		parts = append(parts, fmt.Sprintf("delivery `%s`", event.DeliveryID))
+	}
+	if workspace != "" {
+		parts = append(parts, fmt.Sprintf("workspace `%s` (see `%s/%s` and `%s/run.log`)", workspace, OutputsSubdir, StatusFile, OutputsSubdir))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// failureTarget says where and how a failed run is reported.
+type failureTarget struct {
+	// number is the issue or PR the run works on; 0 for scheduled tasks,
+	// whose failures are only logged.
+	number int
+	// status is the run's status comment, which then shows the failure;
+	// runs without one post a failure comment instead.
+	status *statusComment
+	// inProgress is set once the run has labeled the issue in-progress.
+	// Only then are the labels changed.
+	inProgress bool
+	// retry tells people how to run it again.
+	retry string
+}
+
+// reportFailure reports the step a run failed at with a short, sanitized
+// summary of the error and where to find the job details. An issue labeled
+// in-progress by the run gets the failed label instead.
+func (e *Engine) reportFailure(ctx context.Context, owner, repo string, target failureTarget, err error, details string, log *logging.Logger) {
+	step, summary := failureSummary(err)
+	log.Info("reporting failure", "step", step)
+	if target.number == 0 {
+		log.Error("run failed", "step", step, "summary", summary, "job_details", details)
+		return
+	}
+	labeled := target.inProgress && e.markFailed(ctx, owner, repo, target.number, log)
+
+	var sb strings.Builder
+	if details != "" {
+		fmt.Fprintf(&sb, "Job details: %s\n\n", details)
+	}
+	if labeled && e.cfg.FailedLabel != "" {
+		fmt.Fprintf(&sb, "The issue is labeled `%s`. ", e.cfg.FailedLabel)
+	}
+	sb.WriteString(target.retry)
+	next := strings.TrimSpace(sb.String())
+
+	if target.status != nil {
+		detail := next
+		if step != "" {
+			detail = strings.TrimSpace(fmt.Sprintf("Failed at step `%s`.\n\n%s", step, next))
+		}
+		target.status.fail(ctx, err, detail)
+		return
+	}
+	header := "Automation failed:"
+	if step != "" {
+		header = fmt.Sprintf("Automation failed at step `%s`:", step)
+	}
+	comment := strings.TrimSpace(fmt.Sprintf("%s\n\n> %s\n\n%s", header, summary, next))
+	if err := e.gh.CreateIssueComment(ctx, owner, repo, target.number, comment); err != nil {
+		log.Warn("failed to post failure comment", "error", err)
+	}
+}
+
+// markFailed swaps the in-progress label of a failed issue run for the failed
+// label, leaving labels the run did not set alone. The labels are re-read,
+// since people may have changed them while the run was in flight.
+func (e *Engine) markFailed(ctx context.Context, owner, repo string, number int, log *logging.Logger) bool {
+	issue, err := e.gh.GetIssue(ctx, owner, repo, number)
+	if err != nil {
+		log.Warn("failed to read labels after failure", "error", err)
+		return false
+	}
+	if err := e.gh.SetIssueLabels(ctx, owner, repo, number, updateProgressLabels(issue.Labels, e.cfg.FailedLabel, e.cfg.InProgressLabel)); err != nil {
+		log.Warn("failed to set labels after failure", "error", err)
+		return false
+	}
+	return true
+}
+
+// issueRetryHint tells people how to rerun a failed issue run. The trigger
+// label is still set when the run failed before labeling it in-progress.
+func (e *Engine) issueRetryHint(inProgress bool) string {
+	if len(e.cfg.TriggerLabels) == 0 {
+		return ""
+	}
+	if inProgress {
+		return fmt.Sprintf("Add the `%s` label to retry.", e.cfg.TriggerLabels[0])
+	}
+	return fmt.Sprintf("Remove and add the `%s` label again to retry.", e.cfg.TriggerLabels[0])
+}
+
+// commandRetryHint tells people how to rerun a failed comment command.
+const commandRetryHint = "Comment the command again to retry."
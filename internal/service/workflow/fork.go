@@ -0,0 +1,135 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"git_sonic/pkg/github"
+	"git_sonic/pkg/gitutil"
+	"git_sonic/pkg/logging"
+	"github.com/MimeLyc/agent-core-go/pkg/llm"
+)
+
+// forkRemote is the remote name used for the head repository of fork PRs.
+const forkRemote = "fork"
+
+// addForkRemote registers the PR head repository as the fork remote and
+// fetches the head branch from it.
+func (e *Engine) addForkRemote(ctx context.Context, repDir string, pr github.PR) error {
+	if pr.HeadCloneURL == "" {
+		return fmt.Errorf("PR #%d head repository %s has no clone URL", pr.Number, pr.HeadRepoFullName)
+	}
+	remoteURL, err := gitutil.InjectToken(pr.HeadCloneURL, e.cfg.GitHubToken)
+	if err != nil {
+		return err
+	}
+	if err := e.git.AddRemote(ctx, repDir, forkRemote, remoteURL); err != nil {
+		return err
+	}
+	return e.git.Fetch(ctx, repDir, forkRemote, pr.HeadRef)
+}
+
+// checkoutPRHead checks out the PR head branch, fetching it from the fork for
+// fork PRs, and returns the remote holding the head branch.
+func (e *Engine) checkoutPRHead(ctx context.Context, repDir, repoFullName string, pr github.PR) (string, error) {
+	remote := "origin"
+	if pr.IsFork(repoFullName) {
+		remote = forkRemote
+		if err := e.addForkRemote(ctx, repDir, pr); err != nil {
+			return "", err
+		}
+	}
+	return remote, e.git.CheckoutBranch(ctx, repDir, pr.HeadRef, remote+"/"+pr.HeadRef)
+}
+
+// openForkFollowUp pushes the committed changes to a new branch in the base
+// repository and opens a PR against the fork author's branch. It is used when
+// the fork does not allow maintainers to push to the head branch. The token
+// usually cannot open PRs in the fork; the pushed branch and a compare link
+// are then posted on the original PR instead, and the returned URL is empty.
+func (e *Engine) openForkFollowUp(ctx context.Context, owner, repo string, pr github.PR, resp llm.Response, stats DiffStats, slash, sender, repDir string, log *logging.Logger) (string, error) {
+	forkOwner, forkRepo, err := splitFullName(pr.HeadRepoFullName)
+	if err != nil {
+		return "", log.WrapError("push-follow-up-branch", "splitFullName", err)
+	}
+
+	done := log.Step("push-follow-up-branch")
+	branch, err := e.prBranchName(owner+"/"+repo, pr, sender, "")
+	if err != nil {
+		done(err)
+		return "", log.WrapError("push-follow-up-branch", "prBranchName", err)
+	}
+	if branch, err = e.unusedBranchName(ctx, repDir, branch); err != nil {
+		done(err)
+		return "", log.WrapError("push-follow-up-branch", "unusedBranchName", err)
+	}
+	log.Info("using branch", "branch", branch)
+	if err := e.git.CheckoutBranch(ctx, repDir, branch, ""); err != nil {
+		done(err)
+		return "", log.WrapError("push-follow-up-branch", "CheckoutBranch", err)
+	}
+	if err := e.git.Push(ctx, repDir, branch); err != nil {
+		done(err)
+		return "", log.WrapError("push-follow-up-branch", "Push", err)
+	}
+	done(nil)
+
+	done = log.Step("create-follow-up-pr", "repo", pr.HeadRepoFullName, "base", pr.HeadRef)
+	title := fallback(resp.PRTitle, fmt.Sprintf("Automated changes for %s/%s#%d", owner, repo, pr.Number))
+	body := withChangesSummary(fallback(resp.PRBody, fmt.Sprintf("Changes requested via `%s` on %s/%s#%d.", slash, owner, repo, pr.Number)), stats)
+	followUp, err := e.gh.CreatePR(ctx, forkOwner, forkRepo, github.PRRequest{
+		Title: title,
+		Head:  owner + ":" + branch,
+		Base:  pr.HeadRef,
+		Body:  body,
+	})
+	comment := ""
+	if err != nil {
+		log.Warn("cannot open a follow-up PR in the fork, linking the branch instead", "error", err)
+		comment = forkBranchComment(slash, owner, repo, pr, branch)
+	} else {
+		log.Info("follow-up PR created", "pr", followUp.Number, "url", followUp.URL)
+		comment = forkFollowUpComment(slash, pr, followUp)
+	}
+	done(nil)
+
+	done = log.Step("post-completion-comment")
+	if err := e.gh.CreateIssueComment(ctx, owner, repo, pr.Number, comment); err != nil {
+		done(err)
+		return "", log.WrapError("post-completion-comment", "CreateIssueComment", err)
+	}
+	done(nil)
+	return followUp.URL, nil
+}
+
+func forkFollowUpComment(slash string, pr github.PR, followUp github.PR) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Automation applied: %s\n\n", slash)
+	fmt.Fprintf(&sb, "This PR's branch lives in `%s` and does not allow edits from maintainers, ", pr.HeadRepoFullName)
+	fmt.Fprintf(&sb, "so the changes were opened as a follow-up PR against `%s`: %s", pr.HeadRef, followUp.URL)
+	return sb.String()
+}
+
+// forkBranchComment explains how to pick up changes pushed to branch when no
+// follow-up PR could be opened in the fork.
+func forkBranchComment(slash, owner, repo string, pr github.PR, branch string) string {
+	compare := fmt.Sprintf("%s/%s/compare/%s...%s:%s:%s", webBaseURL(pr.URL), pr.HeadRepoFullName, pr.HeadRef, owner, repo, branch)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Automation applied: %s\n\n", slash)
+	fmt.Fprintf(&sb, "This PR's branch lives in `%s` and does not allow edits from maintainers, ", pr.HeadRepoFullName)
+	fmt.Fprintf(&sb, "and a follow-up PR could not be opened there. The changes were pushed to `%s` in this repository.\n\n", branch)
+	fmt.Fprintf(&sb, "@%s, to take them, open a PR from it into `%s` (%s), or enable \"Allow edits from maintainers\" on this PR and run `%s` again.", pr.Author, pr.HeadRef, compare, slash)
+	return sb.String()
+}
+
+// webBaseURL returns the GitHub web origin (e.g. "https://github.com") from
+// an HTML URL, defaulting to github.com.
+func webBaseURL(htmlURL string) string {
+	parsed, err := url.Parse(htmlURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return "https://github.com"
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
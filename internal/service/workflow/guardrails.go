@@ -0,0 +1,65 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"git_sonic/pkg/policy"
+)
+
+// policyRulesFor returns the guardrail rules that apply to a repository.
+func (e *Engine) policyRulesFor(repoFullName string) policy.Rules {
+	enabled := e.cfg.PolicyRules
+	if repoRules := e.cfg.ForRepo(repoFullName).PolicyRules; repoRules != nil {
+		enabled = repoRules
+	}
+	return policy.Rules{Enabled: enabled, MaxFileBytes: e.cfg.PolicyMaxFileBytes}
+}
+
+// policyViolations evaluates the guardrail rules against the diff of the
+// uncommitted changes in repDir.
+func (e *Engine) policyViolations(repoFullName, repDir, diff string) []policy.Violation {
+	rules := e.policyRulesFor(repoFullName)
+	if len(rules.Enabled) == 0 {
+		return nil
+	}
+	files := parseDiff(diff)
+	changes := make([]policy.Change, 0, len(files))
+	for _, f := range files {
+		change := policy.Change{
+			Path:         f.path,
+			Deleted:      f.deleted,
+			Binary:       f.binary,
+			AddedLines:   f.added,
+			RemovedLines: f.removed,
+		}
+		if info, err := os.Stat(filepath.Join(repDir, f.path)); err == nil {
+			change.Size = info.Size()
+		}
+		changes = append(changes, change)
+	}
+	return policy.Evaluate(rules, changes)
+}
+
+// policyComment explains which guardrail rules blocked a change.
+func policyComment(violations []policy.Violation) string {
+	var b strings.Builder
+	b.WriteString("Automation stopped: the proposed change breaks the repository guardrails and was not committed.\n\n")
+	for _, v := range violations {
+		b.WriteString("- " + v.String() + "\n")
+	}
+	b.WriteString("\nPlease adjust the request or ask a maintainer to make this change manually.")
+	return b.String()
+}
+
+// policyRules lists the distinct rules of violations, for logs and errors.
+func policyRules(violations []policy.Violation) []string {
+	var rules []string
+	for _, v := range violations {
+		if !contains(v.Rule, rules) {
+			rules = append(rules, v.Rule)
+		}
+	}
+	return rules
+}
@@ -0,0 +1,90 @@
+package workflow
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/pkg/gitutil"
+	"git_sonic/pkg/logging"
+)
+
+// MirrorsSubdir is the directory under RepoCloneBase holding per-repository bare mirrors.
+const MirrorsSubdir = ".mirrors"
+
+// keyedMutex provides a reader/writer lock per key (e.g. per mirror directory).
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*sync.RWMutex
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: map[string]*sync.RWMutex{}}
+}
+
+// Lock acquires the exclusive lock for key and returns its release function.
+func (k *keyedMutex) Lock(key string) func() {
+	lock := k.get(key)
+	lock.Lock()
+	return lock.Unlock
+}
+
+// RLock acquires the shared lock for key and returns its release function.
+func (k *keyedMutex) RLock(key string) func() {
+	lock := k.get(key)
+	lock.RLock()
+	return lock.RUnlock
+}
+
+func (k *keyedMutex) get(key string) *sync.RWMutex {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	lock, ok := k.locks[key]
+	if !ok {
+		lock = &sync.RWMutex{}
+		k.locks[key] = lock
+	}
+	return lock
+}
+
+// cloneRepository clones cloneURL into dir, borrowing objects from the
+// repository mirror when the mirror cache is enabled. The mirror is fetched
+// under an exclusive lock and read under a shared lock while cloning. The
+// clone keeps borrowing objects from the mirror, which the janitor never
+// removes and which never prunes objects. Mirror failures fall back to a
+// direct clone.
+func (e *Engine) cloneRepository(ctx context.Context, repo webhook.Repository, cloneURL, dir string, log *logging.Logger) error {
+	opts := gitutil.CloneOptions{Depth: e.cfg.CloneDepth, Filter: e.cfg.CloneFilter}
+	if e.cfg.MirrorCache && repo.FullName != "" {
+		mirror := e.mirrorDir(repo.FullName)
+		unlock := e.mirrors.Lock(mirror)
+		log.Debug("syncing repository mirror", "mirror", mirror)
+		err := e.git.SyncMirror(ctx, cloneURL, mirror)
+		unlock()
+		if err != nil {
+			log.Warn("mirror sync failed, cloning directly", "mirror", mirror, "error", err)
+		} else {
+			opts.Reference = mirror
+			defer e.mirrors.RLock(mirror)()
+		}
+	}
+	log.Debug("cloning repository", "url", gitutil.RedactToken(cloneURL), "target", dir,
+		"depth", opts.Depth, "filter", opts.Filter, "reference", opts.Reference)
+	return e.git.CloneWithOptions(ctx, cloneURL, dir, opts)
+}
+
+// mirrorDir returns the bare mirror path for a repository full name.
+func (e *Engine) mirrorDir(fullName string) string {
+	name := strings.ToLower(strings.ReplaceAll(fullName, "/", string(filepath.Separator)))
+	return filepath.Join(e.cloneBase(), MirrorsSubdir, name+".git")
+}
+
+// cloneBase returns the root directory for workspaces and mirrors.
+func (e *Engine) cloneBase() string {
+	if e.cfg.RepoCloneBase == "" {
+		return "./workdir"
+	}
+	return e.cfg.RepoCloneBase
+}
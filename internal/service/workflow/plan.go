@@ -0,0 +1,130 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/pkg/github"
+	"git_sonic/pkg/logging"
+	"github.com/MimeLyc/agent-core-go/pkg/llm"
+)
+
+// Markers delimiting the plan in the plan comment, so approval can find it.
+const (
+	planStartMarker = "<!-- git-sonic:plan -->"
+	planEndMarker   = "<!-- git-sonic:plan-end -->"
+)
+
+// planModeFor reports whether plan mode applies to a repository.
+func (e *Engine) planModeFor(repoFullName string) bool {
+	if planMode := e.cfg.ForRepo(repoFullName).PlanMode; planMode != nil {
+		return *planMode
+	}
+	return e.cfg.PlanMode
+}
+
+// planApproval checks a comment on a plan-pending issue for an approval by a
+// user with write access. It returns the latest plan and the approver's edits;
+// an empty plan means the comment does not approve the plan.
+func (e *Engine) planApproval(ctx context.Context, owner, repo string, event webhook.Event, comments []github.Comment, log *logging.Logger) (plan, edits string, err error) {
+	edits, ok := commandArgs(event.CommentBody, e.cfg.ApproveCommand)
+	if !ok {
+		log.Info("plan pending approval, ignoring comment", "sender", event.Sender)
+		return "", "", nil
+	}
+	permission, err := e.gh.GetCollaboratorPermission(ctx, owner, repo, event.Sender)
+	if err != nil {
+		return "", "", err
+	}
+	if permission != "admin" && permission != "write" {
+		log.Warn("plan approval by unauthorized user", "sender", event.Sender, "permission", permission)
+		comment := fmt.Sprintf("@%s only users with write access can approve the plan.", event.Sender)
+		return "", "", e.gh.CreateIssueComment(ctx, owner, repo, event.Issue.Number, comment)
+	}
+	plan = latestPlan(comments, e.cfg.BotLogin)
+	if plan == "" {
+		comment := "No implementation plan was found to approve. Re-add the trigger label to request a new plan."
+		return "", "", e.gh.CreateIssueComment(ctx, owner, repo, event.Issue.Number, comment)
+	}
+	log.Info("plan approved", "approver", event.Sender, "has_edits", edits != "")
+	return plan, edits, nil
+}
+
+// postPlan posts the plan from a planning run and marks the issue as awaiting approval.
+func (e *Engine) postPlan(ctx context.Context, owner, repo string, issue github.Issue, resp llm.Response, log *logging.Logger) error {
+	done := log.Step("post-plan")
+	plan := fallback(resp.Summary, resp.PRBody)
+	if strings.TrimSpace(plan) == "" {
+		err := errors.New("LLM returned an empty plan")
+		done(err)
+		return log.WrapError("post-plan", "postPlan", err)
+	}
+	if err := e.gh.CreateIssueComment(ctx, owner, repo, issue.Number, planComment(plan, e.cfg.ApproveCommand)); err != nil {
+		done(err)
+		return log.WrapError("post-plan", "CreateIssueComment", err)
+	}
+	labelsToRemove := append([]string{e.cfg.InProgressLabel, e.cfg.DoneLabel, e.cfg.NeedsInfoLabel, e.cfg.FailedLabel}, e.cfg.TriggerLabels...)
+	labels := updateProgressLabels(issue.Labels, e.cfg.PlanPendingLabel, labelsToRemove...)
+	if err := e.gh.SetIssueLabels(ctx, owner, repo, issue.Number, labels); err != nil {
+		done(err)
+		return log.WrapError("post-plan", "SetIssueLabels", err)
+	}
+	done(nil)
+	return nil
+}
+
+// latestPlan extracts the plan from the most recent plan comment posted by
+// botLogin. Plan markers in other users' comments are ignored so that nobody
+// can get a forged plan approved.
+func latestPlan(comments []github.Comment, botLogin string) string {
+	for i := len(comments) - 1; i >= 0; i-- {
+		if comments[i].User != botLogin {
+			continue
+		}
+		body := comments[i].Body
+		start := strings.Index(body, planStartMarker)
+		if start < 0 {
+			continue
+		}
+		body = body[start+len(planStartMarker):]
+		if end := strings.Index(body, planEndMarker); end >= 0 {
+			body = body[:end]
+		}
+		return strings.TrimSpace(body)
+	}
+	return ""
+}
+
+// commandArgs returns the text following command in body and whether the
+// command is present. The text runs to the end of the comment so approvers
+// can add multi-line edits.
+func commandArgs(body, command string) (string, bool) {
+	if command == "" {
+		return "", false
+	}
+	for offset := 0; ; {
+		idx := strings.Index(body[offset:], command)
+		if idx < 0 {
+			return "", false
+		}
+		idx += offset
+		end := idx + len(command)
+		if end == len(body) || strings.ContainsRune(" \t\r\n", rune(body[end])) {
+			return strings.TrimSpace(body[end:]), true
+		}
+		offset = end
+	}
+}
+
+func planComment(plan, approveCommand string) string {
+	var sb strings.Builder
+	sb.WriteString("## Proposed implementation plan\n\n")
+	sb.WriteString(planStartMarker + "\n")
+	sb.WriteString(strings.TrimSpace(plan) + "\n")
+	sb.WriteString(planEndMarker + "\n\n")
+	fmt.Fprintf(&sb, "Reply `%s` to implement this plan. Text after the command is passed to the implementation as edits to the plan.", approveCommand)
+	return sb.String()
+}
@@ -0,0 +1,91 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/pkg/github"
+)
+
+// acknowledgements records the triggering comments that started a run, so
+// that only those get a result reaction.
+type acknowledgements struct {
+	mu       sync.Mutex
+	comments map[string]bool
+}
+
+func newAcknowledgements() *acknowledgements {
+	return &acknowledgements{comments: map[string]bool{}}
+}
+
+func acknowledgementKey(event webhook.Event) string {
+	return fmt.Sprintf("%s/%d", event.Type, event.CommentID)
+}
+
+// add records that event was acknowledged. A nil set records nothing.
+func (a *acknowledgements) add(event webhook.Event) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.comments[acknowledgementKey(event)] = true
+}
+
+// take reports whether event was acknowledged and forgets it.
+func (a *acknowledgements) take(event webhook.Event) bool {
+	if a == nil {
+		return false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := acknowledgementKey(event)
+	acknowledged := a.comments[key]
+	delete(a.comments, key)
+	return acknowledged
+}
+
+// acknowledge adds 👀 to the comment that triggered event so that its author
+// can see it was picked up. Handlers call it once they commit to a run; the
+// engine must already be the one returned by forRepository.
+func (e *Engine) acknowledge(ctx context.Context, event webhook.Event) {
+	if event.CommentID == 0 || event.Action != "created" {
+		return
+	}
+	e.acks.add(event)
+	e.react(ctx, event, github.ReactionEyes)
+}
+
+// ReportResult adds 🚀 to the triggering comment when its job succeeded and
+// 😕 when it failed. Comments that did not start a run get no reaction.
+func (e *Engine) ReportResult(ctx context.Context, event webhook.Event, err error) {
+	if event.CommentID == 0 || !e.acks.take(event) {
+		return
+	}
+	content := github.ReactionRocket
+	if err != nil {
+		content = github.ReactionConfused
+	}
+	e.forRepository(event.Repository.FullName).react(ctx, event, content)
+}
+
+// react adds a reaction to the triggering comment of event. Reactions are
+// acknowledgements only, so failures are logged and otherwise ignored.
+func (e *Engine) react(ctx context.Context, event webhook.Event, content string) {
+	log := e.logger.With("delivery_id", event.DeliveryID, "comment_id", event.CommentID, "reaction", content)
+	owner, repo, err := splitFullName(event.Repository.FullName)
+	if err != nil {
+		log.Warn("failed to add reaction", "error", err)
+		return
+	}
+	if event.Type == webhook.EventPRComment {
+		err = e.gh.CreateReviewCommentReaction(ctx, owner, repo, event.CommentID, content)
+	} else {
+		err = e.gh.CreateIssueCommentReaction(ctx, owner, repo, event.CommentID, content)
+	}
+	if err != nil {
+		log.Warn("failed to add reaction", "error", err)
+	}
+}
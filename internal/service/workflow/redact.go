@@ -0,0 +1,97 @@
+package workflow
+
+import (
+	"context"
+	"os"
+
+	"git_sonic/pkg/github"
+	"git_sonic/pkg/redact"
+	"github.com/MimeLyc/agent-core-go/pkg/llm"
+)
+
+// writeFile writes a workspace artifact with secrets redacted. Repository
+// files written on behalf of the LLM must not go through it.
+func (e *Engine) writeFile(path string, data []byte) error {
+	return os.WriteFile(path, e.redactor.Bytes(data), 0o644)
+}
+
+// redactRequest redacts the text fields of an LLM request. It works on the
+// fields rather than the marshalled JSON, where escaping of characters such
+// as <, & or " would keep secrets containing them from matching.
+func (e *Engine) redactRequest(req llm.Request) llm.Request {
+	r := e.redactor
+	req.TaskTitle = r.String(req.TaskTitle)
+	req.TaskBody = r.String(req.TaskBody)
+	req.TaskLabels = r.Strings(append([]string(nil), req.TaskLabels...))
+	req.TaskComments = redactComments(r, req.TaskComments)
+	if req.Metadata != nil {
+		metadata := make(map[string]string, len(req.Metadata))
+		for k, v := range req.Metadata {
+			metadata[k] = r.String(v)
+		}
+		req.Metadata = metadata
+	}
+	req.IssueTitle = r.String(req.IssueTitle)
+	req.IssueBody = r.String(req.IssueBody)
+	req.IssueLabels = r.Strings(append([]string(nil), req.IssueLabels...))
+	req.IssueComments = redactComments(r, req.IssueComments)
+	req.PRTitle = r.String(req.PRTitle)
+	req.PRBody = r.String(req.PRBody)
+	req.PRHeadRef = r.String(req.PRHeadRef)
+	req.PRBaseRef = r.String(req.PRBaseRef)
+	req.CommentBody = r.String(req.CommentBody)
+	req.SlashCommand = r.String(req.SlashCommand)
+	req.RepoInstructions = r.String(req.RepoInstructions)
+	req.Requirements = r.String(req.Requirements)
+	return req
+}
+
+func redactComments(r *redact.Redactor, comments []llm.Comment) []llm.Comment {
+	if comments == nil {
+		return nil
+	}
+	redacted := make([]llm.Comment, len(comments))
+	for i, c := range comments {
+		redacted[i] = llm.Comment{User: c.User, Body: r.String(c.Body)}
+	}
+	return redacted
+}
+
+// redactingGitHub redacts secrets from everything posted to GitHub.
+type redactingGitHub struct {
+	GitHubClient
+	r *redact.Redactor
+}
+
+func (g redactingGitHub) CreateIssueComment(ctx context.Context, owner, repo string, number int, body string) error {
+	return g.GitHubClient.CreateIssueComment(ctx, owner, repo, number, g.r.String(body))
+}
+
+func (g redactingGitHub) CreateIssueCommentID(ctx context.Context, owner, repo string, number int, body string) (int64, error) {
+	return g.GitHubClient.CreateIssueCommentID(ctx, owner, repo, number, g.r.String(body))
+}
+
+func (g redactingGitHub) UpdateIssueComment(ctx context.Context, owner, repo string, commentID int64, body string) error {
+	return g.GitHubClient.UpdateIssueComment(ctx, owner, repo, commentID, g.r.String(body))
+}
+
+func (g redactingGitHub) CreatePR(ctx context.Context, owner, repo string, req github.PRRequest) (github.PR, error) {
+	req.Title = g.r.String(req.Title)
+	req.Body = g.r.String(req.Body)
+	return g.GitHubClient.CreatePR(ctx, owner, repo, req)
+}
+
+func (g redactingGitHub) UpdatePRBody(ctx context.Context, owner, repo string, number int, body string) error {
+	return g.GitHubClient.UpdatePRBody(ctx, owner, repo, number, g.r.String(body))
+}
+
+func (g redactingGitHub) CreatePRReview(ctx context.Context, owner, repo string, number int, review github.ReviewRequest) error {
+	review.Body = g.r.String(review.Body)
+	comments := make([]github.ReviewComment, len(review.Comments))
+	for i, c := range review.Comments {
+		c.Body = g.r.String(c.Body)
+		comments[i] = c
+	}
+	review.Comments = comments
+	return g.GitHubClient.CreatePRReview(ctx, owner, repo, number, review)
+}
@@ -0,0 +1,48 @@
+package workflow
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/MimeLyc/agent-core-go/pkg/llm"
+)
+
+// decodeExtended decodes mode-specific response fields into T. Runners only
+// parse the standard response, so the raw output file and stdout are decoded
+// again; the first JSON object for which accept returns true wins.
+func decodeExtended[T any](outputPath string, result llm.RunResult, accept func(T) bool) (T, bool) {
+	var candidates []string
+	if outputPath != "" {
+		if data, err := os.ReadFile(outputPath); err == nil {
+			candidates = append(candidates, string(data))
+		}
+	}
+	candidates = append(candidates, result.Stdout)
+	// API runners return the chat completion envelope as stdout.
+	var envelope struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if json.Unmarshal([]byte(result.Stdout), &envelope) == nil && len(envelope.Choices) > 0 {
+		candidates = append(candidates, envelope.Choices[0].Message.Content)
+	}
+	for _, text := range candidates {
+		for idx := strings.IndexByte(text, '{'); idx >= 0; {
+			var value T
+			if err := json.NewDecoder(strings.NewReader(text[idx:])).Decode(&value); err == nil && accept(value) {
+				return value, true
+			}
+			next := strings.IndexByte(text[idx+1:], '{')
+			if next < 0 {
+				break
+			}
+			idx += next + 1
+		}
+	}
+	var zero T
+	return zero, false
+}
@@ -0,0 +1,308 @@
+package workflow
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/pkg/github"
+	"git_sonic/pkg/logging"
+	"github.com/MimeLyc/agent-core-go/pkg/llm"
+)
+
+// DiffFile is written to the outputs subdirectory by review runs.
+const DiffFile = "pr.diff"
+
+// maxReviewDiffBytes bounds the diff embedded in the review context.
+const maxReviewDiffBytes = 200 * 1024
+
+// ReviewResult is the review-specific part of the LLM response.
+type ReviewResult struct {
+	Summary        string          `json:"summary"`
+	ReviewComments []ReviewComment `json:"review_comments"`
+}
+
+// ReviewComment is an inline comment proposed by the LLM.
+type ReviewComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Body string `json:"body"`
+}
+
+var reviewInstructions = strings.Join([]string{
+	"This is a code review run: do NOT modify any files and leave 'files' and 'patch' empty.",
+	"The pull request diff is in metadata.diff; the PR head is checked out in the working directory.",
+	"Respond with decision=proceed, a Markdown review summary in 'summary', and a 'review_comments' array.",
+	"Each review comment is {\"path\": \"<file>\", \"line\": <line number in the new version of the file>, \"body\": \"<comment>\"}.",
+	"Only comment on lines that are added or shown as context in the diff. Focus on bugs, security, and maintainability; do not nitpick style.",
+}, "\n")
+
+// HandlePullRequest reviews newly opened or ready-for-review PRs in
+// repositories with auto_review enabled.
+func (e *Engine) HandlePullRequest(ctx context.Context, event webhook.Event) error {
+	e = e.forRepository(event.Repository.FullName)
+	log := e.logger.With("delivery_id", event.DeliveryID, "event_type", event.Type)
+
+	if event.Type != webhook.EventPullRequest {
+		log.Debug("skipping event: not a pull_request event")
+		return nil
+	}
+	if event.Action != "opened" && event.Action != "ready_for_review" {
+		log.Debug("skipping event: action is not opened or ready_for_review", "action", event.Action)
+		return nil
+	}
+	if event.PullRequest == nil {
+		log.Warn("skipping event: missing pull request payload")
+		return errors.New("missing pull request payload")
+	}
+	if !e.cfg.ForRepo(event.Repository.FullName).AutoReview {
+		log.Debug("skipping event: auto review is disabled for repository", "repo", event.Repository.FullName)
+		return nil
+	}
+	if event.PullRequest.Draft || strings.HasSuffix(event.PullRequest.Author, "[bot]") {
+		log.Debug("skipping event: draft or bot-authored PR", "pr", event.PullRequest.Number, "author", event.PullRequest.Author)
+		return nil
+	}
+	return e.runReview(ctx, event, event.PullRequest.Number, "auto")
+}
+
+// runReview starts a review workflow for a PR. Reviews run the agent on
+// untrusted PR content, so they need the read-only runner, and reviews
+// requested by command need write access.
+func (e *Engine) runReview(ctx context.Context, event webhook.Event, number int, trigger string) error {
+	log := e.logger.With("delivery_id", event.DeliveryID, "event_type", event.Type)
+	owner, repo, err := splitFullName(event.Repository.FullName)
+	if err != nil {
+		return err
+	}
+	if e.askLLM == nil {
+		log.Info("skipping review: no read-only runner", "pr", number, "trigger", trigger)
+		if trigger == "auto" {
+			return nil
+		}
+		comment := fmt.Sprintf("@%s `%s` is not supported for the configured agent.", event.Sender, e.cfg.ReviewCommand)
+		return e.gh.CreateIssueComment(ctx, owner, repo, number, comment)
+	}
+	if trigger == "command" {
+		permission, err := e.gh.GetCollaboratorPermission(ctx, owner, repo, event.Sender)
+		if err != nil {
+			return err
+		}
+		if permission != "admin" && permission != "write" {
+			log.Warn("review by unauthorized user", "sender", event.Sender, "permission", permission)
+			comment := fmt.Sprintf("@%s only users with write access can request reviews.", event.Sender)
+			return e.gh.CreateIssueComment(ctx, owner, repo, number, comment)
+		}
+	}
+	e.acknowledge(ctx, event)
+
+	wfLog := e.logger.StartWorkflow("pr-review",
+		"pr", number,
+		"repo", event.Repository.FullName,
+		"trigger", trigger,
+		"sender", event.Sender,
+	)
+	err = e.handleReview(ctx, event, number, wfLog)
+	wfLog.EndWorkflow(err)
+	return err
+}
+
+// handleReview reviews a PR and submits a GitHub review with inline comments.
+// It never commits or pushes.
+func (e *Engine) handleReview(ctx context.Context, event webhook.Event, number int, log *logging.Logger) (err error) {
+	// Step 1: Parse repository info
+	done := log.Step("parse-repo-info")
+	owner, repo, err := splitFullName(event.Repository.FullName)
+	if err != nil {
+		done(err)
+		return log.WrapError("parse-repo-info", "splitFullName", err)
+	}
+	done(nil)
+
+	// Step 2: Get PR details
+	done = log.Step("get-pr-details", "pr", number)
+	pr, err := e.gh.GetPR(ctx, owner, repo, number)
+	if err != nil {
+		done(err)
+		return log.WrapError("get-pr-details", "GetPR", err)
+	}
+	if pr.State != "open" {
+		log.Info("PR is not open, skipping", "state", pr.State)
+		done(nil)
+		return nil
+	}
+	done(nil)
+
+	// Step 3: Prepare workspace
+	done = log.Step("prepare-workspace")
+	workDir, err := e.prepareWorkspace(ctx, event.Repository, fmt.Sprintf("review-%d", pr.Number), log)
+	if err != nil {
+		done(err)
+		return err // Already wrapped
+	}
+	defer func() { e.writeRunStatus(workDir, err) }()
+	repDir := repoDir(workDir)
+	done(nil)
+
+	// Step 4: Checkout PR head
+	done = log.Step("checkout-branch", "branch", pr.HeadRef)
+	remote, err := e.checkoutPRHead(ctx, repDir, event.Repository.FullName, pr)
+	if err != nil {
+		done(err)
+		return log.WrapError("checkout-branch", "checkoutPRHead", err)
+	}
+	done(nil)
+
+	// Step 5: Clear remote auth so the agent never sees the token
+	done = log.Step("clear-remote-auth")
+	remotes := []string{"origin"}
+	if remote != "origin" {
+		remotes = append(remotes, remote)
+	}
+	for _, name := range remotes {
+		if err := e.git.ClearRemoteAuth(ctx, repDir, name); err != nil {
+			done(err)
+			return log.WrapError("clear-remote-auth", "ClearRemoteAuth", err)
+		}
+	}
+	done(nil)
+
+	// Step 6: Get PR diff
+	done = log.Step("get-pr-diff")
+	diff, err := e.gh.GetPRDiff(ctx, owner, repo, pr.Number)
+	if err != nil {
+		done(err)
+		return log.WrapError("get-pr-diff", "GetPRDiff", err)
+	}
+	_ = e.writeFile(filepath.Join(outputsDir(workDir), DiffFile), []byte(diff))
+	done(nil)
+
+	// Step 7: Prepare LLM prompt
+	done = log.Step("prepare-llm-prompt")
+	contextDiff := diff
+	if len(contextDiff) > maxReviewDiffBytes {
+		contextDiff = contextDiff[:maxReviewDiffBytes] + "\n... (diff truncated)"
+	}
+	contextReq := llm.Request{
+		Mode:         "review",
+		RepoPath:     repDir,
+		RepoFullName: event.Repository.FullName,
+		PRNumber:     pr.Number,
+		PRTitle:      pr.Title,
+		PRBody:       pr.Body,
+		PRHeadRef:    pr.HeadRef,
+		PRBaseRef:    pr.BaseRef,
+		CommentBody:  event.CommentBody,
+		Metadata:     map[string]string{"diff": contextDiff, "head_sha": pr.HeadSHA},
+		Requirements: "Review the pull request and point out problems with inline comments. Do not change any files.",
+	}
+	request, err := e.preparePrompt(workDir, contextReq)
+	if err != nil {
+		done(err)
+		return log.WrapError("prepare-llm-prompt", "preparePrompt", err)
+	}
+	done(nil)
+
+	// Step 8: Run LLM with the read-only runner
+	done = log.Step("run-llm")
+	result, err := e.askLLM.Run(ctx, request, repDir)
+	e.writeArtifacts(workDir, request, result, err)
+	if err != nil {
+		done(err)
+		return log.WrapError("run-llm", "Run", err)
+	}
+	done(nil)
+	if result.Response.Decision != llm.DecisionProceed {
+		log.StepInfo("check-decision", "LLM decided not to review", "decision", result.Response.Decision)
+		comment := fallback(result.Response.NeedsInfoComment, fallback(result.Response.Summary, "Automated review skipped."))
+		return e.gh.CreateIssueComment(ctx, owner, repo, pr.Number, comment)
+	}
+
+	// Step 9: Submit review
+	review, _ := decodeExtended(request.OutputPath, result, func(r ReviewResult) bool { return r.ReviewComments != nil })
+	review.Summary = fallback(review.Summary, result.Response.Summary)
+	reviewReq := buildReview(review, commentableLines(diff), pr.HeadSHA)
+	done = log.Step("submit-review", "inline_comments", len(reviewReq.Comments))
+	if err := e.gh.CreatePRReview(ctx, owner, repo, pr.Number, reviewReq); err != nil {
+		done(err)
+		return log.WrapError("submit-review", "CreatePRReview", err)
+	}
+	done(nil)
+	return nil
+}
+
+// buildReview turns the LLM review into a GitHub review. Comments on lines
+// outside the diff cannot be posted inline and are listed in the body instead.
+func buildReview(review ReviewResult, commentable map[string]map[int]bool, headSHA string) github.ReviewRequest {
+	req := github.ReviewRequest{CommitID: headSHA, Event: github.ReviewEventComment}
+	var outside []string
+	for _, comment := range review.ReviewComments {
+		if strings.TrimSpace(comment.Body) == "" {
+			continue
+		}
+		if commentable[comment.Path][comment.Line] {
+			req.Comments = append(req.Comments, github.ReviewComment{Path: comment.Path, Line: comment.Line, Side: "RIGHT", Body: comment.Body})
+			continue
+		}
+		outside = append(outside, fmt.Sprintf("- `%s:%d`: %s", comment.Path, comment.Line, comment.Body))
+	}
+	body := strings.TrimSpace(review.Summary)
+	if len(outside) > 0 {
+		body = strings.TrimSpace(body + "\n\n**Comments outside the diff:**\n" + strings.Join(outside, "\n"))
+	}
+	req.Body = fallback(body, "Automated review found no issues.")
+	return req
+}
+
+// commentableLines maps each file in a unified diff to the new-file line
+// numbers that can take inline review comments (added and context lines).
+func commentableLines(diff string) map[string]map[int]bool {
+	lines := map[string]map[int]bool{}
+	var file string
+	line := 0
+	inHeader := false
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			file, line, inHeader = "", 0, true
+		case inHeader && strings.HasPrefix(text, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+			if file == "/dev/null" {
+				file = ""
+			}
+		case strings.HasPrefix(text, "@@ "):
+			line, inHeader = hunkNewStart(text), false
+		case inHeader || file == "" || line == 0:
+		case strings.HasPrefix(text, "+"), strings.HasPrefix(text, " "):
+			if lines[file] == nil {
+				lines[file] = map[int]bool{}
+			}
+			lines[file][line] = true
+			line++
+		}
+	}
+	return lines
+}
+
+// hunkNewStart returns the new-file start line of a hunk header
+// ("@@ -a,b +c,d @@"), or 0 when it cannot be parsed.
+func hunkNewStart(header string) int {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return 0
+	}
+	start, _, _ := strings.Cut(strings.TrimPrefix(fields[2], "+"), ",")
+	n, err := strconv.Atoi(start)
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,287 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"git_sonic/internal/config"
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/pkg/github"
+	"git_sonic/pkg/logging"
+	"github.com/MimeLyc/agent-core-go/pkg/llm"
+)
+
+// taskBranchTimeFormat is the timestamp suffix of task branches.
+const taskBranchTimeFormat = "20060102-150405"
+
+// taskBranch returns the branch of a task run started at t.
+func taskBranch(name string, t time.Time) string {
+	return "llm/task-" + name + "-" + t.Format(taskBranchTimeFormat)
+}
+
+// isTaskBranch reports whether branch was created by a run of the named task.
+// Tasks whose names share a prefix, such as "deps" and "deps-major", do not
+// match each other's branches.
+func isTaskBranch(branch, name string) bool {
+	return regexp.MustCompile(`^llm/task-` + regexp.QuoteMeta(name) + `-\d{8}-\d{6}$`).MatchString(branch)
+}
+
+// HandleScheduledTask runs a scheduled maintenance task and opens a PR with
+// the result. A task is skipped while a PR from an earlier run is still open.
+func (e *Engine) HandleScheduledTask(ctx context.Context, event webhook.Event) error {
+	e = e.forRepository(event.Repository.FullName)
+	log := e.logger.With("delivery_id", event.DeliveryID, "event_type", event.Type)
+
+	if event.Type != webhook.EventSchedule {
+		log.Debug("skipping event: not a schedule event")
+		return nil
+	}
+	task, ok := e.cfg.Task(event.Task)
+	if !ok {
+		log.Warn("skipping event: unknown scheduled task", "task", event.Task)
+		return fmt.Errorf("unknown scheduled task %q", event.Task)
+	}
+
+	// Runs of the same task are serialized so the open-PR check sees earlier runs.
+	unlock := e.tasks.Lock(task.Repo + "/" + task.Name)
+	defer unlock()
+
+	wfLog := e.logger.StartWorkflow("scheduled-task",
+		"task", task.Name,
+		"repo", task.Repo,
+	)
+	err := e.handleScheduledTask(ctx, event, task, wfLog)
+	wfLog.EndWorkflow(err)
+	return err
+}
+
+func (e *Engine) handleScheduledTask(ctx context.Context, event webhook.Event, task config.ScheduledTask, log *logging.Logger) (err error) {
+	// Step 1: Parse repository info
+	done := log.Step("parse-repo-info")
+	owner, repo, err := splitFullName(task.Repo)
+	if err != nil {
+		done(err)
+		return log.WrapError("parse-repo-info", "splitFullName", err)
+	}
+	done(nil)
+
+	// Scheduled tasks have no issue or PR to report on, so failures are logged
+	var workDir string
+	defer func() {
+		var wfErr *logging.WorkflowError
+		if errors.As(err, &wfErr) {
+			target := failureTarget{}
+			e.reportFailure(context.WithoutCancel(ctx), owner, repo, target, err, e.jobDetails(event, 0, workDir, log), log)
+		}
+	}()
+
+	// Step 2: Check for an open PR from an earlier run
+	done = log.Step("check-open-prs")
+	openPRs, err := e.gh.ListOpenPRs(ctx, owner, repo)
+	if err != nil {
+		done(err)
+		return log.WrapError("check-open-prs", "ListOpenPRs", err)
+	}
+	for _, pr := range openPRs {
+		if isTaskBranch(pr.HeadRef, task.Name) {
+			log.Info("task PR still open, skipping", "pr", pr.Number, "url", pr.URL)
+			done(nil)
+			return nil
+		}
+	}
+	done(nil)
+
+	// Step 3: Resolve repository clone URL and base branch
+	done = log.Step("resolve-repository")
+	repository := event.Repository
+	baseBranch := fallback(task.BaseBranch, repository.DefaultBranch)
+	if repository.CloneURL == "" || baseBranch == "" {
+		repoInfo, err := e.gh.GetRepo(ctx, owner, repo)
+		if err != nil {
+			done(err)
+			return log.WrapError("resolve-repository", "GetRepo", err)
+		}
+		repository.CloneURL = fallback(repository.CloneURL, repoInfo.CloneURL)
+		repository.DefaultBranch = fallback(repository.DefaultBranch, repoInfo.DefaultBranch)
+		baseBranch = fallback(baseBranch, repoInfo.DefaultBranch)
+	}
+	if repository.CloneURL == "" {
+		err := errors.New("repository clone URL is unknown")
+		done(err)
+		return log.WrapError("resolve-repository", "GetRepo", err)
+	}
+	log.Info("using base branch", "branch", baseBranch)
+	done(nil)
+
+	// Step 4: Prepare workspace
+	done = log.Step("prepare-workspace")
+	workDir, err = e.prepareWorkspace(ctx, repository, "task-"+task.Name, log)
+	if err != nil {
+		done(err)
+		return err // Already wrapped
+	}
+	defer func() { e.writeRunStatus(workDir, err) }()
+	repDir := repoDir(workDir)
+	done(nil)
+
+	// Step 5: Set remote auth
+	done = log.Step("set-remote-auth")
+	if err := e.git.SetRemoteAuth(ctx, repDir, e.cfg.GitHubToken); err != nil {
+		done(err)
+		return log.WrapError("set-remote-auth", "SetRemoteAuth", err)
+	}
+	done(nil)
+
+	// Step 6: Checkout new branch
+	branch := taskBranch(task.Name, e.now())
+	done = log.Step("checkout-branch", "branch", branch, "base", baseBranch)
+	if err := e.git.CheckoutBranch(ctx, repDir, branch, "origin/"+baseBranch); err != nil {
+		done(err)
+		return log.WrapError("checkout-branch", "CheckoutBranch", err)
+	}
+	done(nil)
+
+	// CODEOWNERS comes from the base branch so that the agent cannot rewrite its own protections
+	owners := e.loadCodeOwners(ctx, repDir, "origin/"+baseBranch, log)
+
+	// Step 7: Prepare LLM prompt
+	done = log.Step("prepare-llm-prompt")
+	contextReq := llm.Request{
+		Mode:         "task",
+		RepoPath:     repDir,
+		RepoFullName: task.Repo,
+		TaskBody:     task.Prompt,
+		Metadata:     map[string]string{"task": task.Name, "base_branch": baseBranch},
+		Requirements: "Carry out the scheduled maintenance task in task_body and prepare a PR. Respond with decision=stop if there is nothing to do.",
+	}
+	request, err := e.preparePrompt(workDir, contextReq)
+	if err != nil {
+		done(err)
+		return log.WrapError("prepare-llm-prompt", "preparePrompt", err)
+	}
+	done(nil)
+
+	// Step 8: Run LLM
+	done = log.Step("run-llm")
+	result, err := e.llm.Run(ctx, request, repDir)
+	e.writeArtifacts(workDir, request, result, err)
+	if err != nil {
+		done(err)
+		return log.WrapError("run-llm", "Run", err)
+	}
+	log.Info("LLM completed", "decision", result.Response.Decision, "files_count", len(result.Response.Files))
+	done(nil)
+
+	// Step 9: Check decision (there is no issue to ask for more information on)
+	if result.Response.Decision != llm.DecisionProceed {
+		log.StepInfo("check-decision", "LLM decided not to proceed", "decision", result.Response.Decision,
+			"reason", fallback(result.Response.NeedsInfoComment, result.Response.Summary))
+		return nil
+	}
+
+	// Step 10: Apply changes (write files or apply patch)
+	done = log.Step("apply-changes", "files_count", len(result.Response.Files), "has_patch", result.Response.Patch != "")
+	if err := e.applyChanges(ctx, workDir, result.Response, log); err != nil {
+		done(err)
+		return err // Already wrapped
+	}
+	done(nil)
+
+	// Step 11: Record the diff against the base branch, including commits the
+	// agent made itself
+	done = log.Step("record-changes")
+	diff, stats, err := e.recordChanges(ctx, workDir, "origin/"+baseBranch, log)
+	if err != nil {
+		done(err)
+		return log.WrapError("record-changes", "Diff", err)
+	}
+	if len(stats.Files) == 0 {
+		log.Info("no file changes, nothing to do")
+		done(nil)
+		return nil
+	}
+	changedFiles := stats.Paths()
+	done(nil)
+
+	// Step 12: Enforce change size limits
+	done = log.Step("check-change-limits")
+	if reason := e.changeLimitExceeded(stats); reason != "" {
+		err := fmt.Errorf("change exceeds size limits: %s", reason)
+		done(err)
+		return log.WrapError("check-change-limits", "changeLimitExceeded", err)
+	}
+	done(nil)
+
+	// Step 13: Check guardrail policies
+	done = log.Step("check-policy")
+	if violations := e.policyViolations(task.Repo, repDir, diff); len(violations) > 0 {
+		err := fmt.Errorf("change violates policy: %s", strings.Join(policyRules(violations), ", "))
+		done(err)
+		return log.WrapError("check-policy", "policyViolations", err)
+	}
+	done(nil)
+
+	// Step 14: Check protected paths (CODEOWNERS)
+	done = log.Step("check-protected-paths")
+	if blocked := e.protectedChanges(task.Repo, owners, changedFiles); len(blocked) > 0 {
+		err := fmt.Errorf("changes touch protected paths: %s", strings.Join(blocked, ", "))
+		done(err)
+		return log.WrapError("check-protected-paths", "protectedChanges", err)
+	}
+	done(nil)
+
+	// Step 15: Commit changes
+	done = log.Step("commit-changes")
+	commitMessage := fallback(result.Response.CommitMessage, "Scheduled task: "+task.Name)
+	if err := e.git.CommitAll(ctx, repDir, commitMessage); err != nil {
+		done(err)
+		return log.WrapError("commit-changes", "CommitAll", err)
+	}
+	done(nil)
+
+	// Step 16: Push changes
+	done = log.Step("push-changes", "branch", branch)
+	if err := e.git.Push(ctx, repDir, branch); err != nil {
+		done(err)
+		return log.WrapError("push-changes", "Push", err)
+	}
+	done(nil)
+
+	// Step 17: Create PR
+	done = log.Step("create-pr")
+	prTitle := fallback(result.Response.PRTitle, "Scheduled task: "+task.Name)
+	prBody := strings.TrimSpace(withChangesSummary(fallback(result.Response.PRBody, result.Response.Summary), stats) + taskFooter(task))
+	pr, err := e.gh.CreatePR(ctx, owner, repo, github.PRRequest{Title: prTitle, Body: prBody, Head: branch, Base: baseBranch})
+	if err != nil {
+		done(err)
+		return log.WrapError("create-pr", "CreatePR", err)
+	}
+	log.Info("PR created", "pr_number", pr.Number, "pr_url", pr.URL)
+	done(nil)
+
+	// Step 18: Label PR (optional)
+	if len(task.Labels) > 0 {
+		done = log.Step("label-pr", "labels", task.Labels)
+		if err := e.gh.SetIssueLabels(ctx, owner, repo, pr.Number, task.Labels); err != nil {
+			log.Warn("failed to label PR", "error", err)
+		}
+		done(nil)
+	}
+
+	// Step 19: Request CODEOWNERS reviews (optional)
+	if e.cfg.RequestCodeOwnerReviews && !owners.Empty() {
+		done = log.Step("request-reviewers")
+		e.requestCodeOwnerReviews(ctx, owner, repo, pr.Number, owners, changedFiles, log)
+		done(nil)
+	}
+	return nil
+}
+
+func taskFooter(task config.ScheduledTask) string {
+	return fmt.Sprintf("\n\n---\nOpened by scheduled task `%s` (`%s`):\n\n> %s",
+		task.Name, task.Schedule, strings.ReplaceAll(strings.TrimSpace(task.Prompt), "\n", "\n> "))
+}
@@ -0,0 +1,281 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"git_sonic/pkg/github"
+	"git_sonic/pkg/logging"
+)
+
+// statusMarker starts the hidden HTML comment that identifies a run's status
+// comment so that it can be found and edited in place.
+const statusMarker = "<!-- git-sonic:status"
+
+// Run stages shown in the status comment, in order.
+const (
+	stageQueued = iota
+	stageCloning
+	stageRunning
+	stageVerifying
+	stagePublishing
+)
+
+// Step names of the issue and PR flows, indexed by stage.
+var (
+	issueStatusSteps = []string{"Queued", "Cloning the repository", "Running the agent", "Verifying the changes", "Opening a pull request"}
+	prStatusSteps    = []string{"Queued", "Cloning the repository", "Running the agent", "Verifying the changes", "Pushing the changes"}
+)
+
+// Step states passed to the status template.
+const (
+	stepDone    = "done"
+	stepCurrent = "current"
+	stepPending = "pending"
+	stepFailed  = "failed"
+	stepStopped = "stopped"
+)
+
+var stepIcons = map[string]string{
+	stepDone:    "✅",
+	stepCurrent: "⏳",
+	stepPending: "⬜",
+	stepFailed:  "❌",
+	stepStopped: "⏹️",
+}
+
+// defaultStatusTemplate renders the status comment when STATUS_TEMPLATE_FILE
+// is unset.
+const defaultStatusTemplate = `### {{.Icon}} {{.Headline}}
+
+{{range .Steps}}- {{.Icon}} {{.Name}}
+{{end}}{{with .URL}}
+**Pull request:** {{.}}
+{{end}}{{with .Detail}}
+{{.}}
+{{end}}{{with .Error}}
+<details><summary>Error details</summary>
+
+` + "```" + `
+{{.}}
+` + "```" + `
+
+</details>
+{{end}}`
+
+// statusView is the data passed to the status template.
+type statusView struct {
+	// State is "running", "succeeded", "stopped" or "failed".
+	State    string
+	Icon     string
+	Headline string
+	Steps    []statusStep
+	// URL links the PR the run opened or updated.
+	URL    string
+	Detail string
+	Error  string
+}
+
+type statusStep struct {
+	Name  string
+	State string
+	Icon  string
+}
+
+// statusComment is the single comment a run creates when it starts and edits
+// as it progresses.
+type statusComment struct {
+	gh     GitHubClient
+	tmpl   *template.Template
+	log    *logging.Logger
+	owner  string
+	repo   string
+	number int
+	marker string
+	steps  []string
+	stage  int
+	id     int64
+	posted bool
+	closed bool
+}
+
+// startStatus posts the queued status comment for a run on an issue or PR.
+// Status comments are best effort: failures are logged and never fail the run.
+func (e *Engine) startStatus(ctx context.Context, owner, repo string, number int, runID string, steps []string, log *logging.Logger) *statusComment {
+	if runID == "" {
+		runID = e.now().Format("20060102-150405.000")
+	}
+	s := &statusComment{
+		gh:     e.gh,
+		tmpl:   e.statusTemplate(log),
+		log:    log,
+		owner:  owner,
+		repo:   repo,
+		number: number,
+		marker: fmt.Sprintf("%s run=%s -->", statusMarker, runID),
+		steps:  steps,
+	}
+	s.publish(ctx, s.view("running", "", "", ""))
+	return s
+}
+
+// statusTemplate returns the configured status template, falling back to the
+// built-in one.
+func (e *Engine) statusTemplate(log *logging.Logger) *template.Template {
+	if e.cfg.StatusTemplate != "" {
+		tmpl, err := template.New("status").Parse(e.cfg.StatusTemplate)
+		if err == nil {
+			return tmpl
+		}
+		log.Warn("invalid status template, using the default", "error", err)
+	}
+	return template.Must(template.New("status").Parse(defaultStatusTemplate))
+}
+
+// advance moves the run to a later stage.
+func (s *statusComment) advance(ctx context.Context, stage int) {
+	if s.closed || stage <= s.stage {
+		return
+	}
+	s.stage = stage
+	s.publish(ctx, s.view("running", "", "", ""))
+}
+
+// succeed marks the run finished, linking the PR it opened or updated.
+func (s *statusComment) succeed(ctx context.Context, headline, url string) {
+	s.finish(ctx, s.view("succeeded", headline, url, ""))
+}
+
+// stop marks the run finished without changes; detail says why.
+func (s *statusComment) stop(ctx context.Context, headline, detail string) {
+	s.finish(ctx, s.view("stopped", headline, "", detail))
+}
+
+// close marks the run failed when err is set and it was not finished yet.
+// Runs that return without an outcome are reported as stopped.
+func (s *statusComment) close(ctx context.Context, err error) {
+	if s.closed {
+		return
+	}
+	if err == nil {
+		s.stop(ctx, "Stopped", "")
+		return
+	}
+	s.fail(ctx, err, "")
+}
+
+// fail marks the run failed with err; detail says where to find out more.
+func (s *statusComment) fail(ctx context.Context, err error, detail string) {
+	view := s.view("failed", "", "", detail)
+	_, view.Error = failureSummary(err)
+	s.finish(ctx, view)
+}
+
+func (s *statusComment) finish(ctx context.Context, view statusView) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.publish(ctx, view)
+}
+
+func (s *statusComment) view(state, headline, url, detail string) statusView {
+	view := statusView{State: state, Headline: headline, URL: url, Detail: detail}
+	for i, name := range s.steps {
+		step := statusStep{Name: name, State: stepPending}
+		switch {
+		case i < s.stage:
+			step.State = stepDone
+		case i > s.stage:
+		case state == "running":
+			step.State = stepCurrent
+		case state == "failed":
+			step.State = stepFailed
+		case state == "stopped":
+			step.State = stepStopped
+		default:
+			step.State = stepDone
+		}
+		step.Icon = stepIcons[step.State]
+		view.Steps = append(view.Steps, step)
+	}
+	switch state {
+	case "running":
+		view.Icon = stepIcons[stepCurrent]
+		view.Headline = s.steps[s.stage]
+	case "succeeded":
+		view.Icon = stepIcons[stepDone]
+	case "stopped":
+		view.Icon = stepIcons[stepStopped]
+	case "failed":
+		view.Icon = stepIcons[stepFailed]
+		view.Headline = "Failed: " + s.steps[s.stage]
+	}
+	return view
+}
+
+// publish renders the view and creates or edits the status comment. When the
+// ID of the posted comment is unknown and it cannot be found again by its
+// marker, intermediate stages are dropped and only the final state is posted
+// as a new comment.
+func (s *statusComment) publish(ctx context.Context, view statusView) {
+	var sb strings.Builder
+	if err := s.tmpl.Execute(&sb, view); err != nil {
+		s.log.Warn("failed to render status comment", "error", err)
+		return
+	}
+	body := s.marker + "\n" + strings.TrimSpace(sb.String())
+
+	if s.posted && s.id == 0 && !s.find(ctx) && !s.closed {
+		return
+	}
+	if s.id == 0 {
+		id, err := s.gh.CreateIssueCommentID(ctx, s.owner, s.repo, s.number, body)
+		if err != nil {
+			s.log.Warn("failed to post status comment", "error", err)
+			return
+		}
+		s.id, s.posted = id, true
+		return
+	}
+	if err := s.gh.UpdateIssueComment(ctx, s.owner, s.repo, s.id, body); err != nil {
+		s.log.Warn("failed to update status comment", "error", err)
+	}
+}
+
+// find looks up the ID of the posted status comment by its marker.
+func (s *statusComment) find(ctx context.Context) bool {
+	comments, err := s.gh.ListIssueComments(ctx, s.owner, s.repo, s.number)
+	if err != nil {
+		s.log.Warn("failed to find status comment", "error", err)
+		return false
+	}
+	for i := len(comments) - 1; i >= 0; i-- {
+		if comments[i].ID != 0 && strings.HasPrefix(comments[i].Body, s.marker) {
+			s.id = comments[i].ID
+			return true
+		}
+	}
+	return false
+}
+
+// isStatusComment reports whether body is a status comment. Status comments
+// come back as comment events and must not start runs.
+func isStatusComment(body string) bool {
+	return strings.HasPrefix(body, statusMarker)
+}
+
+// withoutStatusComments drops the status comments posted by botLogin, which
+// are bookkeeping rather than discussion, from an issue's comments. Comments
+// by other users that merely start with the marker are kept.
+func withoutStatusComments(comments []github.Comment, botLogin string) []github.Comment {
+	out := comments[:0:0]
+	for _, c := range comments {
+		if !strings.EqualFold(c.User, botLogin) || !isStatusComment(c.Body) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,289 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/pkg/logging"
+	"github.com/MimeLyc/agent-core-go/pkg/llm"
+)
+
+// TriageFile is written to the outputs subdirectory by triage runs.
+const TriageFile = "triage.json"
+
+// Triage classifications.
+const (
+	TriageBug       = "bug"
+	TriageFeature   = "feature"
+	TriageQuestion  = "question"
+	TriageDuplicate = "duplicate"
+)
+
+// TriageResult is the triage-specific part of the LLM response.
+type TriageResult struct {
+	Classification string   `json:"classification"`
+	Labels         []string `json:"labels,omitempty"`
+	RelevantFiles  []string `json:"relevant_files,omitempty"`
+	Estimate       string   `json:"estimate,omitempty"`
+	DuplicateOf    int      `json:"duplicate_of,omitempty"`
+	Summary        string   `json:"summary,omitempty"`
+}
+
+var triageInstructions = strings.Join([]string{
+	"This is a triage run: do NOT modify any files and leave 'files' and 'patch' empty.",
+	"Respond with decision=proceed and these additional JSON fields:",
+	"- classification: one of bug, feature, question, duplicate.",
+	"- labels: labels to apply, chosen ONLY from metadata.repo_labels (a JSON array).",
+	"- relevant_files: repository paths most likely involved, verified to exist in the working directory.",
+	"- estimate: small, medium or large.",
+	"- duplicate_of: the duplicated issue number, only when classification is duplicate.",
+	"- summary: a short Markdown explanation for the issue author.",
+}, "\n")
+
+// HandleIssueOpened triages newly opened issues when enabled.
+func (e *Engine) HandleIssueOpened(ctx context.Context, event webhook.Event) error {
+	e = e.forRepository(event.Repository.FullName)
+	log := e.logger.With("delivery_id", event.DeliveryID, "event_type", event.Type)
+
+	if event.Type != webhook.EventIssues || event.Action != "opened" {
+		log.Debug("skipping event: not an issues.opened event", "action", event.Action)
+		return nil
+	}
+	if !e.cfg.TriageOnOpen {
+		log.Debug("skipping event: triage is disabled")
+		return nil
+	}
+	if event.Issue == nil {
+		log.Warn("skipping event: missing issue payload")
+		return errors.New("missing issue payload")
+	}
+
+	wfLog := e.logger.StartWorkflow("issue-triage",
+		"issue", event.Issue.Number,
+		"repo", event.Repository.FullName,
+		"sender", event.Sender,
+	)
+	err := e.handleTriage(ctx, event, wfLog)
+	wfLog.EndWorkflow(err)
+	return err
+}
+
+// handleTriage classifies an issue and posts a triage comment. It never
+// commits or pushes; the clone is only read by the LLM.
+func (e *Engine) handleTriage(ctx context.Context, event webhook.Event, log *logging.Logger) (err error) {
+	// Step 1: Parse repository info
+	done := log.Step("parse-repo-info")
+	owner, repo, err := splitFullName(event.Repository.FullName)
+	if err != nil {
+		done(err)
+		return log.WrapError("parse-repo-info", "splitFullName", err)
+	}
+	done(nil)
+
+	var workDir string
+	defer func() {
+		var wfErr *logging.WorkflowError
+		if errors.As(err, &wfErr) {
+			target := failureTarget{number: event.Issue.Number}
+			e.reportFailure(context.WithoutCancel(ctx), owner, repo, target, err, e.jobDetails(event, event.Issue.Number, workDir, log), log)
+		}
+	}()
+
+	// Step 2: Get issue details
+	done = log.Step("get-issue-details", "issue", event.Issue.Number)
+	issue, err := e.gh.GetIssue(ctx, owner, repo, event.Issue.Number)
+	if err != nil {
+		done(err)
+		return log.WrapError("get-issue-details", "GetIssue", err)
+	}
+	done(nil)
+
+	// Step 3: List repository labels
+	done = log.Step("list-repo-labels")
+	repoLabels, err := e.gh.ListRepoLabels(ctx, owner, repo)
+	if err != nil {
+		done(err)
+		return log.WrapError("list-repo-labels", "ListRepoLabels", err)
+	}
+	repoLabels = e.triageLabels(repoLabels)
+	done(nil)
+
+	// Step 4: Prepare workspace
+	done = log.Step("prepare-workspace")
+	workDir, err = e.prepareWorkspace(ctx, event.Repository, fmt.Sprintf("triage-%d", issue.Number), log)
+	if err != nil {
+		done(err)
+		return err // Already wrapped
+	}
+	defer func() { e.writeRunStatus(workDir, err) }()
+	repDir := repoDir(workDir)
+	done(nil)
+
+	// Step 5: Prepare LLM prompt
+	done = log.Step("prepare-llm-prompt")
+	labelsJSON, _ := json.Marshal(repoLabels)
+	contextReq := llm.Request{
+		Mode:         "triage",
+		RepoPath:     repDir,
+		RepoFullName: event.Repository.FullName,
+		IssueNumber:  issue.Number,
+		IssueTitle:   issue.Title,
+		IssueBody:    issue.Body,
+		IssueLabels:  issue.Labels,
+		Metadata:     map[string]string{"repo_labels": string(labelsJSON)},
+		Requirements: "Triage the issue: classify it, suggest labels, and point to relevant files. Do not change any files.",
+	}
+	request, err := e.preparePrompt(workDir, contextReq)
+	if err != nil {
+		done(err)
+		return log.WrapError("prepare-llm-prompt", "preparePrompt", err)
+	}
+	done(nil)
+
+	// Step 6: Run LLM
+	done = log.Step("run-llm")
+	result, err := e.llm.Run(ctx, request, repDir)
+	e.writeArtifacts(workDir, request, result, err)
+	if err != nil {
+		done(err)
+		return log.WrapError("run-llm", "Run", err)
+	}
+	triage := parseTriage(request.OutputPath, result)
+	triage.Labels = knownLabels(triage.Labels, repoLabels)
+	triage.RelevantFiles = existingFiles(repDir, triage.RelevantFiles)
+	if data, err := json.MarshalIndent(triage, "", "  "); err == nil {
+		_ = e.writeFile(filepath.Join(outputsDir(workDir), TriageFile), data)
+	}
+	log.Info("triage completed", "classification", triage.Classification, "labels", triage.Labels)
+	done(nil)
+
+	// Step 7: Apply suggested labels (optional)
+	if e.cfg.TriageApplyLabels && len(triage.Labels) > 0 {
+		done = log.Step("apply-labels", "labels", triage.Labels)
+		labels := append([]string{}, issue.Labels...)
+		for _, label := range triage.Labels {
+			if !contains(label, labels) {
+				labels = append(labels, label)
+			}
+		}
+		if err := e.gh.SetIssueLabels(ctx, owner, repo, issue.Number, labels); err != nil {
+			done(err)
+			return log.WrapError("apply-labels", "SetIssueLabels", err)
+		}
+		done(nil)
+	}
+
+	// Step 8: Post triage comment
+	done = log.Step("post-triage-comment")
+	if err := e.gh.CreateIssueComment(ctx, owner, repo, issue.Number, triageComment(triage, e.cfg.TriageApplyLabels)); err != nil {
+		done(err)
+		return log.WrapError("post-triage-comment", "CreateIssueComment", err)
+	}
+	done(nil)
+	return nil
+}
+
+// parseTriage extracts the triage fields from the raw LLM output. The
+// standard summary is used when no triage fields are found.
+func parseTriage(outputPath string, result llm.RunResult) TriageResult {
+	triage, ok := decodeExtended(outputPath, result, func(t TriageResult) bool { return t.Classification != "" })
+	if !ok {
+		return TriageResult{Summary: result.Response.Summary}
+	}
+	triage.Classification = strings.ToLower(strings.TrimSpace(triage.Classification))
+	triage.Summary = fallback(triage.Summary, result.Response.Summary)
+	return triage
+}
+
+// triageLabels drops the labels triage must never apply: trigger labels would
+// start a run, status labels are managed by the runs themselves, and base
+// labels change the branch the issue is implemented on.
+func (e *Engine) triageLabels(repoLabels []string) []string {
+	reserved := append([]string{e.cfg.InProgressLabel, e.cfg.DoneLabel, e.cfg.NeedsInfoLabel, e.cfg.PlanPendingLabel, e.cfg.FailedLabel}, e.cfg.TriggerLabels...)
+	var out []string
+	for _, label := range repoLabels {
+		if containsFold(label, reserved) {
+			continue
+		}
+		if e.cfg.BaseLabelPrefix != "" && strings.HasPrefix(strings.ToLower(label), strings.ToLower(e.cfg.BaseLabelPrefix)) {
+			continue
+		}
+		out = append(out, label)
+	}
+	return out
+}
+
+// containsFold reports whether label is in labels, ignoring case.
+func containsFold(label string, labels []string) bool {
+	for _, l := range labels {
+		if l != "" && strings.EqualFold(l, label) {
+			return true
+		}
+	}
+	return false
+}
+
+// knownLabels keeps the suggested labels that exist in the repository, using
+// the repository's spelling.
+func knownLabels(suggested, repoLabels []string) []string {
+	var out []string
+	for _, label := range suggested {
+		for _, known := range repoLabels {
+			if strings.EqualFold(strings.TrimSpace(label), known) && !contains(known, out) {
+				out = append(out, known)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// existingFiles keeps the relative paths that exist inside the repository.
+func existingFiles(repDir string, paths []string) []string {
+	var out []string
+	for _, path := range paths {
+		clean := filepath.Clean(strings.TrimPrefix(strings.TrimSpace(path), "/"))
+		if clean == "." || strings.HasPrefix(clean, "..") {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(repDir, clean)); err == nil && !contains(clean, out) {
+			out = append(out, clean)
+		}
+	}
+	return out
+}
+
+func triageComment(triage TriageResult, applied bool) string {
+	var sb strings.Builder
+	sb.WriteString("## Triage\n\n")
+	fmt.Fprintf(&sb, "**Classification:** %s\n", fallback(triage.Classification, "unknown"))
+	if triage.Classification == TriageDuplicate && triage.DuplicateOf > 0 {
+		fmt.Fprintf(&sb, "**Duplicate of:** #%d\n", triage.DuplicateOf)
+	}
+	if triage.Estimate != "" {
+		fmt.Fprintf(&sb, "**Estimate:** %s\n", triage.Estimate)
+	}
+	if len(triage.Labels) > 0 {
+		verb := "Suggested labels"
+		if applied {
+			verb = "Labels applied"
+		}
+		fmt.Fprintf(&sb, "**%s:** %s\n", verb, "`"+strings.Join(triage.Labels, "`, `")+"`")
+	}
+	if len(triage.RelevantFiles) > 0 {
+		sb.WriteString("\n**Likely relevant files:**\n")
+		for _, file := range triage.RelevantFiles {
+			sb.WriteString("- `" + file + "`\n")
+		}
+	}
+	if summary := strings.TrimSpace(triage.Summary); summary != "" {
+		sb.WriteString("\n" + summary + "\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
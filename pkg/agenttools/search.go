@@ -0,0 +1,102 @@
+// Package agenttools provides agent tools not covered by the agent library's
+// builtin set.
+package agenttools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+const (
+	searchTimeout = 60 * time.Second
+	// maxSearchResults caps the matching lines returned so a broad pattern
+	// does not flood the agent's context.
+	maxSearchResults = 200
+)
+
+// SearchTool searches tracked files for a regular expression with git grep.
+// It only reads the repository.
+type SearchTool struct{}
+
+func (t SearchTool) Name() string {
+	return "search_files"
+}
+
+func (t SearchTool) Description() string {
+	return "Search the repository's files for a regular expression. Returns matching lines as path:line:text."
+}
+
+func (t SearchTool) InputSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"pattern": map[string]any{
+				"type":        "string",
+				"description": "Extended regular expression to search for",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Directory or file to search, relative to the working directory. Defaults to the whole repository.",
+			},
+			"ignore_case": map[string]any{
+				"type":        "boolean",
+				"description": "Match case-insensitively",
+			},
+		},
+		"required": []string{"pattern"},
+	}
+}
+
+func (t SearchTool) Execute(ctx context.Context, toolCtx *tools.ToolContext, input map[string]any) (tools.ToolResult, error) {
+	if err := toolCtx.CheckFileRead(); err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+
+	pattern, ok := input["pattern"].(string)
+	if !ok || pattern == "" {
+		return tools.NewErrorResultf("pattern is required"), nil
+	}
+	path, _ := input["path"].(string)
+	if path == "" {
+		path = "."
+	}
+	if _, err := toolCtx.ValidatePath(path); err != nil {
+		return tools.NewErrorResult(err), nil
+	}
+
+	args := []string{"grep", "-n", "-I", "-E", "--no-color"}
+	if ignoreCase, _ := input["ignore_case"].(bool); ignoreCase {
+		args = append(args, "-i")
+	}
+	args = append(args, "-e", pattern, "--", path)
+
+	ctx, cancel := context.WithTimeout(ctx, searchTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = toolCtx.WorkDir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// git grep exits with 1 when nothing matches.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && stderr.Len() == 0 {
+			return tools.NewToolResult("No matches found"), nil
+		}
+		return tools.NewErrorResultf("search failed: %v: %s", err, strings.TrimSpace(stderr.String())), nil
+	}
+
+	lines := strings.Split(strings.TrimRight(stdout.String(), "\n"), "\n")
+	if len(lines) > maxSearchResults {
+		omitted := len(lines) - maxSearchResults
+		lines = append(lines[:maxSearchResults], fmt.Sprintf("... %d more matches omitted; narrow the pattern or path", omitted))
+	}
+	return tools.NewToolResult(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,214 @@
+// Package codeowners parses GitHub CODEOWNERS files and resolves path owners.
+package codeowners
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Locations lists CODEOWNERS paths in the order GitHub searches them.
+// The first file found is used; the others are ignored.
+var Locations = []string{
+	filepath.Join(".github", "CODEOWNERS"),
+	"CODEOWNERS",
+	filepath.Join("docs", "CODEOWNERS"),
+}
+
+// Rule is a single CODEOWNERS entry.
+type Rule struct {
+	Pattern string
+	Owners  []string
+	re      *regexp.Regexp
+}
+
+// Ruleset holds parsed CODEOWNERS rules in file order.
+type Ruleset struct {
+	Path  string
+	Rules []Rule
+}
+
+// Load reads the first CODEOWNERS file found in repoDir.
+// Returns an empty ruleset when the repository has no CODEOWNERS file.
+func Load(repoDir string) (Ruleset, error) {
+	return LoadFrom(func(path string) (string, bool, error) {
+		data, err := os.ReadFile(filepath.Join(repoDir, filepath.FromSlash(path)))
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return string(data), err == nil, err
+	})
+}
+
+// LoadFrom reads the first CODEOWNERS file found by read, which is called
+// with slash-separated repository paths and reports whether the file exists.
+// Returns an empty ruleset when no location exists.
+func LoadFrom(read func(path string) (content string, found bool, err error)) (Ruleset, error) {
+	for _, loc := range Locations {
+		path := filepath.ToSlash(loc)
+		content, found, err := read(path)
+		if err != nil {
+			return Ruleset{}, err
+		}
+		if !found {
+			continue
+		}
+		rules := Parse(content)
+		rules.Path = path
+		return rules, nil
+	}
+	return Ruleset{}, nil
+}
+
+// IsCodeOwnersFile reports whether a repository-relative path is one of the
+// CODEOWNERS locations.
+func IsCodeOwnersFile(path string) bool {
+	path = strings.TrimPrefix(filepath.ToSlash(path), "/")
+	for _, loc := range Locations {
+		if path == filepath.ToSlash(loc) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse parses CODEOWNERS content. Invalid patterns are skipped.
+func Parse(content string) Ruleset {
+	var rules []Rule
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if idx := strings.Index(line, " #"); idx != -1 {
+			line = strings.TrimSpace(line[:idx])
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		re, err := compilePattern(fields[0])
+		if err != nil {
+			continue
+		}
+		rules = append(rules, Rule{Pattern: fields[0], Owners: fields[1:], re: re})
+	}
+	return Ruleset{Rules: rules}
+}
+
+// Empty reports whether the ruleset has no rules.
+func (r Ruleset) Empty() bool {
+	return len(r.Rules) == 0
+}
+
+// Owners returns the owners of a repository-relative path.
+// The last matching rule wins, matching GitHub semantics.
+func (r Ruleset) Owners(path string) []string {
+	path = strings.TrimPrefix(filepath.ToSlash(path), "/")
+	for i := len(r.Rules) - 1; i >= 0; i-- {
+		if r.Rules[i].re.MatchString(path) {
+			return r.Rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// OwnersOf returns the sorted, de-duplicated owners of all paths.
+func (r Ruleset) OwnersOf(paths []string) []string {
+	seen := map[string]struct{}{}
+	for _, path := range paths {
+		for _, owner := range r.Owners(path) {
+			seen[owner] = struct{}{}
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for owner := range seen {
+		out = append(out, owner)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// PathsOwnedBy returns the paths owned by any of the given owners.
+// Owner comparison is case-insensitive and ignores a leading "@".
+func (r Ruleset) PathsOwnedBy(paths []string, owners []string) []string {
+	wanted := map[string]struct{}{}
+	for _, owner := range owners {
+		wanted[normalizeOwner(owner)] = struct{}{}
+	}
+	var out []string
+	for _, path := range paths {
+		for _, owner := range r.Owners(path) {
+			if _, ok := wanted[normalizeOwner(owner)]; ok {
+				out = append(out, path)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// SplitReviewers splits owners into user logins and team slugs suitable for
+// the GitHub review request API. Email owners are dropped.
+func SplitReviewers(owners []string) (users, teams []string) {
+	for _, owner := range owners {
+		if !strings.HasPrefix(owner, "@") {
+			continue
+		}
+		name := strings.TrimPrefix(owner, "@")
+		if idx := strings.Index(name, "/"); idx != -1 {
+			teams = append(teams, name[idx+1:])
+			continue
+		}
+		users = append(users, name)
+	}
+	return users, teams
+}
+
+func normalizeOwner(owner string) string {
+	return strings.ToLower(strings.TrimPrefix(owner, "@"))
+}
+
+// compilePattern converts a gitignore-style CODEOWNERS pattern into a regexp.
+// A pattern whose last segment is a plain name also matches everything below
+// that directory; one ending in a glob matches single path segments only, so
+// "docs/*" owns "docs/a.md" but not "docs/a/b.md".
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	trimmed := strings.Trim(pattern, "/")
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(trimmed, "/")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(trimmed); i++ {
+		ch := trimmed[i]
+		switch {
+		case strings.HasPrefix(trimmed[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(trimmed[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case ch == '*':
+			sb.WriteString("[^/]*")
+		case ch == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	last := trimmed[strings.LastIndex(trimmed, "/")+1:]
+	switch {
+	case dirOnly:
+		sb.WriteString("/.*$")
+	case strings.ContainsAny(last, "*?"):
+		sb.WriteString("$")
+	default:
+		sb.WriteString("(?:/.*)?$")
+	}
+	return regexp.Compile(sb.String())
+}
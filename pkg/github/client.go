@@ -9,12 +9,20 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 )
 
 const defaultBaseURL = "https://api.github.com"
 
+// List endpoints are read perPage items at a time. maxPages bounds how many
+// pages are read so a runaway listing cannot stall a workflow.
+const (
+	perPage  = 100
+	maxPages = 50
+)
+
 // Client talks to the GitHub API.
 type Client struct {
 	baseURL    string
@@ -30,14 +38,31 @@ type Issue struct {
 	Body   string
 	Labels []string
 	Author string
+	// AuthorID is the numeric user ID of the author, used for noreply emails.
+	AuthorID int64
+	// UpdatedAt is set by SearchIssues.
+	UpdatedAt time.Time
 }
 
 // Comment holds a GitHub comment.
 type Comment struct {
+	ID   int64
 	User string
 	Body string
 }
 
+// RepoComment is an issue, PR, or review comment from a repository-wide listing.
+type RepoComment struct {
+	ID int64
+	// Number is the issue or PR the comment belongs to.
+	Number        int
+	IsPullRequest bool
+	User          string
+	Body          string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
 // Repo holds repository data.
 type Repo struct {
 	DefaultBranch string
@@ -53,6 +78,22 @@ type PR struct {
 	HeadRef string
 	BaseRef string
 	URL     string
+	HeadSHA string
+	Author  string
+	Draft   bool
+	// HeadRepoFullName and HeadCloneURL describe the repository holding the
+	// head branch; they differ from the base repository for fork PRs.
+	HeadRepoFullName    string
+	HeadCloneURL        string
+	MaintainerCanModify bool
+	// Merged and MergeCommitSHA are set for merged PRs.
+	Merged         bool
+	MergeCommitSHA string
+}
+
+// IsFork reports whether the PR head lives in a different repository than baseFullName.
+func (p PR) IsFork(baseFullName string) bool {
+	return p.HeadRepoFullName != "" && !strings.EqualFold(p.HeadRepoFullName, baseFullName)
 }
 
 // PRRequest is used to create a PR.
@@ -63,6 +104,36 @@ type PRRequest struct {
 	Base  string `json:"base"`
 }
 
+// ReviewRequest submits a pull request review.
+type ReviewRequest struct {
+	CommitID string          `json:"commit_id,omitempty"`
+	Body     string          `json:"body,omitempty"`
+	Event    string          `json:"event"`
+	Comments []ReviewComment `json:"comments,omitempty"`
+}
+
+// ReviewComment is an inline review comment on a line of the PR diff.
+type ReviewComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Side string `json:"side,omitempty"`
+	Body string `json:"body"`
+}
+
+// Review events.
+const (
+	ReviewEventComment        = "COMMENT"
+	ReviewEventApprove        = "APPROVE"
+	ReviewEventRequestChanges = "REQUEST_CHANGES"
+)
+
+// Reaction contents.
+const (
+	ReactionEyes     = "eyes"
+	ReactionRocket   = "rocket"
+	ReactionConfused = "confused"
+)
+
 // NewClient creates a GitHub API client.
 func NewClient(baseURL, token string) *Client {
 	if baseURL == "" {
@@ -77,8 +148,42 @@ func NewClient(baseURL, token string) *Client {
 
 // CreateIssueComment posts a comment to an issue.
 func (c *Client) CreateIssueComment(ctx context.Context, owner, repo string, number int, body string) error {
+	_, err := c.CreateIssueCommentID(ctx, owner, repo, number, body)
+	return err
+}
+
+// CreateIssueCommentID posts a comment like CreateIssueComment and returns
+// the ID of the created comment, for comments that are edited later.
+func (c *Client) CreateIssueCommentID(ctx context.Context, owner, repo string, number int, body string) (int64, error) {
 	payload := map[string]string{"body": body}
 	path := fmt.Sprintf("/repos/%s/%s/issues/%d/comments", owner, repo, number)
+	var resp struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.doRequest(ctx, http.MethodPost, path, payload, &resp); err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+// UpdateIssueComment replaces the body of an issue or PR comment.
+func (c *Client) UpdateIssueComment(ctx context.Context, owner, repo string, commentID int64, body string) error {
+	payload := map[string]string{"body": body}
+	path := fmt.Sprintf("/repos/%s/%s/issues/comments/%d", owner, repo, commentID)
+	return c.doRequest(ctx, http.MethodPatch, path, payload, nil)
+}
+
+// CreateIssueCommentReaction adds a reaction to an issue or PR conversation comment.
+func (c *Client) CreateIssueCommentReaction(ctx context.Context, owner, repo string, commentID int64, content string) error {
+	payload := map[string]string{"content": content}
+	path := fmt.Sprintf("/repos/%s/%s/issues/comments/%d/reactions", owner, repo, commentID)
+	return c.doRequest(ctx, http.MethodPost, path, payload, nil)
+}
+
+// CreateReviewCommentReaction adds a reaction to a PR review (diff) comment.
+func (c *Client) CreateReviewCommentReaction(ctx context.Context, owner, repo string, commentID int64, content string) error {
+	payload := map[string]string{"content": content}
+	path := fmt.Sprintf("/repos/%s/%s/pulls/comments/%d/reactions", owner, repo, commentID)
 	return c.doRequest(ctx, http.MethodPost, path, payload, nil)
 }
 
@@ -92,6 +197,7 @@ func (c *Client) GetIssue(ctx context.Context, owner, repo string, number int) (
 		Body   string `json:"body"`
 		User   struct {
 			Login string `json:"login"`
+			ID    int64  `json:"id"`
 		} `json:"user"`
 		Labels []struct {
 			Name string `json:"name"`
@@ -105,30 +211,153 @@ func (c *Client) GetIssue(ctx context.Context, owner, repo string, number int) (
 		labels = append(labels, label.Name)
 	}
 	return Issue{
-		Number: resp.Number,
-		State:  resp.State,
-		Title:  resp.Title,
-		Body:   resp.Body,
-		Labels: labels,
-		Author: resp.User.Login,
+		Number:   resp.Number,
+		State:    resp.State,
+		Title:    resp.Title,
+		Body:     resp.Body,
+		Labels:   labels,
+		Author:   resp.User.Login,
+		AuthorID: resp.User.ID,
 	}, nil
 }
 
-// ListIssueComments lists issue comments.
+// ListIssueComments lists all comments on an issue or PR, oldest first.
 func (c *Client) ListIssueComments(ctx context.Context, owner, repo string, number int) ([]Comment, error) {
 	path := fmt.Sprintf("/repos/%s/%s/issues/%d/comments", owner, repo, number)
-	var resp []struct {
+	resp, err := getPages[struct {
+		ID   int64  `json:"id"`
 		Body string `json:"body"`
 		User struct {
 			Login string `json:"login"`
 		} `json:"user"`
+	}](ctx, c, path)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Comment, 0, len(resp))
+	for _, item := range resp {
+		out = append(out, Comment{ID: item.ID, User: item.User.Login, Body: item.Body})
+	}
+	return out, nil
+}
+
+// SearchIssues returns the first 100 issues matching a search query, least
+// recently updated first.
+func (c *Client) SearchIssues(ctx context.Context, query string) ([]Issue, error) {
+	path := "/search/issues?" + url.Values{
+		"q":        {query},
+		"sort":     {"updated"},
+		"order":    {"asc"},
+		"per_page": {"100"},
+	}.Encode()
+	var resp struct {
+		Items []struct {
+			Number    int       `json:"number"`
+			State     string    `json:"state"`
+			Title     string    `json:"title"`
+			Body      string    `json:"body"`
+			UpdatedAt time.Time `json:"updated_at"`
+			User      struct {
+				Login string `json:"login"`
+				ID    int64  `json:"id"`
+			} `json:"user"`
+			Labels []struct {
+				Name string `json:"name"`
+			} `json:"labels"`
+		} `json:"items"`
+	}
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	out := make([]Issue, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		labels := make([]string, 0, len(item.Labels))
+		for _, label := range item.Labels {
+			labels = append(labels, label.Name)
+		}
+		out = append(out, Issue{
+			Number:    item.Number,
+			State:     item.State,
+			Title:     item.Title,
+			Body:      item.Body,
+			Labels:    labels,
+			Author:    item.User.Login,
+			AuthorID:  item.User.ID,
+			UpdatedAt: item.UpdatedAt,
+		})
+	}
+	return out, nil
+}
+
+// ListRepoIssueComments lists the first 100 issue and PR conversation
+// comments updated at or after since, oldest first.
+func (c *Client) ListRepoIssueComments(ctx context.Context, owner, repo string, since time.Time) ([]RepoComment, error) {
+	path := fmt.Sprintf("/repos/%s/%s/issues/comments?sort=updated&direction=asc&per_page=100&since=%s",
+		owner, repo, url.QueryEscape(since.UTC().Format(time.RFC3339)))
+	return c.listRepoComments(ctx, path)
+}
+
+// ListPRReviewComments lists the first 100 PR review (diff) comments updated
+// at or after since, oldest first.
+func (c *Client) ListPRReviewComments(ctx context.Context, owner, repo string, since time.Time) ([]RepoComment, error) {
+	path := fmt.Sprintf("/repos/%s/%s/pulls/comments?sort=updated&direction=asc&per_page=100&since=%s",
+		owner, repo, url.QueryEscape(since.UTC().Format(time.RFC3339)))
+	return c.listRepoComments(ctx, path)
+}
+
+// listRepoComments decodes an issue or review comment listing. The issue or
+// PR number is the last path segment of the comment's parent URL.
+func (c *Client) listRepoComments(ctx context.Context, requestPath string) ([]RepoComment, error) {
+	var resp []struct {
+		ID             int64     `json:"id"`
+		Body           string    `json:"body"`
+		IssueURL       string    `json:"issue_url"`
+		PullRequestURL string    `json:"pull_request_url"`
+		HTMLURL        string    `json:"html_url"`
+		CreatedAt      time.Time `json:"created_at"`
+		UpdatedAt      time.Time `json:"updated_at"`
+		User           struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	}
+	if err := c.doRequest(ctx, http.MethodGet, requestPath, nil, &resp); err != nil {
+		return nil, err
+	}
+	out := make([]RepoComment, 0, len(resp))
+	for _, item := range resp {
+		parentURL := item.IssueURL
+		if parentURL == "" {
+			parentURL = item.PullRequestURL
+		}
+		number, err := strconv.Atoi(path.Base(parentURL))
+		if err != nil {
+			return nil, fmt.Errorf("comment %d has no issue or pull request URL", item.ID)
+		}
+		out = append(out, RepoComment{
+			ID:            item.ID,
+			Number:        number,
+			IsPullRequest: item.PullRequestURL != "" || strings.Contains(item.HTMLURL, "/pull/"),
+			User:          item.User.Login,
+			Body:          item.Body,
+			CreatedAt:     item.CreatedAt,
+			UpdatedAt:     item.UpdatedAt,
+		})
+	}
+	return out, nil
+}
+
+// ListRepoLabels returns the names of a repository's labels (first 100).
+func (c *Client) ListRepoLabels(ctx context.Context, owner, repo string) ([]string, error) {
+	path := fmt.Sprintf("/repos/%s/%s/labels?per_page=100", owner, repo)
+	var resp []struct {
+		Name string `json:"name"`
 	}
 	if err := c.doRequest(ctx, http.MethodGet, path, nil, &resp); err != nil {
 		return nil, err
 	}
-	out := make([]Comment, 0, len(resp))
+	out := make([]string, 0, len(resp))
 	for _, item := range resp {
-		out = append(out, Comment{User: item.User.Login, Body: item.Body})
+		out = append(out, item.Name)
 	}
 	return out, nil
 }
@@ -184,6 +413,77 @@ func (c *Client) AddAssignees(ctx context.Context, owner, repo string, number in
 	return c.doRequest(ctx, http.MethodPost, path, payload, nil)
 }
 
+// GetCollaboratorPermission returns a user's permission on a repository:
+// "admin", "write", "read" or "none".
+func (c *Client) GetCollaboratorPermission(ctx context.Context, owner, repo, user string) (string, error) {
+	path := fmt.Sprintf("/repos/%s/%s/collaborators/%s/permission", owner, repo, url.PathEscape(user))
+	var resp struct {
+		Permission string `json:"permission"`
+	}
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Permission, nil
+}
+
+// RequestReviewers requests user and team reviews on a pull request.
+func (c *Client) RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers, teamReviewers []string) error {
+	path := fmt.Sprintf("/repos/%s/%s/pulls/%d/requested_reviewers", owner, repo, number)
+	payload := map[string][]string{}
+	if len(reviewers) > 0 {
+		payload["reviewers"] = reviewers
+	}
+	if len(teamReviewers) > 0 {
+		payload["team_reviewers"] = teamReviewers
+	}
+	return c.doRequest(ctx, http.MethodPost, path, payload, nil)
+}
+
+// GetPRDiff returns the unified diff of a pull request.
+func (c *Client) GetPRDiff(ctx context.Context, owner, repo string, number int) (string, error) {
+	path := fmt.Sprintf("/repos/%s/%s/pulls/%d", owner, repo, number)
+	resp, err := c.send(ctx, http.MethodGet, path, "application/vnd.github.diff", nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// CreatePRReview submits a review with optional inline comments.
+func (c *Client) CreatePRReview(ctx context.Context, owner, repo string, number int, review ReviewRequest) error {
+	path := fmt.Sprintf("/repos/%s/%s/pulls/%d/reviews", owner, repo, number)
+	return c.doRequest(ctx, http.MethodPost, path, review, nil)
+}
+
+// GetUserID returns the numeric ID of a user or bot account, e.g. "app[bot]".
+func (c *Client) GetUserID(ctx context.Context, login string) (int64, error) {
+	path := "/users/" + login
+	var resp struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.doRequest(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+// GetAuthenticatedLogin returns the login of the user the token belongs to.
+// GitHub App installation tokens cannot call this endpoint.
+func (c *Client) GetAuthenticatedLogin(ctx context.Context) (string, error) {
+	var resp struct {
+		Login string `json:"login"`
+	}
+	if err := c.doRequest(ctx, http.MethodGet, "/user", nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Login, nil
+}
+
 // GetRepo retrieves repository info.
 func (c *Client) GetRepo(ctx context.Context, owner, repo string) (Repo, error) {
 	path := fmt.Sprintf("/repos/%s/%s", owner, repo)
@@ -197,6 +497,38 @@ func (c *Client) GetRepo(ctx context.Context, owner, repo string) (Repo, error)
 	return Repo{DefaultBranch: resp.DefaultBranch, CloneURL: resp.CloneURL}, nil
 }
 
+// ListOpenPRs lists all open PRs, newest first.
+func (c *Client) ListOpenPRs(ctx context.Context, owner, repo string) ([]PR, error) {
+	path := fmt.Sprintf("/repos/%s/%s/pulls?state=open", owner, repo)
+	resp, err := getPages[struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		State   string `json:"state"`
+		HTMLURL string `json:"html_url"`
+		Head    struct {
+			Ref string `json:"ref"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	}](ctx, c, path)
+	if err != nil {
+		return nil, err
+	}
+	prs := make([]PR, 0, len(resp))
+	for _, item := range resp {
+		prs = append(prs, PR{
+			Number:  item.Number,
+			Title:   item.Title,
+			State:   item.State,
+			URL:     item.HTMLURL,
+			HeadRef: item.Head.Ref,
+			BaseRef: item.Base.Ref,
+		})
+	}
+	return prs, nil
+}
+
 // GetPR retrieves PR details.
 func (c *Client) GetPR(ctx context.Context, owner, repo string, number int) (PR, error) {
 	path := fmt.Sprintf("/repos/%s/%s/pulls/%d", owner, repo, number)
@@ -206,36 +538,101 @@ func (c *Client) GetPR(ctx context.Context, owner, repo string, number int) (PR,
 		Body    string `json:"body"`
 		State   string `json:"state"`
 		HTMLURL string `json:"html_url"`
-		Head    struct {
-			Ref string `json:"ref"`
+		Draft   bool   `json:"draft"`
+		User    struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Head struct {
+			Ref  string `json:"ref"`
+			SHA  string `json:"sha"`
+			Repo struct {
+				FullName string `json:"full_name"`
+				CloneURL string `json:"clone_url"`
+			} `json:"repo"`
 		} `json:"head"`
 		Base struct {
 			Ref string `json:"ref"`
 		} `json:"base"`
+		MaintainerCanModify bool   `json:"maintainer_can_modify"`
+		Merged              bool   `json:"merged"`
+		MergeCommitSHA      string `json:"merge_commit_sha"`
 	}
 	if err := c.doRequest(ctx, http.MethodGet, path, nil, &resp); err != nil {
 		return PR{}, err
 	}
-	return PR{Number: resp.Number, Title: resp.Title, Body: resp.Body, State: resp.State, URL: resp.HTMLURL, HeadRef: resp.Head.Ref, BaseRef: resp.Base.Ref}, nil
+	return PR{
+		Number:              resp.Number,
+		Title:               resp.Title,
+		Body:                resp.Body,
+		State:               resp.State,
+		URL:                 resp.HTMLURL,
+		HeadRef:             resp.Head.Ref,
+		BaseRef:             resp.Base.Ref,
+		HeadSHA:             resp.Head.SHA,
+		Author:              resp.User.Login,
+		Draft:               resp.Draft,
+		HeadRepoFullName:    resp.Head.Repo.FullName,
+		HeadCloneURL:        resp.Head.Repo.CloneURL,
+		MaintainerCanModify: resp.MaintainerCanModify,
+		Merged:              resp.Merged,
+		MergeCommitSHA:      resp.MergeCommitSHA,
+	}, nil
+}
+
+// getPages fetches every page of a list endpoint, up to maxPages pages of
+// perPage items.
+func getPages[T any](ctx context.Context, c *Client, requestPath string) ([]T, error) {
+	sep := "?"
+	if strings.Contains(requestPath, "?") {
+		sep = "&"
+	}
+	var out []T
+	for page := 1; page <= maxPages; page++ {
+		var items []T
+		if err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("%s%sper_page=%d&page=%d", requestPath, sep, perPage, page), nil, &items); err != nil {
+			return nil, err
+		}
+		out = append(out, items...)
+		if len(items) < perPage {
+			break
+		}
+	}
+	return out, nil
 }
 
 func (c *Client) doRequest(ctx context.Context, method, requestPath string, payload any, out any) error {
-	base, err := url.Parse(c.baseURL)
+	resp, err := c.send(ctx, method, requestPath, "application/vnd.github+json", payload)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send performs an API request with the given Accept header. Responses with
+// an error status are returned as errors; callers must close the body otherwise.
+func (c *Client) send(ctx context.Context, method, requestPath, accept string, payload any) (*http.Response, error) {
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, err
+	}
+	requestPath, query, _ := strings.Cut(requestPath, "?")
 	base.Path = path.Join(base.Path, requestPath)
+	base.RawQuery = query
 	var body io.Reader
 	if payload != nil {
 		encoded, err := json.Marshal(payload)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		body = bytes.NewReader(encoded)
 	}
 	req, err := http.NewRequestWithContext(ctx, method, base.String(), body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if c.token != "" {
 		req.Header.Set("Authorization", "Bearer "+c.token)
@@ -243,19 +640,16 @@ func (c *Client) doRequest(ctx context.Context, method, requestPath string, payl
 	if payload != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
-	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Accept", accept)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
 		data, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("github api error: %s", string(data))
+		return nil, fmt.Errorf("github api error: %s", string(data))
 	}
-	if out == nil {
-		return nil
-	}
-	return json.NewDecoder(resp.Body).Decode(out)
+	return resp, nil
 }
@@ -0,0 +1,116 @@
+package gitutil
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxStderrBytes bounds the stderr kept in a GitError.
+const maxStderrBytes = 4096
+
+// urlPattern matches URLs embedded in git output so credentials can be redacted.
+var urlPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s'"]+`)
+
+// GitError describes a failed git command.
+type GitError struct {
+	// Subcommand is the git subcommand that failed, e.g. "push".
+	Subcommand string
+	// ExitCode is the process exit code, or -1 if git did not exit normally.
+	ExitCode int
+	// Stderr is the trimmed, token-redacted stderr output.
+	Stderr string
+	Err    error
+}
+
+func (e *GitError) Error() string {
+	msg := fmt.Sprintf("git %s failed (exit %d)", e.Subcommand, e.ExitCode)
+	if e.Stderr != "" {
+		return msg + ": " + e.Stderr
+	}
+	if e.Err != nil {
+		return msg + ": " + RedactText(e.Err.Error())
+	}
+	return msg
+}
+
+func (e *GitError) Unwrap() error {
+	return e.Err
+}
+
+func newGitError(args []string, stderr string, err error) *GitError {
+	exitCode := -1
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	}
+	stderr = strings.TrimSpace(RedactText(stderr))
+	if len(stderr) > maxStderrBytes {
+		// Keep the tail, starting on a rune boundary.
+		start := len(stderr) - maxStderrBytes
+		for start < len(stderr) && !utf8.RuneStart(stderr[start]) {
+			start++
+		}
+		stderr = "..." + stderr[start:]
+	}
+	return &GitError{
+		Subcommand: subcommand(args),
+		ExitCode:   exitCode,
+		Stderr:     stderr,
+		Err:        err,
+	}
+}
+
+// subcommand returns the git subcommand from args, skipping global options.
+func subcommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-c" || arg == "-C" {
+			i++
+			continue
+		}
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		return arg
+	}
+	return ""
+}
+
+// RedactText redacts credentials from every URL found in text.
+func RedactText(text string) string {
+	return urlPattern.ReplaceAllStringFunc(text, RedactToken)
+}
+
+// ConflictError reports that a rebase or cherry-pick stopped on conflicts.
+type ConflictError struct {
+	Op    string
+	Files []string
+	Err   error
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s stopped with conflicts in %s", e.Op, strings.Join(e.Files, ", "))
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// IsPushRejected reports whether a push failed because the remote branch has
+// commits that are not present locally.
+func IsPushRejected(err error) bool {
+	var gitErr *GitError
+	if !errors.As(err, &gitErr) || gitErr.Subcommand != "push" {
+		return false
+	}
+	for _, marker := range []string{"non-fast-forward", "fetch first", "[rejected]"} {
+		if strings.Contains(gitErr.Stderr, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,10 +2,17 @@ package gitutil
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"encoding/base64"
+	"errors"
+	"io"
 	"net/url"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -25,6 +32,56 @@ var ExcludedFiles = map[string]bool{
 // Client runs git commands.
 type Client struct {
 	GitBinary string
+	// Commit configures the identity and signing used by CommitAll.
+	Commit CommitConfig
+}
+
+// CommitConfig configures commit identity, sign-off and signing.
+// Empty fields fall back to the git configuration of the environment.
+type CommitConfig struct {
+	AuthorName  string
+	AuthorEmail string
+	// SignOff adds a Signed-off-by trailer for DCO-enforcing repositories.
+	SignOff bool
+	// SigningFormat is "gpg" or "ssh"; empty disables commit signing.
+	SigningFormat string
+	// SigningKey is a GPG key ID, or the path to an SSH private/public key.
+	SigningKey string
+	// GPGHome is the GnuPG home directory holding the signing key, passed to
+	// git as GNUPGHOME; empty uses the environment's.
+	GPGHome string
+}
+
+// identityArgs returns git global options setting the commit identity.
+func (cc CommitConfig) identityArgs() []string {
+	var global []string
+	if cc.AuthorName != "" {
+		global = append(global, "-c", "user.name="+cc.AuthorName)
+	}
+	if cc.AuthorEmail != "" {
+		global = append(global, "-c", "user.email="+cc.AuthorEmail)
+	}
+	return global
+}
+
+// args returns git global options and commit flags for the configuration.
+func (cc CommitConfig) args() (global []string, flags []string) {
+	global = cc.identityArgs()
+	if cc.SignOff {
+		flags = append(flags, "--signoff")
+	}
+	if cc.SigningFormat != "" {
+		format := cc.SigningFormat
+		if format == "gpg" {
+			format = "openpgp"
+		}
+		global = append(global, "-c", "gpg.format="+format)
+		if cc.SigningKey != "" {
+			global = append(global, "-c", "user.signingkey="+cc.SigningKey)
+		}
+		flags = append(flags, "--gpg-sign")
+	}
+	return global, flags
 }
 
 func (c Client) gitBinary() string {
@@ -39,6 +96,56 @@ func (c Client) Clone(ctx context.Context, repoURL, dir string) error {
 	return c.run(ctx, "clone", repoURL, dir)
 }
 
+// CloneOptions controls how a workspace clone is created.
+type CloneOptions struct {
+	// Depth creates a shallow clone with the given history depth (0 = full history).
+	Depth int
+	// Filter is a partial clone filter spec, e.g. "blob:none".
+	Filter string
+	// Reference is a local repository (typically a mirror) to borrow objects
+	// from. The clone keeps borrowing them through its alternates, so the
+	// reference must outlive the clone and must not prune objects.
+	Reference string
+}
+
+// CloneWithOptions clones a repository into dir using the given options.
+// Shallow clones still fetch all branches so that any branch can be checked out.
+func (c Client) CloneWithOptions(ctx context.Context, repoURL, dir string, opts CloneOptions) error {
+	args := []string{"clone"}
+	if opts.Reference != "" {
+		args = append(args, "--reference-if-able", opts.Reference)
+	}
+	if opts.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(opts.Depth), "--no-single-branch")
+	}
+	if opts.Filter != "" {
+		args = append(args, "--filter="+opts.Filter)
+	}
+	args = append(args, repoURL, dir)
+	return c.run(ctx, args...)
+}
+
+// SyncMirror creates a bare mirror of repoURL in mirrorDir, or incrementally
+// fetches into it when it already exists. Credentials in repoURL are sent as
+// an HTTP header for the command only and are never written to the mirror's
+// config. Automatic gc is disabled in the mirror, since workspace clones
+// borrow its objects. Callers must serialize access per mirror.
+func (c Client) SyncMirror(ctx context.Context, repoURL, mirrorDir string) error {
+	remoteURL, auth := splitCredentials(repoURL)
+	if _, err := os.Stat(filepath.Join(mirrorDir, "HEAD")); err != nil {
+		if err := os.MkdirAll(filepath.Dir(mirrorDir), 0o755); err != nil {
+			return err
+		}
+		if err := c.run(ctx, append(auth, "clone", "--mirror", remoteURL, mirrorDir)...); err != nil {
+			_ = os.RemoveAll(mirrorDir)
+			return err
+		}
+	} else if err := c.runDir(ctx, mirrorDir, append(auth, "fetch", "--prune", remoteURL, "+refs/*:refs/*")...); err != nil {
+		return err
+	}
+	return c.runDir(ctx, mirrorDir, "config", "gc.auto", "0")
+}
+
 // CheckoutBranch checks out a branch from base.
 func (c Client) CheckoutBranch(ctx context.Context, dir, branch, base string) error {
 	if base == "" {
@@ -47,16 +154,39 @@ func (c Client) CheckoutBranch(ctx context.Context, dir, branch, base string) er
 	return c.runDir(ctx, dir, "checkout", "-B", branch, base)
 }
 
+// RemoteBranchExists reports whether the clone has a remote-tracking ref for
+// branch on remote, i.e. whether the branch existed when it was last fetched.
+func (c Client) RemoteBranchExists(ctx context.Context, dir, remote, branch string) (bool, error) {
+	_, err := c.runDirOutput(ctx, dir, "rev-parse", "--verify", "--quiet", "refs/remotes/"+remote+"/"+branch)
+	if err == nil {
+		return true, nil
+	}
+	var gitErr *GitError
+	if errors.As(err, &gitErr) && gitErr.ExitCode == 1 {
+		return false, nil
+	}
+	return false, err
+}
+
 // HasChanges returns true if there are uncommitted changes in the working directory.
 // Excludes automation artifacts defined in ExcludedFiles.
 func (c Client) HasChanges(ctx context.Context, dir string) (bool, error) {
-	// Get list of changed files (unstaged)
-	output, err := c.runDirOutput(ctx, dir, "status", "--porcelain")
+	files, err := c.ChangedFiles(ctx, dir)
 	if err != nil {
 		return false, err
 	}
+	return len(files) > 0, nil
+}
 
-	// Check if any non-excluded files have changes
+// ChangedFiles returns the paths of uncommitted changes in the working directory,
+// including untracked files. Excludes automation artifacts defined in ExcludedFiles.
+func (c Client) ChangedFiles(ctx context.Context, dir string) ([]string, error) {
+	output, err := c.runDirOutput(ctx, dir, "status", "--porcelain", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
 	scanner := bufio.NewScanner(strings.NewReader(output))
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -69,46 +199,46 @@ func (c Client) HasChanges(ctx context.Context, dir string) (bool, error) {
 		if idx := strings.Index(filename, " -> "); idx != -1 {
 			filename = filename[idx+4:]
 		}
-		baseName := filepath.Base(filename)
-		if !ExcludedFiles[baseName] {
-			return true, nil
+		filename = strings.Trim(filename, "\"")
+		if !ExcludedFiles[filepath.Base(filename)] {
+			files = append(files, filename)
 		}
 	}
-	return false, scanner.Err()
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// Diff returns the patch from rev to the working tree. Untracked files are
+// marked intent-to-add so that they are included; ExcludedFiles are omitted.
+func (c Client) Diff(ctx context.Context, dir, rev string) (string, error) {
+	files, err := c.ChangedFiles(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	if len(files) > 0 {
+		if err := c.runDir(ctx, dir, append([]string{"add", "--intent-to-add", "--"}, files...)...); err != nil {
+			return "", err
+		}
+	}
+	excluded := make([]string, 0, len(ExcludedFiles))
+	for name := range ExcludedFiles {
+		excluded = append(excluded, ":(exclude,glob)**/"+name)
+	}
+	sort.Strings(excluded)
+	args := append([]string{"diff", "--binary", rev, "--", "."}, excluded...)
+	return c.runDirOutput(ctx, dir, args...)
 }
 
 // CommitAll stages non-excluded changes and commits with message.
 // Returns nil without error if there are no changes to commit.
 // Excludes automation artifacts defined in ExcludedFiles.
 func (c Client) CommitAll(ctx context.Context, dir, message string) error {
-	// Get list of all changed files
-	output, err := c.runDirOutput(ctx, dir, "status", "--porcelain")
+	filesToStage, err := c.ChangedFiles(ctx, dir)
 	if err != nil {
 		return err
 	}
-
-	// Collect files to stage (excluding automation artifacts)
-	var filesToStage []string
-	scanner := bufio.NewScanner(strings.NewReader(output))
-	for scanner.Scan() {
-		line := scanner.Text()
-		if len(line) < 3 {
-			continue
-		}
-		filename := strings.TrimSpace(line[3:])
-		// Handle renamed files (old -> new)
-		if idx := strings.Index(filename, " -> "); idx != -1 {
-			filename = filename[idx+4:]
-		}
-		baseName := filepath.Base(filename)
-		if !ExcludedFiles[baseName] {
-			filesToStage = append(filesToStage, filename)
-		}
-	}
-	if err := scanner.Err(); err != nil {
-		return err
-	}
-
 	if len(filesToStage) == 0 {
 		// No non-excluded changes to commit
 		return nil
@@ -120,12 +250,182 @@ func (c Client) CommitAll(ctx context.Context, dir, message string) error {
 		return err
 	}
 
-	return c.runDir(ctx, dir, "commit", "-m", message)
+	global, flags := c.Commit.args()
+	args = append(global, "commit")
+	args = append(args, flags...)
+	return c.runDir(ctx, dir, append(args, "-m", message)...)
+}
+
+// Fetch fetches a ref from a remote, updating its remote-tracking branch.
+func (c Client) Fetch(ctx context.Context, dir, remote, ref string) error {
+	return c.runDir(ctx, dir, "fetch", remote, ref)
+}
+
+// Rebase rebases the current branch onto upstream. When the rebase stops on
+// conflicts it is left in progress and a *ConflictError listing the
+// conflicted files is returned; callers must ContinueRebase or AbortRebase.
+func (c Client) Rebase(ctx context.Context, dir, upstream string) error {
+	global, flags := c.Commit.args()
+	args := append(global, "rebase")
+	args = append(args, signingFlags(flags)...)
+	return c.conflictAware(ctx, dir, "rebase", c.runDir(ctx, dir, append(args, upstream)...))
+}
+
+// ContinueRebase continues an in-progress rebase after conflicts were
+// resolved and staged. Returns *ConflictError if the next commit conflicts.
+func (c Client) ContinueRebase(ctx context.Context, dir string) error {
+	args := append(c.Commit.identityArgs(), "-c", "core.editor=true", "rebase", "--continue")
+	return c.conflictAware(ctx, dir, "rebase", c.runDir(ctx, dir, args...))
+}
+
+// AbortRebase aborts an in-progress rebase.
+func (c Client) AbortRebase(ctx context.Context, dir string) error {
+	return c.runDir(ctx, dir, "rebase", "--abort")
+}
+
+// CherryPick applies commit onto the current branch, recording its origin with
+// -x. Merge commits are picked relative to their first parent. When the pick
+// stops on conflicts it is left in progress and a *ConflictError is returned;
+// callers must ContinueCherryPick or AbortCherryPick.
+func (c Client) CherryPick(ctx context.Context, dir, commit string) error {
+	output, err := c.runDirOutput(ctx, dir, "rev-list", "--parents", "-n", "1", commit)
+	if err != nil {
+		return err
+	}
+	global, flags := c.Commit.args()
+	args := append(global, "cherry-pick", "-x")
+	args = append(args, flags...)
+	if len(strings.Fields(output)) > 2 {
+		args = append(args, "-m", "1")
+	}
+	return c.conflictAware(ctx, dir, "cherry-pick", c.runDir(ctx, dir, append(args, commit)...))
+}
+
+// CommitParents returns the parent commits of commit.
+func (c Client) CommitParents(ctx context.Context, dir, commit string) ([]string, error) {
+	output, err := c.runDirOutput(ctx, dir, "rev-list", "--parents", "-n", "1", commit)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields[1:], nil
+}
+
+// MergeBase returns the best common ancestor of commits a and b.
+func (c Client) MergeBase(ctx context.Context, dir, a, b string) (string, error) {
+	output, err := c.runDirOutput(ctx, dir, "merge-base", a, b)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// ShowFile returns the content of path at rev. found is false when rev
+// exists but has no such file.
+func (c Client) ShowFile(ctx context.Context, dir, rev, path string) (content string, found bool, err error) {
+	output, err := c.runDirOutput(ctx, dir, "ls-tree", "--name-only", rev, "--", path)
+	if err != nil {
+		return "", false, err
+	}
+	if strings.TrimSpace(output) == "" {
+		return "", false, nil
+	}
+	content, err = c.runDirOutput(ctx, dir, "show", rev+":"+path)
+	if err != nil {
+		return "", false, err
+	}
+	return content, true, nil
+}
+
+// CommitsBetween returns the non-merge commits reachable from head but not
+// from base, oldest first.
+func (c Client) CommitsBetween(ctx context.Context, dir, base, head string) ([]string, error) {
+	output, err := c.runDirOutput(ctx, dir, "rev-list", "--reverse", "--no-merges", base+".."+head)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(output), nil
+}
+
+// ContinueCherryPick continues an in-progress cherry-pick after conflicts
+// were resolved and staged.
+func (c Client) ContinueCherryPick(ctx context.Context, dir string) error {
+	args := append(c.Commit.identityArgs(), "-c", "core.editor=true", "cherry-pick", "--continue")
+	return c.conflictAware(ctx, dir, "cherry-pick", c.runDir(ctx, dir, args...))
+}
+
+// AbortCherryPick aborts an in-progress cherry-pick.
+func (c Client) AbortCherryPick(ctx context.Context, dir string) error {
+	return c.runDir(ctx, dir, "cherry-pick", "--abort")
+}
+
+// ConflictedFiles lists files with unresolved merge conflicts.
+func (c Client) ConflictedFiles(ctx context.Context, dir string) ([]string, error) {
+	output, err := c.runDirOutput(ctx, dir, "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// MarkResolved stages files whose conflicts have been resolved.
+func (c Client) MarkResolved(ctx context.Context, dir string, files []string) error {
+	if len(files) == 0 {
+		return nil
+	}
+	return c.runDir(ctx, dir, append([]string{"add", "--"}, files...)...)
+}
+
+// conflictAware converts a failed merge-like operation into a *ConflictError
+// when the working tree has conflicted files.
+func (c Client) conflictAware(ctx context.Context, dir, op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	files, listErr := c.ConflictedFiles(ctx, dir)
+	if listErr != nil || len(files) == 0 {
+		return err
+	}
+	return &ConflictError{Op: op, Files: files, Err: err}
+}
+
+// signingFlags keeps only the signing flag from commit flags; sign-off
+// trailers are already present on commits being replayed.
+func signingFlags(flags []string) []string {
+	var out []string
+	for _, flag := range flags {
+		if flag == "--gpg-sign" {
+			out = append(out, flag)
+		}
+	}
+	return out
 }
 
 // Push pushes a branch to origin.
 func (c Client) Push(ctx context.Context, dir, branch string) error {
-	return c.runDir(ctx, dir, "push", "origin", branch)
+	return c.PushTo(ctx, dir, "origin", branch)
+}
+
+// PushTo pushes a branch to the named remote. Pushes are never forced.
+func (c Client) PushTo(ctx context.Context, dir, remote, branch string) error {
+	return c.runDir(ctx, dir, "push", remote, branch)
+}
+
+// AddRemote adds a remote, or updates its URL when it already exists.
+func (c Client) AddRemote(ctx context.Context, dir, name, remoteURL string) error {
+	if _, err := c.runDirOutput(ctx, dir, "remote", "get-url", name); err == nil {
+		return c.runDir(ctx, dir, "remote", "set-url", name, remoteURL)
+	}
+	return c.runDir(ctx, dir, "remote", "add", name, remoteURL)
 }
 
 // SetRemoteAuth updates origin URL with a token.
@@ -145,36 +445,54 @@ func (c Client) SetRemoteAuth(ctx context.Context, dir, token string) error {
 	return c.runDir(ctx, dir, "remote", "set-url", "origin", updated)
 }
 
+// ClearRemoteAuth removes credentials from the URL of remote.
+func (c Client) ClearRemoteAuth(ctx context.Context, dir, remote string) error {
+	output, err := c.runDirOutput(ctx, dir, "remote", "get-url", remote)
+	if err != nil {
+		return err
+	}
+	return c.runDir(ctx, dir, "remote", "set-url", remote, stripCredentials(strings.TrimSpace(output)))
+}
+
 // ApplyPatch applies a unified diff patch to the repository.
 func (c Client) ApplyPatch(ctx context.Context, dir, patch string) error {
 	if strings.TrimSpace(patch) == "" {
 		return nil
 	}
-	cmd := exec.CommandContext(ctx, c.gitBinary(), "apply", "--whitespace=nowarn")
-	cmd.Dir = dir
-	cmd.Stdin = strings.NewReader(patch)
-	return cmd.Run()
+	_, err := c.exec(ctx, dir, strings.NewReader(patch), "apply", "--whitespace=nowarn")
+	return err
 }
 
 func (c Client) run(ctx context.Context, args ...string) error {
-	cmd := exec.CommandContext(ctx, c.gitBinary(), args...)
-	return cmd.Run()
+	_, err := c.exec(ctx, "", nil, args...)
+	return err
 }
 
 func (c Client) runDir(ctx context.Context, dir string, args ...string) error {
-	cmd := exec.CommandContext(ctx, c.gitBinary(), args...)
-	cmd.Dir = dir
-	return cmd.Run()
+	_, err := c.exec(ctx, dir, nil, args...)
+	return err
 }
 
 func (c Client) runDirOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	return c.exec(ctx, dir, nil, args...)
+}
+
+// exec runs git and returns its stdout. Failures are reported as *GitError
+// carrying the subcommand, exit code and redacted stderr.
+func (c Client) exec(ctx context.Context, dir string, stdin io.Reader, args ...string) (string, error) {
 	cmd := exec.CommandContext(ctx, c.gitBinary(), args...)
 	cmd.Dir = dir
-	out, err := cmd.Output()
-	if err != nil {
-		return "", err
+	cmd.Stdin = stdin
+	if c.Commit.GPGHome != "" {
+		cmd.Env = append(os.Environ(), "GNUPGHOME="+c.Commit.GPGHome)
 	}
-	return string(out), nil
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", newGitError(args, stderr.String(), err)
+	}
+	return stdout.String(), nil
 }
 
 func injectToken(rawURL, token string) (string, error) {
@@ -191,6 +509,29 @@ func InjectToken(rawURL, token string) (string, error) {
 	return injectToken(rawURL, token)
 }
 
+// splitCredentials removes the user information from a URL and returns git
+// global options sending it as a basic auth header instead.
+func splitCredentials(rawURL string) (string, []string) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.User == nil {
+		return rawURL, nil
+	}
+	password, _ := parsed.User.Password()
+	token := base64.StdEncoding.EncodeToString([]byte(parsed.User.Username() + ":" + password))
+	return stripCredentials(rawURL), []string{"-c", "http.extraHeader=Authorization: Basic " + token}
+}
+
+// stripCredentials removes the user information from a URL. Values that do not
+// parse as URLs (e.g. local paths) are returned unchanged.
+func stripCredentials(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.User == nil {
+		return rawURL
+	}
+	parsed.User = nil
+	return parsed.String()
+}
+
 // RedactToken removes token information from URLs for logs.
 func RedactToken(rawURL string) string {
 	parsed, err := url.Parse(rawURL)
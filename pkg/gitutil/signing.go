@@ -0,0 +1,41 @@
+package gitutil
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ImportGPGKey imports the secret key in keyFile into the GnuPG home
+// directory home and returns the fingerprint of the first secret key there.
+// Pass home as CommitConfig.GPGHome so that commits are signed with it
+// instead of a keyring of the environment.
+func ImportGPGKey(ctx context.Context, keyFile, home string) (string, error) {
+	if _, err := runGPG(ctx, home, "--import", keyFile); err != nil {
+		return "", err
+	}
+	output, err := runGPG(ctx, home, "--with-colons", "--list-secret-keys")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, ":")
+		if fields[0] == "fpr" && len(fields) > 9 && fields[9] != "" {
+			return fields[9], nil
+		}
+	}
+	return "", fmt.Errorf("no secret key found in %s", keyFile)
+}
+
+func runGPG(ctx context.Context, home string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "gpg", append([]string{"--batch", "--homedir", home}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("gpg %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -4,6 +4,7 @@ package logging
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"runtime"
@@ -57,6 +58,14 @@ func (e *WorkflowError) Format(f fmt.State, verb rune) {
 	}
 }
 
+// output is where loggers created by New write.
+var output io.Writer = os.Stdout
+
+// SetOutput sets the destination of loggers created afterwards.
+func SetOutput(w io.Writer) {
+	output = w
+}
+
 // New creates a new Logger with the specified output format.
 func New(jsonFormat bool) *Logger {
 	var handler slog.Handler
@@ -70,9 +79,9 @@ func New(jsonFormat bool) *Logger {
 		},
 	}
 	if jsonFormat {
-		handler = slog.NewJSONHandler(os.Stdout, opts)
+		handler = slog.NewJSONHandler(output, opts)
 	} else {
-		handler = slog.NewTextHandler(os.Stdout, opts)
+		handler = slog.NewTextHandler(output, opts)
 	}
 	return &Logger{
 		Logger: slog.New(handler),
@@ -0,0 +1,243 @@
+// Package policy checks proposed repository changes against guardrail rules
+// before they are committed.
+package policy
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+
+	"git_sonic/pkg/redact"
+)
+
+// Rule names.
+const (
+	// RuleBinary blocks adding or modifying binary files.
+	RuleBinary = "binary"
+	// RuleFileSize blocks files larger than Rules.MaxFileBytes.
+	RuleFileSize = "file_size"
+	// RuleLockfileOnly blocks changes that touch nothing but lockfiles.
+	RuleLockfileOnly = "lockfile_only"
+	// RuleTestDeletion blocks deleting test files.
+	RuleTestDeletion = "test_deletion"
+	// RuleNewDependency blocks new requirements in go.mod and new
+	// dependencies in package.json.
+	RuleNewDependency = "new_dependency"
+	// RuleSecrets blocks added lines that look like credentials (see
+	// redact.Detect) or high-entropy tokens.
+	RuleSecrets = "secrets"
+)
+
+// All lists every rule in evaluation order.
+var All = []string{RuleBinary, RuleFileSize, RuleLockfileOnly, RuleTestDeletion, RuleNewDependency, RuleSecrets}
+
+// Known reports whether name is a rule name.
+func Known(name string) bool {
+	for _, rule := range All {
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Rules selects the enabled rules and their parameters.
+type Rules struct {
+	Enabled []string
+	// MaxFileBytes is the size limit for RuleFileSize (0 = no limit).
+	MaxFileBytes int64
+}
+
+func (r Rules) enabled(name string) bool {
+	for _, rule := range r.Enabled {
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Change describes one changed file.
+type Change struct {
+	Path    string
+	Deleted bool
+	Binary  bool
+	// Size is the size of the file after the change.
+	Size         int64
+	AddedLines   []string
+	RemovedLines []string
+}
+
+// Violation is a rule that a change breaks.
+type Violation struct {
+	Rule    string
+	Path    string
+	Message string
+}
+
+func (v Violation) String() string {
+	if v.Path == "" {
+		return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+	}
+	return fmt.Sprintf("%s: `%s` %s", v.Rule, v.Path, v.Message)
+}
+
+// Evaluate returns the violations of the enabled rules, in rule order.
+func Evaluate(rules Rules, changes []Change) []Violation {
+	var violations []Violation
+	if rules.enabled(RuleBinary) {
+		for _, c := range changes {
+			if c.Binary && !c.Deleted {
+				violations = append(violations, Violation{RuleBinary, c.Path, "is a binary file"})
+			}
+		}
+	}
+	if rules.enabled(RuleFileSize) && rules.MaxFileBytes > 0 {
+		for _, c := range changes {
+			if !c.Deleted && c.Size > rules.MaxFileBytes {
+				violations = append(violations, Violation{RuleFileSize, c.Path,
+					fmt.Sprintf("is %d bytes, more than the limit of %d", c.Size, rules.MaxFileBytes)})
+			}
+		}
+	}
+	if rules.enabled(RuleLockfileOnly) && len(changes) > 0 && allLockfiles(changes) {
+		violations = append(violations, Violation{RuleLockfileOnly, "", "the change only touches lockfiles"})
+	}
+	if rules.enabled(RuleTestDeletion) {
+		for _, c := range changes {
+			if c.Deleted && IsTestFile(c.Path) {
+				violations = append(violations, Violation{RuleTestDeletion, c.Path, "is a test file and would be deleted"})
+			}
+		}
+	}
+	if rules.enabled(RuleNewDependency) {
+		for _, c := range changes {
+			for _, dep := range newDependencies(c) {
+				violations = append(violations, Violation{RuleNewDependency, c.Path, fmt.Sprintf("adds dependency %s", dep)})
+			}
+		}
+	}
+	if rules.enabled(RuleSecrets) {
+		for _, c := range changes {
+			if IsLockfile(c.Path) {
+				continue
+			}
+			for _, line := range c.AddedLines {
+				if kind := secretKind(line); kind != "" {
+					violations = append(violations, Violation{RuleSecrets, c.Path, "adds what looks like " + kind})
+					break
+				}
+			}
+		}
+	}
+	return violations
+}
+
+var lockfiles = map[string]bool{
+	"go.sum": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"Cargo.lock": true, "poetry.lock": true, "Gemfile.lock": true, "composer.lock": true,
+	"Pipfile.lock": true, "uv.lock": true,
+}
+
+// IsLockfile reports whether p is a dependency lockfile.
+func IsLockfile(p string) bool {
+	return lockfiles[path.Base(p)]
+}
+
+func allLockfiles(changes []Change) bool {
+	for _, c := range changes {
+		if !IsLockfile(c.Path) {
+			return false
+		}
+	}
+	return true
+}
+
+var testFilePattern = regexp.MustCompile(`(^|/)(tests?|__tests__|spec)/|_test\.go$|_test\.py$|(^|/)test_[^/]*\.py$|\.(test|spec)\.[jt]sx?$|Test\.java$|_spec\.rb$`)
+
+// IsTestFile reports whether p looks like a test file.
+func IsTestFile(p string) bool {
+	return testFilePattern.MatchString(p)
+}
+
+var (
+	goRequirePattern  = regexp.MustCompile(`^\s*(?:require\s+)?([A-Za-z0-9.\-_~/]+\.[A-Za-z0-9.\-_~/]+)\s+v\d\S*`)
+	npmDepPattern     = regexp.MustCompile(`^\s*"(@?[^"\s]+)"\s*:\s*"((?:[\^~<>=]*\s*\d|npm:|workspace:|github:|git\+|file:|latest)[^"]*)"`)
+	npmSectionPattern = regexp.MustCompile(`"(?:dev|peer|optional)?[dD]ependencies"\s*:`)
+)
+
+// newDependencies returns the modules or packages a change adds. Version
+// changes of existing dependencies are not reported.
+func newDependencies(c Change) []string {
+	var pattern *regexp.Regexp
+	switch path.Base(c.Path) {
+	case "go.mod":
+		pattern = goRequirePattern
+	case "package.json":
+		pattern = npmDepPattern
+	default:
+		return nil
+	}
+	removed := map[string]bool{}
+	for _, line := range c.RemovedLines {
+		if m := pattern.FindStringSubmatch(line); m != nil {
+			removed[m[1]] = true
+		}
+	}
+	var added []string
+	for _, line := range c.AddedLines {
+		if npmSectionPattern.MatchString(line) {
+			continue
+		}
+		if m := pattern.FindStringSubmatch(line); m != nil && !removed[m[1]] {
+			added = append(added, m[1])
+		}
+	}
+	sort.Strings(added)
+	return added
+}
+
+var (
+	tokenCandidate = regexp.MustCompile(`[A-Za-z0-9+=_\-]{32,}`)
+	hasDigit       = regexp.MustCompile(`[0-9]`)
+	hasUpper       = regexp.MustCompile(`[A-Z]`)
+	hasLower       = regexp.MustCompile(`[a-z]`)
+)
+
+// minSecretEntropy is the Shannon entropy in bits per character above which a
+// long token is treated as a credential. Hex digests stay below 4.
+const minSecretEntropy = 4.5
+
+// secretKind names the kind of secret line appears to contain, or "".
+func secretKind(line string) string {
+	if kind := redact.Detect(line); kind != "" {
+		return kind
+	}
+	for _, token := range tokenCandidate.FindAllString(line, -1) {
+		// Credentials mix digits and both cases; identifiers rarely do.
+		if !hasDigit.MatchString(token) || !hasUpper.MatchString(token) || !hasLower.MatchString(token) {
+			continue
+		}
+		if entropy(token) >= minSecretEntropy {
+			return "a high-entropy credential"
+		}
+	}
+	return ""
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	var h float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
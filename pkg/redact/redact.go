@@ -0,0 +1,141 @@
+// Package redact scrubs credentials from text before it is logged, written
+// to disk or posted to GitHub.
+package redact
+
+import (
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Marker replaces redacted text.
+const Marker = "[REDACTED]"
+
+// minSecretLength is the shortest configured value treated as a secret;
+// shorter values (flags, small numbers) would redact ordinary text.
+const minSecretLength = 8
+
+// pattern is a generic secret format. When group is set only that submatch
+// is replaced, keeping surrounding context such as the key name.
+type pattern struct {
+	kind  string
+	re    *regexp.Regexp
+	group int
+}
+
+var patterns = []pattern{
+	{kind: "a private key", re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----(?:[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----)?`)},
+	{kind: "a GitHub token", re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{40,})\b`)},
+	{kind: "an AWS access key", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{kind: "a Slack token", re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{kind: "an API key", re: regexp.MustCompile(`\bsk-(?:ant-)?[A-Za-z0-9_-]{32,}\b`)},
+	{kind: "URL credentials", re: regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@'"]+:([^/\s@'"]+)@`), group: 1},
+	{kind: "an authorization header", re: regexp.MustCompile(`(?i)\bauthorization:\s*(?:bearer|token|basic)\s+([^\s"'\\]+)`), group: 1},
+	{kind: "a credential assignment", re: regexp.MustCompile(`(?i)\b(?:api[_-]?key|access[_-]?key|secret|password|passwd|private[_-]?token)["']?\s*[:=]\s*["']?([A-Za-z0-9_\-+/=!@#$%^&*~]{8,})`), group: 1},
+}
+
+// Detect names the kind of secret text appears to contain, or returns "".
+func Detect(text string) string {
+	for _, p := range patterns {
+		if p.re.MatchString(text) {
+			return p.kind
+		}
+	}
+	return ""
+}
+
+// Text redacts generic secret formats from text.
+func Text(text string) string {
+	for _, p := range patterns {
+		if p.group == 0 {
+			text = p.re.ReplaceAllString(text, Marker)
+			continue
+		}
+		text = replaceGroup(p.re, p.group, text)
+	}
+	return text
+}
+
+func replaceGroup(re *regexp.Regexp, group int, text string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[2*group], m[2*group+1]
+		if start < 0 {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(Marker)
+		last = end
+	}
+	if last == 0 {
+		return text
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Redactor redacts configured secret values as well as generic secret formats.
+type Redactor struct {
+	secrets []string
+}
+
+// New creates a Redactor for the given secret values. Empty and very short
+// values are ignored.
+func New(secrets ...string) *Redactor {
+	seen := map[string]bool{}
+	var kept []string
+	for _, s := range secrets {
+		s = strings.TrimSpace(s)
+		if len(s) < minSecretLength || seen[s] {
+			continue
+		}
+		seen[s] = true
+		kept = append(kept, s)
+	}
+	// Longest first, so a secret containing another is replaced whole.
+	sort.Slice(kept, func(i, j int) bool { return len(kept[i]) > len(kept[j]) })
+	return &Redactor{secrets: kept}
+}
+
+// String redacts s. A nil Redactor only applies the generic formats.
+func (r *Redactor) String(s string) string {
+	if r != nil {
+		for _, secret := range r.secrets {
+			s = strings.ReplaceAll(s, secret, Marker)
+		}
+	}
+	return Text(s)
+}
+
+// Bytes redacts b.
+func (r *Redactor) Bytes(b []byte) []byte {
+	return []byte(r.String(string(b)))
+}
+
+// Strings redacts every element of values in place and returns it.
+func (r *Redactor) Strings(values []string) []string {
+	for i, v := range values {
+		values[i] = r.String(v)
+	}
+	return values
+}
+
+// Writer returns a writer that redacts each write before passing it to w.
+// Writes are expected to hold whole lines, as log handlers produce.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	return writer{w: w, r: r}
+}
+
+type writer struct {
+	w io.Writer
+	r *Redactor
+}
+
+func (w writer) Write(p []byte) (int, error) {
+	if _, err := w.w.Write(w.r.Bytes(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -51,3 +51,32 @@ func TestParseEventIssuesLabeled(t *testing.T) {
 		t.Fatalf("unexpected repo name")
 	}
 }
+
+func TestParseEventPRReviewComment(t *testing.T) {
+	payload := `{
+  "action": "created",
+  "comment": {"id": 991, "body": "/ai-optimize tighten error handling"},
+  "pull_request": {
+    "number": 45,
+    "state": "open",
+    "head": {"ref": "feature"},
+    "base": {"ref": "main"}
+  },
+  "repository": {"full_name": "org/repo"},
+  "sender": {"login": "reviewer"}
+}`
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(payload))
+	req.Header.Set("X-GitHub-Event", "pull_request_review_comment")
+
+	event, err := webhook.ParseEvent(req)
+	if err != nil {
+		t.Fatalf("parse event: %v", err)
+	}
+	if event.CommentID != 991 || event.CommentBody != "/ai-optimize tighten error handling" {
+		t.Fatalf("unexpected comment: id=%d body=%q", event.CommentID, event.CommentBody)
+	}
+	if event.PullRequest == nil || event.PullRequest.Number != 45 {
+		t.Fatalf("unexpected pull request data")
+	}
+}
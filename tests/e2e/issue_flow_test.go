@@ -2,13 +2,20 @@ package e2e_test
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"git_sonic/internal/config"
 	"git_sonic/internal/controller/webhook"
+	"git_sonic/internal/service/scheduler"
 	"git_sonic/internal/service/workflow"
 	"git_sonic/pkg/github"
+	"git_sonic/pkg/gitutil"
 	"github.com/MimeLyc/agent-core-go/pkg/llm"
 )
 
@@ -17,6 +24,12 @@ type fakeGitHub struct {
 	assignedTo  string
 	commented   bool
 	labelUpdate bool
+	reviewed    bool
+	openPRs     []github.PR
+	comments    []github.Comment
+	edits       int
+	reactions   []string
+	labels      []string
 }
 
 type fakeGit struct{}
@@ -24,20 +37,52 @@ type fakeGit struct{}
 type fakeLLM struct{}
 
 func (f *fakeGitHub) GetIssue(ctx context.Context, owner, repo string, number int) (github.Issue, error) {
-	return github.Issue{Number: number, State: "open", Title: "t", Body: "b", Labels: []string{"ai-ready"}, Author: "author"}, nil
+	labels := []string{"ai-ready"}
+	if f.labelUpdate {
+		labels = f.labels
+	}
+	return github.Issue{Number: number, State: "open", Title: "t", Body: "b", Labels: labels, Author: "author"}, nil
 }
 
 func (f *fakeGitHub) ListIssueComments(ctx context.Context, owner, repo string, number int) ([]github.Comment, error) {
-	return []github.Comment{{User: "commenter", Body: "details"}}, nil
+	return append([]github.Comment{{ID: 1, User: "commenter", Body: "details"}}, f.comments...), nil
 }
 
 func (f *fakeGitHub) CreateIssueComment(ctx context.Context, owner, repo string, number int, body string) error {
+	_, err := f.CreateIssueCommentID(ctx, owner, repo, number, body)
+	return err
+}
+
+func (f *fakeGitHub) CreateIssueCommentID(ctx context.Context, owner, repo string, number int, body string) (int64, error) {
 	f.commented = true
+	id := int64(len(f.comments) + 2)
+	f.comments = append(f.comments, github.Comment{ID: id, User: "git-sonic[bot]", Body: body})
+	return id, nil
+}
+
+func (f *fakeGitHub) UpdateIssueComment(ctx context.Context, owner, repo string, commentID int64, body string) error {
+	for i := range f.comments {
+		if f.comments[i].ID == commentID {
+			f.comments[i].Body = body
+			f.edits++
+		}
+	}
+	return nil
+}
+
+func (f *fakeGitHub) CreateIssueCommentReaction(ctx context.Context, owner, repo string, commentID int64, content string) error {
+	f.reactions = append(f.reactions, fmt.Sprintf("issue-comment/%d:%s", commentID, content))
+	return nil
+}
+
+func (f *fakeGitHub) CreateReviewCommentReaction(ctx context.Context, owner, repo string, commentID int64, content string) error {
+	f.reactions = append(f.reactions, fmt.Sprintf("review-comment/%d:%s", commentID, content))
 	return nil
 }
 
 func (f *fakeGitHub) SetIssueLabels(ctx context.Context, owner, repo string, number int, labels []string) error {
 	f.labelUpdate = true
+	f.labels = labels
 	return nil
 }
 
@@ -62,16 +107,83 @@ func (f *fakeGitHub) GetRepo(ctx context.Context, owner, repo string) (github.Re
 }
 
 func (f *fakeGitHub) GetPR(ctx context.Context, owner, repo string, number int) (github.PR, error) {
-	return github.PR{}, nil
+	return github.PR{Number: number, State: "open", HeadRef: "feature", BaseRef: "main", HeadSHA: "abc123", Author: "human"}, nil
+}
+
+func (f *fakeGitHub) ListOpenPRs(ctx context.Context, owner, repo string) ([]github.PR, error) {
+	return f.openPRs, nil
+}
+
+func (f *fakeGitHub) RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers, teamReviewers []string) error {
+	return nil
 }
 
-func (f *fakeGit) Clone(ctx context.Context, repoURL, dir string) error               { return nil }
+func (f *fakeGitHub) GetPRDiff(ctx context.Context, owner, repo string, number int) (string, error) {
+	return "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1,1 +1,2 @@\n package main\n+func f() {}\n", nil
+}
+
+func (f *fakeGitHub) CreatePRReview(ctx context.Context, owner, repo string, number int, review github.ReviewRequest) error {
+	f.reviewed = true
+	return nil
+}
+
+func (f *fakeGitHub) ListRepoLabels(ctx context.Context, owner, repo string) ([]string, error) {
+	return []string{"bug", "enhancement", "question"}, nil
+}
+
+func (f *fakeGitHub) GetCollaboratorPermission(ctx context.Context, owner, repo, user string) (string, error) {
+	return "write", nil
+}
+
+func (f *fakeGit) CloneWithOptions(ctx context.Context, repoURL, dir string, opts gitutil.CloneOptions) error {
+	return nil
+}
+func (f *fakeGit) SyncMirror(ctx context.Context, repoURL, mirrorDir string) error    { return nil }
 func (f *fakeGit) CheckoutBranch(ctx context.Context, dir, branch, base string) error { return nil }
 func (f *fakeGit) CommitAll(ctx context.Context, dir, message string) error           { return nil }
 func (f *fakeGit) Push(ctx context.Context, dir, branch string) error                 { return nil }
-func (f *fakeGit) SetRemoteAuth(ctx context.Context, dir, token string) error         { return nil }
-func (f *fakeGit) ApplyPatch(ctx context.Context, dir, patch string) error            { return nil }
-func (f *fakeGit) HasChanges(ctx context.Context, dir string) (bool, error)           { return true, nil }
+func (f *fakeGit) PushTo(ctx context.Context, dir, remote, branch string) error       { return nil }
+func (f *fakeGit) AddRemote(ctx context.Context, dir, name, remoteURL string) error   { return nil }
+func (f *fakeGit) Fetch(ctx context.Context, dir, remote, ref string) error           { return nil }
+func (f *fakeGit) Rebase(ctx context.Context, dir, upstream string) error             { return nil }
+func (f *fakeGit) ContinueRebase(ctx context.Context, dir string) error               { return nil }
+func (f *fakeGit) AbortRebase(ctx context.Context, dir string) error                  { return nil }
+func (f *fakeGit) CherryPick(ctx context.Context, dir, commit string) error           { return nil }
+func (f *fakeGit) ContinueCherryPick(ctx context.Context, dir string) error           { return nil }
+func (f *fakeGit) AbortCherryPick(ctx context.Context, dir string) error              { return nil }
+func (f *fakeGit) MarkResolved(ctx context.Context, dir string, files []string) error { return nil }
+func (f *fakeGit) ShowFile(ctx context.Context, dir, rev, path string) (string, bool, error) {
+	return "", false, nil
+}
+func (f *fakeGit) SetRemoteAuth(ctx context.Context, dir, token string) error { return nil }
+func (f *fakeGit) ApplyPatch(ctx context.Context, dir, patch string) error    { return nil }
+func (f *fakeGit) ChangedFiles(ctx context.Context, dir string) ([]string, error) {
+	return []string{"main.go"}, nil
+}
+
+func (f *fakeGit) Diff(ctx context.Context, dir, rev string) (string, error) {
+	return "diff --git a/main.go b/main.go\n", nil
+}
+
+func (f *fakeGit) ClearRemoteAuth(ctx context.Context, dir, remote string) error {
+	return nil
+}
+
+func (f *fakeGit) RemoteBranchExists(ctx context.Context, dir, remote, branch string) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeGit) CommitParents(ctx context.Context, dir, commit string) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeGit) MergeBase(ctx context.Context, dir, a, b string) (string, error) {
+	return "", nil
+}
+
+func (f *fakeGit) CommitsBetween(ctx context.Context, dir, base, head string) ([]string, error) {
+	return nil, nil
+}
 
 func (f *fakeLLM) Run(ctx context.Context, req llm.Request, workDir string) (llm.RunResult, error) {
 	return llm.RunResult{Response: llm.Response{Decision: llm.DecisionProceed, CommitMessage: "msg", PRTitle: "title", PRBody: "body"}}, nil
@@ -119,7 +231,510 @@ func TestIssueLabelFlowCreatesPR(t *testing.T) {
 	if gh.assignedTo != "labeler" {
 		t.Fatalf("expected assignee labeler, got %s", gh.assignedTo)
 	}
+	if len(gh.comments) != 1 || gh.edits == 0 {
+		t.Fatalf("expected one status comment edited in place, got %d comments and %d edits", len(gh.comments), gh.edits)
+	}
+	if status := gh.comments[0].Body; !strings.HasPrefix(status, "<!-- git-sonic:status") || !strings.Contains(status, "https://example.com/pr/10") {
+		t.Fatalf("expected status comment linking the PR, got %q", status)
+	}
+}
+
+func TestIssueLabelFlowPlanModePostsPlan(t *testing.T) {
+	cfg := config.Config{
+		TriggerLabels:    []string{"ai-ready"},
+		InProgressLabel:  "ai-in-progress",
+		DoneLabel:        "ai-done",
+		NeedsInfoLabel:   "ai-needs-info",
+		PlanMode:         true,
+		PlanPendingLabel: "ai-plan-pending",
+		ApproveCommand:   "/ai-approve",
+		RepoCloneBase:    t.TempDir(),
+	}
+
+	gh := &fakeGitHub{}
+	engine := workflow.NewEngine(cfg, gh, &fakeGit{}, &fakeLLM{})
+	event := webhook.Event{
+		Type:       webhook.EventIssues,
+		Action:     "labeled",
+		Label:      "ai-ready",
+		Sender:     "labeler",
+		Repository: webhook.Repository{FullName: "org/repo", CloneURL: "https://github.com/org/repo.git", DefaultBranch: "main"},
+		Issue:      &webhook.Issue{Number: 12, State: "open", Title: "t", Body: "b", Labels: []string{"ai-ready"}},
+	}
+
+	if err := engine.HandleIssueLabel(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gh.createdPR {
+		t.Fatalf("expected no PR before the plan is approved")
+	}
+	if !gh.commented || !gh.labelUpdate {
+		t.Fatalf("expected plan comment and plan-pending label")
+	}
+}
+
+func TestIssueLabelFlowDryRunRecordsActions(t *testing.T) {
+	base := t.TempDir()
+	cfg := config.Config{
+		TriggerLabels:   []string{"ai-ready"},
+		InProgressLabel: "ai-in-progress",
+		DoneLabel:       "ai-done",
+		NeedsInfoLabel:  "ai-needs-info",
+		RepoCloneBase:   base,
+		DryRun:          true,
+	}
+
+	gh := &fakeGitHub{}
+	engine := workflow.NewEngine(cfg, gh, &fakeGit{}, &fakeLLM{})
+	event := webhook.Event{
+		Type:       webhook.EventIssues,
+		Action:     "labeled",
+		Label:      "ai-ready",
+		Sender:     "labeler",
+		Repository: webhook.Repository{FullName: "org/repo", CloneURL: "https://github.com/org/repo.git", DefaultBranch: "main"},
+		Issue:      &webhook.Issue{Number: 12, State: "open", Title: "t", Body: "b", Labels: []string{"ai-ready"}},
+	}
+
+	if err := engine.HandleIssueLabel(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gh.createdPR || gh.commented || gh.labelUpdate || gh.assignedTo != "" {
+		t.Fatalf("expected no GitHub writes in dry run, got %+v", gh)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(base, "*", workflow.OutputsSubdir, workflow.StatusFile))
+	if len(matches) != 1 {
+		t.Fatalf("expected one status file, got %v", matches)
+	}
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatalf("read status: %v", err)
+	}
+	var status workflow.RunStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		t.Fatalf("parse status: %v", err)
+	}
+	kinds := map[string]bool{}
+	for _, action := range status.Actions {
+		kinds[action.Kind] = true
+	}
+	if !status.DryRun || !kinds[workflow.ActionPush] || !kinds[workflow.ActionCreatePR] || !kinds[workflow.ActionLabels] {
+		t.Fatalf("expected push, PR and label actions in the job record, got %+v", status)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(matches[0]), workflow.DryRunDiffFile)); err != nil {
+		t.Fatalf("expected dry-run diff artifact: %v", err)
+	}
+}
+
+func TestIssueOpenedTriagePostsCommentWithoutPR(t *testing.T) {
+	cfg := config.Config{TriageOnOpen: true, RepoCloneBase: t.TempDir()}
+
+	gh := &fakeGitHub{}
+	engine := workflow.NewEngine(cfg, gh, &fakeGit{}, &fakeLLM{})
+	event := webhook.Event{
+		Type:       webhook.EventIssues,
+		Action:     "opened",
+		Sender:     "author",
+		Repository: webhook.Repository{FullName: "org/repo", CloneURL: "https://github.com/org/repo.git", DefaultBranch: "main"},
+		Issue:      &webhook.Issue{Number: 13, State: "open", Title: "t", Body: "b"},
+	}
+
+	if err := engine.HandleIssueOpened(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gh.createdPR || gh.labelUpdate {
+		t.Fatalf("triage must not create PRs or change labels by default")
+	}
 	if !gh.commented {
-		t.Fatalf("expected issue comment to be posted")
+		t.Fatalf("expected triage comment to be posted")
+	}
+}
+
+func TestPullRequestOpenedAutoReview(t *testing.T) {
+	cfg := config.Config{
+		RepoCloneBase: t.TempDir(),
+		RepoSettings:  map[string]config.RepoSettings{"org/repo": {AutoReview: true}},
+	}
+
+	gh := &fakeGitHub{}
+	engine := workflow.NewEngine(cfg, gh, &fakeGit{}, &fakeLLM{}).WithReadOnlyRunner(&readOnlyLLM{})
+	event := webhook.Event{
+		Type:        webhook.EventPullRequest,
+		Action:      "opened",
+		Sender:      "human",
+		Repository:  webhook.Repository{FullName: "org/repo", CloneURL: "https://github.com/org/repo.git", DefaultBranch: "main"},
+		PullRequest: &webhook.PullRequest{Number: 5, State: "open", Author: "human", HeadRef: "feature"},
+	}
+
+	if err := engine.HandlePullRequest(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !gh.reviewed {
+		t.Fatalf("expected a review to be submitted")
+	}
+	if gh.createdPR {
+		t.Fatalf("review must not create PRs")
+	}
+}
+
+func TestAutoReviewSkippedWithoutReadOnlyRunner(t *testing.T) {
+	cfg := config.Config{
+		RepoCloneBase: t.TempDir(),
+		RepoSettings:  map[string]config.RepoSettings{"org/repo": {AutoReview: true}},
+	}
+
+	gh := &fakeGitHub{}
+	engine := workflow.NewEngine(cfg, gh, &fakeGit{}, &fakeLLM{})
+	event := webhook.Event{
+		Type:        webhook.EventPullRequest,
+		Action:      "opened",
+		Sender:      "human",
+		Repository:  webhook.Repository{FullName: "org/repo", CloneURL: "https://github.com/org/repo.git", DefaultBranch: "main"},
+		PullRequest: &webhook.PullRequest{Number: 5, State: "open", Author: "human", HeadRef: "feature"},
+	}
+
+	if err := engine.HandlePullRequest(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gh.reviewed || len(gh.comments) != 0 {
+		t.Fatalf("expected no review and no comment, got reviewed=%v comments=%d", gh.reviewed, len(gh.comments))
+	}
+}
+
+// readOnlyGitHub reports read permission for every user.
+type readOnlyGitHub struct {
+	*fakeGitHub
+}
+
+func (f readOnlyGitHub) GetCollaboratorPermission(ctx context.Context, owner, repo, user string) (string, error) {
+	return "read", nil
+}
+
+func TestReviewCommandRequiresWriteAccess(t *testing.T) {
+	cfg := config.Config{ReviewCommand: "/ai-review", RepoCloneBase: t.TempDir()}
+
+	gh := &fakeGitHub{}
+	askLLM := &readOnlyLLM{}
+	engine := workflow.NewEngine(cfg, readOnlyGitHub{gh}, &fakeGit{}, &fakeLLM{}).WithReadOnlyRunner(askLLM)
+	event := webhook.Event{
+		Type:        webhook.EventIssueComment,
+		Action:      "created",
+		Sender:      "outsider",
+		Repository:  webhook.Repository{FullName: "org/repo", CloneURL: "https://github.com/org/repo.git", DefaultBranch: "main"},
+		Issue:       &webhook.Issue{Number: 5, IsPullRequest: true},
+		CommentBody: "/ai-review",
+	}
+
+	if err := engine.HandleIssueComment(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gh.reviewed {
+		t.Fatalf("review must not run for users without write access")
+	}
+	if len(gh.comments) != 1 || !strings.Contains(gh.comments[0].Body, "only users with write access") {
+		t.Fatalf("expected a refusal comment, got %+v", gh.comments)
+	}
+}
+
+type readOnlyLLM struct {
+	question string
+}
+
+func (f *readOnlyLLM) Run(ctx context.Context, req llm.Request, workDir string) (llm.RunResult, error) {
+	f.question = req.TaskBody
+	return llm.RunResult{Response: llm.Response{Decision: llm.DecisionProceed, Summary: "Retries live in pkg/gitutil."}}, nil
+}
+
+func TestAskCommandAnswersWithReadOnlyRunner(t *testing.T) {
+	cfg := config.Config{AskCommand: "/ai-ask", RepoCloneBase: t.TempDir()}
+
+	gh := &fakeGitHub{}
+	askLLM := &readOnlyLLM{}
+	engine := workflow.NewEngine(cfg, gh, &fakeGit{}, &fakeLLM{}).WithReadOnlyRunner(askLLM)
+	event := webhook.Event{
+		Type:        webhook.EventIssueComment,
+		Action:      "created",
+		Sender:      "asker",
+		Repository:  webhook.Repository{FullName: "org/repo", CloneURL: "https://github.com/org/repo.git", DefaultBranch: "main"},
+		Issue:       &webhook.Issue{Number: 14, State: "closed", Title: "t", Body: "b"},
+		CommentBody: "/ai-ask where are pushes retried?",
+	}
+
+	if err := engine.HandleIssueComment(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if askLLM.question != "where are pushes retried?" {
+		t.Fatalf("expected question to reach the read-only runner, got %q", askLLM.question)
+	}
+	if !gh.commented {
+		t.Fatalf("expected answer comment to be posted")
+	}
+	if gh.createdPR || gh.labelUpdate {
+		t.Fatalf("ask must not create PRs or change labels")
+	}
+}
+
+func TestAskCommandRefusedWithoutReadOnlyRunner(t *testing.T) {
+	cfg := config.Config{AskCommand: "/ai-ask", RepoCloneBase: t.TempDir()}
+
+	gh := &fakeGitHub{}
+	engine := workflow.NewEngine(cfg, gh, &fakeGit{}, &failingLLM{})
+	event := webhook.Event{
+		Type:        webhook.EventIssueComment,
+		Action:      "created",
+		Sender:      "asker",
+		Repository:  webhook.Repository{FullName: "org/repo", CloneURL: "https://github.com/org/repo.git", DefaultBranch: "main"},
+		Issue:       &webhook.Issue{Number: 14, State: "closed", Title: "t", Body: "b"},
+		CommentBody: "/ai-ask where are pushes retried?",
+	}
+
+	if err := engine.HandleIssueComment(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(gh.comments) != 1 || !strings.Contains(gh.comments[0].Body, "`/ai-ask` is not supported") {
+		t.Fatalf("expected an unsupported comment, got %+v", gh.comments)
+	}
+}
+
+func TestScheduledTaskOpensOnePRAtATime(t *testing.T) {
+	task := config.ScheduledTask{Name: "fix-lint", Repo: "org/repo", Schedule: "0 3 * * *", Prompt: "Fix lint warnings"}
+	cfg := config.Config{RepoCloneBase: t.TempDir(), ScheduledTasks: []config.ScheduledTask{task}}
+	event := scheduler.Event(task, time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC))
+
+	gh := &fakeGitHub{openPRs: []github.PR{{Number: 3, HeadRef: "llm/task-fix-lint-20260101-030000"}}}
+	engine := workflow.NewEngine(cfg, gh, &fakeGit{}, &fakeLLM{})
+	if err := engine.HandleScheduledTask(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gh.createdPR {
+		t.Fatalf("expected task to be skipped while its PR is open")
+	}
+
+	gh.openPRs = []github.PR{{Number: 4, HeadRef: "llm/task-other-20260101-030000"}}
+	if err := engine.HandleScheduledTask(context.Background(), event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !gh.createdPR {
+		t.Fatalf("expected task PR to be created")
+	}
+}
+
+// countingLLM counts the runs it is asked to do.
+type countingLLM struct {
+	fakeLLM
+	calls int
+}
+
+func (f *countingLLM) Run(ctx context.Context, req llm.Request, workDir string) (llm.RunResult, error) {
+	f.calls++
+	return f.fakeLLM.Run(ctx, req, workDir)
+}
+
+func TestStatusCommentDeliveredBackStartsNoRun(t *testing.T) {
+	cfg := config.Config{
+		TriggerLabels:   []string{"ai-ready"},
+		InProgressLabel: "ai-in-progress",
+		DoneLabel:       "ai-done",
+		PRSlashCommands: []string{"/ai-optimize"},
+		RepoCloneBase:   t.TempDir(),
+	}
+	gh := &fakeGitHub{}
+	runner := &countingLLM{}
+	engine := workflow.NewEngine(cfg, gh, &fakeGit{}, runner)
+	repo := webhook.Repository{FullName: "org/repo", CloneURL: "https://github.com/org/repo.git", DefaultBranch: "main"}
+
+	label := webhook.Event{Type: webhook.EventIssues, Action: "labeled", Label: "ai-ready", Sender: "labeler", Repository: repo,
+		Issue: &webhook.Issue{Number: 12, State: "open", Title: "t", Body: "b", Labels: []string{"ai-ready"}}}
+	if err := engine.HandleIssueLabel(context.Background(), label); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runner.calls != 1 || len(gh.comments) != 1 {
+		t.Fatalf("expected one run with one status comment, got %d runs and %d comments", runner.calls, len(gh.comments))
+	}
+
+	// GitHub delivers the bot's own status comment back as a comment event.
+	status := gh.comments[0]
+	comment := webhook.Event{Type: webhook.EventIssueComment, Action: "created", Sender: status.User, Repository: repo,
+		Issue: &webhook.Issue{Number: 12, State: "open", Title: "t", Body: "b"}, CommentBody: status.Body, CommentID: status.ID}
+	if err := engine.HandleIssueComment(context.Background(), comment); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	prComment := webhook.Event{Type: webhook.EventPRComment, Action: "created", Sender: status.User, Repository: repo,
+		PullRequest: &webhook.PullRequest{Number: 5, State: "open"}, CommentBody: status.Body + "\n/ai-optimize", CommentID: 99}
+	if err := engine.HandlePRComment(context.Background(), prComment); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runner.calls != 1 || len(gh.comments) != 1 {
+		t.Fatalf("expected the status comment to start no run, got %d runs and %d comments", runner.calls, len(gh.comments))
+	}
+}
+
+func TestPRSlashCommandReactions(t *testing.T) {
+	cfg := config.Config{PRSlashCommands: []string{"/ai-optimize"}, RepoCloneBase: t.TempDir()}
+
+	gh := &fakeGitHub{}
+	engine := workflow.NewEngine(cfg, gh, &fakeGit{}, &fakeLLM{})
+	event := webhook.Event{
+		Type:        webhook.EventPRComment,
+		Action:      "created",
+		Sender:      "human",
+		Repository:  webhook.Repository{FullName: "org/repo", CloneURL: "https://github.com/org/repo.git", DefaultBranch: "main"},
+		PullRequest: &webhook.PullRequest{Number: 5, State: "open", Author: "human", HeadRef: "feature"},
+		CommentBody: "/ai-optimize please",
+		CommentID:   77,
+	}
+
+	err := engine.HandlePRComment(context.Background(), event)
+	engine.ReportResult(context.Background(), event, err)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "review-comment/77:eyes,review-comment/77:rocket"
+	if got := strings.Join(gh.reactions, ","); got != want {
+		t.Fatalf("expected reactions %s, got %s", want, got)
+	}
+
+	gh.reactions = nil
+	event.CommentBody = "looks good"
+	err = engine.HandlePRComment(context.Background(), event)
+	engine.ReportResult(context.Background(), event, err)
+	if len(gh.reactions) != 0 {
+		t.Fatalf("expected no reaction on a non-command comment, got %v", gh.reactions)
+	}
+
+	// A command that is refused starts no run and gets no reaction.
+	event.CommentBody = "/ai-review"
+	err = engine.HandlePRComment(context.Background(), event)
+	engine.ReportResult(context.Background(), event, err)
+	if len(gh.reactions) != 0 {
+		t.Fatalf("expected no reaction on a refused command, got %v", gh.reactions)
+	}
+}
+
+// blockingLLM runs until its context is cancelled.
+type blockingLLM struct {
+	started chan struct{}
+}
+
+func (f *blockingLLM) Run(ctx context.Context, req llm.Request, workDir string) (llm.RunResult, error) {
+	close(f.started)
+	<-ctx.Done()
+	return llm.RunResult{}, ctx.Err()
+}
+
+func TestCancelCommandStopsIssueRun(t *testing.T) {
+	cfg := config.Config{
+		TriggerLabels:   []string{"ai-ready"},
+		InProgressLabel: "ai-in-progress",
+		DoneLabel:       "ai-done",
+		NeedsInfoLabel:  "ai-needs-info",
+		CancelCommand:   "/ai-cancel",
+		RepoCloneBase:   t.TempDir(),
+	}
+
+	gh := &fakeGitHub{}
+	runner := &blockingLLM{started: make(chan struct{})}
+	engine := workflow.NewEngine(cfg, gh, &fakeGit{}, runner)
+	repository := webhook.Repository{FullName: "org/repo", CloneURL: "https://github.com/org/repo.git", DefaultBranch: "main"}
+	event := webhook.Event{
+		Type:       webhook.EventIssues,
+		Action:     "labeled",
+		Label:      "ai-ready",
+		Sender:     "labeler",
+		Repository: repository,
+		Issue:      &webhook.Issue{Number: 12, State: "open", Title: "t", Body: "b", Labels: []string{"ai-ready"}},
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- engine.HandleIssueLabel(context.Background(), event) }()
+	<-runner.started
+
+	cancel := webhook.Event{
+		Type:        webhook.EventIssueComment,
+		Action:      "created",
+		Sender:      "maintainer",
+		Repository:  repository,
+		Issue:       &webhook.Issue{Number: 12, State: "open", Labels: []string{"ai-in-progress"}},
+		CommentBody: "/ai-cancel",
+	}
+	if !engine.HandleCancel(context.Background(), cancel) {
+		t.Fatal("expected the run to be cancelled")
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected a cancelled run to end cleanly, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not stop after cancellation")
+	}
+
+	if gh.createdPR {
+		t.Fatal("cancelled run must not open a PR")
+	}
+	if strings.Join(gh.labels, ",") != "" {
+		t.Fatalf("expected trigger and in-progress labels to be removed, got %v", gh.labels)
+	}
+	last := gh.comments[len(gh.comments)-1].Body
+	if !strings.Contains(last, "Run cancelled: @maintainer requested it with `/ai-cancel`") {
+		t.Fatalf("expected cancellation comment, got %q", last)
+	}
+	if !strings.Contains(gh.comments[0].Body, "Cancelled") {
+		t.Fatalf("expected status comment to show the cancellation, got %q", gh.comments[0].Body)
+	}
+	if engine.HandleCancel(context.Background(), cancel) {
+		t.Fatal("expected no run left to cancel")
+	}
+}
+
+type failingLLM struct{}
+
+func (f *failingLLM) Run(ctx context.Context, req llm.Request, workDir string) (llm.RunResult, error) {
+	return llm.RunResult{}, fmt.Errorf("agent exited with status 1\nstderr: boom")
+}
+
+func TestIssueRunFailureLabelsAndReports(t *testing.T) {
+	cfg := config.Config{
+		TriggerLabels:   []string{"ai-ready"},
+		InProgressLabel: "ai-in-progress",
+		DoneLabel:       "ai-done",
+		NeedsInfoLabel:  "ai-needs-info",
+		FailedLabel:     "ai-failed",
+		JobDetailsURL:   "https://logs.example.com/?delivery={{.DeliveryID}}&repo={{.Repo}}",
+		RepoCloneBase:   t.TempDir(),
+	}
+
+	gh := &fakeGitHub{}
+	engine := workflow.NewEngine(cfg, gh, &fakeGit{}, &failingLLM{})
+	event := webhook.Event{
+		Type:       webhook.EventIssues,
+		Action:     "labeled",
+		Label:      "ai-ready",
+		Sender:     "labeler",
+		DeliveryID: "d-42",
+		Repository: webhook.Repository{FullName: "org/repo", CloneURL: "https://github.com/org/repo.git", DefaultBranch: "main"},
+		Issue:      &webhook.Issue{Number: 13, State: "open", Title: "t", Body: "b", Labels: []string{"ai-ready"}},
+	}
+
+	if err := engine.HandleIssueLabel(context.Background(), event); err == nil {
+		t.Fatal("expected the run to fail")
+	}
+	if gh.createdPR {
+		t.Fatal("failed run must not open a PR")
+	}
+	if labels := strings.Join(gh.labels, ","); labels != "ai-failed" {
+		t.Fatalf("expected ai-failed to replace the status labels, got %v", gh.labels)
+	}
+	if len(gh.comments) != 1 {
+		t.Fatalf("expected the failure to be shown in the status comment only, got %d comments", len(gh.comments))
+	}
+	status := gh.comments[0].Body
+	for _, want := range []string{"Failed: Running the agent", "Failed at step `run-llm`.", "Run: agent exited with status 1 …", "https://logs.example.com/?delivery=d-42&repo=org/repo", "The issue is labeled `ai-failed`. Add the `ai-ready` label to retry."} {
+		if !strings.Contains(status, want) {
+			t.Fatalf("expected status comment to contain %q, got %q", want, status)
+		}
+	}
+	if strings.Contains(status, "boom") {
+		t.Fatalf("expected status comment to keep only the first error line, got %q", status)
 	}
 }
@@ -2,10 +2,13 @@ package integration_test
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"git_sonic/pkg/github"
 )
@@ -22,10 +25,13 @@ func TestCreateIssueComment(t *testing.T) {
 	defer server.Close()
 
 	client := github.NewClient(server.URL, "token")
-	err := client.CreateIssueComment(context.Background(), "org", "repo", 9, "hello")
+	id, err := client.CreateIssueCommentID(context.Background(), "org", "repo", 9, "hello")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if id != 1 {
+		t.Fatalf("unexpected comment ID: %d", id)
+	}
 	if gotMethod != http.MethodPost {
 		t.Fatalf("expected POST, got %s", gotMethod)
 	}
@@ -33,3 +39,223 @@ func TestCreateIssueComment(t *testing.T) {
 		t.Fatalf("unexpected path: %s", gotPath)
 	}
 }
+
+func TestUpdateIssueComment(t *testing.T) {
+	var gotMethod, gotPath, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		_, _ = w.Write([]byte(`{"id":42}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(server.URL, "token")
+	if err := client.UpdateIssueComment(context.Background(), "org", "repo", 42, "edited"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotMethod != http.MethodPatch {
+		t.Fatalf("expected PATCH, got %s", gotMethod)
+	}
+	if gotPath != "/repos/org/repo/issues/comments/42" {
+		t.Fatalf("unexpected path: %s", gotPath)
+	}
+	if !strings.Contains(gotBody, `"edited"`) {
+		t.Fatalf("unexpected body: %s", gotBody)
+	}
+}
+
+func TestGetAuthenticatedLogin(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_, _ = w.Write([]byte(`{"login":"sonic-bot","id":7}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(server.URL, "token")
+	login, err := client.GetAuthenticatedLogin(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if login != "sonic-bot" || gotPath != "/user" {
+		t.Fatalf("unexpected login %q from path %s", login, gotPath)
+	}
+}
+
+func TestGetUserID(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_, _ = w.Write([]byte(`{"login":"git-sonic[bot]","id":41898282}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(server.URL, "token")
+	id, err := client.GetUserID(context.Background(), "git-sonic[bot]")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != 41898282 {
+		t.Fatalf("unexpected id: %d", id)
+	}
+	if gotPath != "/users/git-sonic[bot]" {
+		t.Fatalf("unexpected path: %s", gotPath)
+	}
+}
+
+func TestCreateCommentReactions(t *testing.T) {
+	var gotPaths, gotBodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPaths = append(gotPaths, r.Method+" "+r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		gotBodies = append(gotBodies, string(body))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(server.URL, "token")
+	if err := client.CreateIssueCommentReaction(context.Background(), "org", "repo", 5, github.ReactionEyes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.CreateReviewCommentReaction(context.Background(), "org", "repo", 6, github.ReactionRocket); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"POST /repos/org/repo/issues/comments/5/reactions", "POST /repos/org/repo/pulls/comments/6/reactions"}
+	if strings.Join(gotPaths, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected requests: %v", gotPaths)
+	}
+	if !strings.Contains(gotBodies[0], `"eyes"`) || !strings.Contains(gotBodies[1], `"rocket"`) {
+		t.Fatalf("unexpected bodies: %v", gotBodies)
+	}
+}
+
+func TestListRepoLabelsSendsQuery(t *testing.T) {
+	var gotPath, gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotQuery = r.URL.RawQuery
+		_, _ = w.Write([]byte(`[{"name":"bug"},{"name":"enhancement"}]`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(server.URL, "token")
+	labels, err := client.ListRepoLabels(context.Background(), "org", "repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotPath != "/repos/org/repo/labels" || gotQuery != "per_page=100" {
+		t.Fatalf("unexpected request: %s?%s", gotPath, gotQuery)
+	}
+	if len(labels) != 2 || labels[1] != "enhancement" {
+		t.Fatalf("unexpected labels: %v", labels)
+	}
+}
+
+func TestGetPRDiffRequestsDiffMediaType(t *testing.T) {
+	var gotAccept string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAccept = r.Header.Get("Accept")
+		_, _ = w.Write([]byte("diff --git a/x b/x\n"))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(server.URL, "token")
+	diff, err := client.GetPRDiff(context.Background(), "org", "repo", 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotAccept != "application/vnd.github.diff" {
+		t.Fatalf("unexpected Accept header: %s", gotAccept)
+	}
+	if diff != "diff --git a/x b/x\n" {
+		t.Fatalf("unexpected diff: %q", diff)
+	}
+}
+
+func TestListRepoIssueCommentsParsesParent(t *testing.T) {
+	var gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		_, _ = w.Write([]byte(`[{"id":7,"body":"/ai-ask why?","issue_url":"https://api.github.com/repos/org/repo/issues/42","html_url":"https://github.com/org/repo/pull/42#issuecomment-7","created_at":"2026-01-02T03:04:05Z","updated_at":"2026-01-02T03:04:05Z","user":{"login":"dev"}}]`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(server.URL, "token")
+	since := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	comments, err := client.ListRepoIssueComments(context.Background(), "org", "repo", since)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(gotQuery, "since=2026-01-02T00%3A00%3A00Z") {
+		t.Fatalf("unexpected query: %s", gotQuery)
+	}
+	if len(comments) != 1 || comments[0].Number != 42 || !comments[0].IsPullRequest || comments[0].User != "dev" {
+		t.Fatalf("unexpected comments: %+v", comments)
+	}
+}
+
+func TestListIssueCommentsReadsAllPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/org/repo/issues/9/comments" || r.URL.Query().Get("per_page") != "100" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		count, offset := 100, 0
+		if r.URL.Query().Get("page") == "2" {
+			count, offset = 30, 100
+		}
+		var items []string
+		for i := 0; i < count; i++ {
+			items = append(items, fmt.Sprintf(`{"id":%d,"body":"comment","user":{"login":"alice"}}`, offset+i+1))
+		}
+		_, _ = w.Write([]byte("[" + strings.Join(items, ",") + "]"))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(server.URL, "token")
+	comments, err := client.ListIssueComments(context.Background(), "org", "repo", 9)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(comments) != 130 || comments[129].ID != 130 {
+		t.Fatalf("expected 130 comments across two pages, got %d", len(comments))
+	}
+}
+
+func TestListOpenPRsReadsAllPages(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		if r.URL.Query().Get("per_page") != "100" || r.URL.Query().Get("state") != "open" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		var items []string
+		count := 100
+		if page == "2" {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			items = append(items, fmt.Sprintf(`{"number":%s%02d,"head":{"ref":"llm/task-%s-%d"}}`, page, i, page, i))
+		}
+		_, _ = w.Write([]byte("[" + strings.Join(items, ",") + "]"))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(server.URL, "token")
+	prs, err := client.ListOpenPRs(context.Background(), "org", "repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(prs) != 101 {
+		t.Fatalf("expected 101 PRs across two pages, got %d", len(prs))
+	}
+	if last := prs[len(prs)-1]; last.HeadRef != "llm/task-2-0" {
+		t.Fatalf("unexpected last PR: %+v", last)
+	}
+	if strings.Join(pages, ",") != "1,2" {
+		t.Fatalf("unexpected pages requested: %v", pages)
+	}
+}
@@ -0,0 +1,46 @@
+package unit_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git_sonic/pkg/agenttools"
+	"github.com/MimeLyc/agent-core-go/pkg/tools"
+)
+
+func TestSearchToolFindsMatchingLines(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-b", "main")
+	if err := os.MkdirAll(filepath.Join(dir, "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "pkg", "push.go"), "package pkg\n\nfunc PushWithRetry() {}\n")
+	writeFile(t, filepath.Join(dir, "README.md"), "Pushes are retried.\n")
+	runGit(t, dir, "add", ".")
+
+	toolCtx := tools.NewToolContext(dir)
+	ctx := context.Background()
+	result, err := agenttools.SearchTool{}.Execute(ctx, toolCtx, map[string]any{"pattern": "retry", "ignore_case": true})
+	if err != nil || result.IsError {
+		t.Fatalf("search failed: %v %+v", err, result)
+	}
+	if result.Content != "pkg/push.go:3:func PushWithRetry() {}" {
+		t.Fatalf("unexpected matches: %q", result.Content)
+	}
+
+	result, _ = agenttools.SearchTool{}.Execute(ctx, toolCtx, map[string]any{"pattern": "Retried", "path": "pkg"})
+	if result.IsError || result.Content != "No matches found" {
+		t.Fatalf("expected no matches, got %+v", result)
+	}
+
+	result, _ = agenttools.SearchTool{}.Execute(ctx, toolCtx, map[string]any{"pattern": "x", "path": "../"})
+	if !result.IsError {
+		t.Fatalf("expected paths outside the repository to be rejected, got %+v", result)
+	}
+	if result, _ = (agenttools.SearchTool{}).Execute(ctx, toolCtx, map[string]any{}); !result.IsError || !strings.Contains(result.Content, "pattern") {
+		t.Fatalf("expected a missing pattern error, got %+v", result)
+	}
+}
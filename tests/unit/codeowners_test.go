@@ -0,0 +1,101 @@
+package unit_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"git_sonic/pkg/codeowners"
+)
+
+func TestCodeOwnersLastMatchWins(t *testing.T) {
+	rules := codeowners.Parse(`
+# default owners
+*            @org/core
+*.md         @docs-writer
+/security/   @org/security
+docs/**      @org/docs # trailing comment
+`)
+	cases := map[string][]string{
+		"main.go":               {"@org/core"},
+		"README.md":             {"@docs-writer"},
+		"pkg/notes.md":          {"@docs-writer"},
+		"security/keys.go":      {"@org/security"},
+		"pkg/security/keys.go":  {"@org/core"},
+		"docs/guide/install.md": {"@org/docs"},
+	}
+	for path, want := range cases {
+		if got := rules.Owners(path); !reflect.DeepEqual(got, want) {
+			t.Errorf("Owners(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestCodeOwnersTrailingGlobMatchesOneLevel(t *testing.T) {
+	rules := codeowners.Parse("* @org/core\ndocs/* @org/docs\n/build/ @org/build\nlib @org/lib\n")
+	cases := map[string][]string{
+		"docs/a.md":       {"@org/docs"},
+		"docs/a/b.md":     {"@org/core"},
+		"build/out/x.bin": {"@org/build"},
+		"lib/util.go":     {"@org/lib"},
+		"src/lib/util.go": {"@org/lib"},
+	}
+	for path, want := range cases {
+		if got := rules.Owners(path); !reflect.DeepEqual(got, want) {
+			t.Errorf("Owners(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestCodeOwnersPathsOwnedBy(t *testing.T) {
+	rules := codeowners.Parse("* @org/core\n/auth/ @Org/Security\n")
+	got := rules.PathsOwnedBy([]string{"main.go", "auth/token.go"}, []string{"org/security"})
+	if !reflect.DeepEqual(got, []string{"auth/token.go"}) {
+		t.Fatalf("unexpected protected paths: %v", got)
+	}
+}
+
+func TestCodeOwnersIsCodeOwnersFile(t *testing.T) {
+	for _, path := range []string{"CODEOWNERS", ".github/CODEOWNERS", "/docs/CODEOWNERS"} {
+		if !codeowners.IsCodeOwnersFile(path) {
+			t.Errorf("expected %q to be a CODEOWNERS file", path)
+		}
+	}
+	if codeowners.IsCodeOwnersFile("pkg/CODEOWNERS") {
+		t.Error("expected pkg/CODEOWNERS not to be a CODEOWNERS location")
+	}
+}
+
+func TestCodeOwnersSplitReviewers(t *testing.T) {
+	users, teams := codeowners.SplitReviewers([]string{"@alice", "@org/core", "bob@example.com"})
+	if !reflect.DeepEqual(users, []string{"alice"}) {
+		t.Fatalf("unexpected users: %v", users)
+	}
+	if !reflect.DeepEqual(teams, []string{"core"}) {
+		t.Fatalf("unexpected teams: %v", teams)
+	}
+}
+
+func TestCodeOwnersLoadPrefersGitHubDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, ".github"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "CODEOWNERS"), []byte("* @root\n"), 0o644); err != nil {
+		t.Fatalf("write root CODEOWNERS: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".github", "CODEOWNERS"), []byte("* @github\n"), 0o644); err != nil {
+		t.Fatalf("write .github CODEOWNERS: %v", err)
+	}
+	rules, err := codeowners.Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if rules.Path != ".github/CODEOWNERS" {
+		t.Fatalf("unexpected CODEOWNERS path: %s", rules.Path)
+	}
+	if got := rules.Owners("x.go"); !reflect.DeepEqual(got, []string{"@github"}) {
+		t.Fatalf("unexpected owners: %v", got)
+	}
+}
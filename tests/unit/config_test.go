@@ -1,6 +1,8 @@
 package unit_test
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -57,3 +59,214 @@ func TestLoadFromEnvLists(t *testing.T) {
 		t.Fatalf("unexpected trigger labels: %#v", cfg.TriggerLabels)
 	}
 }
+
+func TestLoadFromEnvRejectsInvalidBranchTemplate(t *testing.T) {
+	env := map[string]string{
+		"GITHUB_TOKEN":    "token",
+		"LLM_COMMAND":     "llm",
+		"BRANCH_TEMPLATE": "llm/{{.Number",
+	}
+	if _, err := config.LoadFromEnv(func(key string) string { return env[key] }); err == nil {
+		t.Fatal("expected invalid BRANCH_TEMPLATE to be rejected")
+	}
+}
+
+func TestLoadFromEnvStatusTemplateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.md")
+	if err := os.WriteFile(path, []byte("**{{.Headline}}**"), 0o644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+	env := map[string]string{
+		"GITHUB_TOKEN":         "token",
+		"LLM_COMMAND":          "llm",
+		"STATUS_TEMPLATE_FILE": path,
+	}
+	cfg, err := config.LoadFromEnv(func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.StatusTemplate != "**{{.Headline}}**" {
+		t.Fatalf("unexpected status template: %q", cfg.StatusTemplate)
+	}
+
+	if err := os.WriteFile(path, []byte("{{.Headline"), 0o644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+	if _, err := config.LoadFromEnv(func(key string) string { return env[key] }); err == nil {
+		t.Fatal("expected invalid STATUS_TEMPLATE_FILE to be rejected")
+	}
+}
+
+func TestLoadFromEnvFailureSettings(t *testing.T) {
+	env := map[string]string{
+		"GITHUB_TOKEN": "token",
+		"LLM_COMMAND":  "llm",
+	}
+	cfg, err := config.LoadFromEnv(func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.FailedLabel != "ai-failed" || cfg.JobDetailsURL != "" {
+		t.Fatalf("unexpected defaults: label=%q url=%q", cfg.FailedLabel, cfg.JobDetailsURL)
+	}
+
+	env["FAILED_LABEL"] = "bot-failed"
+	env["JOB_DETAILS_URL"] = "https://logs.example.com/?q={{.DeliveryID}}"
+	cfg, err = config.LoadFromEnv(func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.FailedLabel != "bot-failed" || cfg.JobDetailsURL != env["JOB_DETAILS_URL"] {
+		t.Fatalf("unexpected settings: label=%q url=%q", cfg.FailedLabel, cfg.JobDetailsURL)
+	}
+
+	env["JOB_DETAILS_URL"] = "{{.DeliveryID"
+	if _, err := config.LoadFromEnv(func(key string) string { return env[key] }); err == nil {
+		t.Fatal("expected invalid JOB_DETAILS_URL to be rejected")
+	}
+}
+
+func TestLoadFromEnvScheduledTasks(t *testing.T) {
+	env := map[string]string{
+		"GITHUB_TOKEN":    "token",
+		"LLM_COMMAND":     "llm",
+		"SCHEDULED_TASKS": `[{"name":"fix-lint","repo":"org/repo","schedule":"0 3 * * 1","prompt":"Fix lint warnings"}]`,
+	}
+	cfg, err := config.LoadFromEnv(func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task, ok := cfg.Task("fix-lint"); !ok || task.Repo != "org/repo" {
+		t.Fatalf("unexpected scheduled tasks: %+v", cfg.ScheduledTasks)
+	}
+
+	for _, tasks := range []string{
+		`[{"name":"fix-lint","repo":"org/repo","schedule":"0 25 * * *","prompt":"p"}]`,
+		`[{"name":"Fix Lint","repo":"org/repo","schedule":"@daily","prompt":"p"}]`,
+		`[{"name":"a","repo":"org/repo","schedule":"@daily","prompt":"p"},{"name":"a","repo":"org/repo","schedule":"@daily","prompt":"p"}]`,
+		`[{"name":"a","repo":"repo","schedule":"@daily","prompt":"p"}]`,
+	} {
+		env["SCHEDULED_TASKS"] = tasks
+		if _, err := config.LoadFromEnv(func(key string) string { return env[key] }); err == nil {
+			t.Fatalf("expected error for %s", tasks)
+		}
+	}
+}
+
+func TestLoadFromEnvPollMode(t *testing.T) {
+	env := map[string]string{
+		"GITHUB_TOKEN":    "token",
+		"LLM_COMMAND":     "llm",
+		"REPO_CLONE_BASE": "/data",
+		"POLL_INTERVAL":   "1m",
+	}
+	if _, err := config.LoadFromEnv(func(key string) string { return env[key] }); err == nil {
+		t.Fatalf("expected error when POLL_REPOS is missing")
+	}
+	env["POLL_REPOS"] = "org/repo,org/other"
+	cfg, err := config.LoadFromEnv(func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.PollInterval != time.Minute || len(cfg.PollRepos) != 2 || cfg.PollCursorFile != "/data/.poll-cursor.json" {
+		t.Fatalf("unexpected poll config: %+v", cfg)
+	}
+}
+
+func TestLoadFromEnvDryRunRepoOverride(t *testing.T) {
+	env := map[string]string{
+		"GITHUB_TOKEN":  "token",
+		"LLM_COMMAND":   "llm",
+		"DRY_RUN":       "true",
+		"REPO_SETTINGS": `{"org/live":{"dry_run":false}}`,
+	}
+	cfg, err := config.LoadFromEnv(func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.DryRun {
+		t.Fatalf("expected global dry run")
+	}
+	if override := cfg.ForRepo("org/live").DryRun; override == nil || *override {
+		t.Fatalf("expected org/live to opt out of dry run, got %v", override)
+	}
+}
+
+func TestLoadFromEnvPolicyRules(t *testing.T) {
+	env := map[string]string{
+		"GITHUB_TOKEN":          "token",
+		"LLM_COMMAND":           "llm",
+		"POLICY_MAX_FILE_BYTES": "2MiB",
+	}
+	cfg, err := config.LoadFromEnv(func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.PolicyRules) != 6 || cfg.PolicyMaxFileBytes != 2<<20 {
+		t.Fatalf("unexpected policy defaults: %v %d", cfg.PolicyRules, cfg.PolicyMaxFileBytes)
+	}
+
+	env["POLICY_RULES"] = "none"
+	cfg, err = config.LoadFromEnv(func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.PolicyRules == nil || len(cfg.PolicyRules) != 0 {
+		t.Fatalf("expected no policy rules, got %v", cfg.PolicyRules)
+	}
+
+	env["POLICY_RULES"] = "binary,no_such_rule"
+	if _, err := config.LoadFromEnv(func(key string) string { return env[key] }); err == nil {
+		t.Fatalf("expected error for unknown policy rule")
+	}
+}
+
+func TestLoadFromEnvGPGSigningNeedsAKey(t *testing.T) {
+	env := map[string]string{
+		"GITHUB_TOKEN":          "token",
+		"LLM_COMMAND":           "llm",
+		"COMMIT_SIGNING_FORMAT": "gpg",
+	}
+	if _, err := config.LoadFromEnv(func(key string) string { return env[key] }); err == nil {
+		t.Fatalf("expected an error for gpg signing without a key")
+	}
+
+	env["COMMIT_SIGNING_KEY_FILE"] = "/run/secrets/signing.asc"
+	cfg, err := config.LoadFromEnv(func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.CommitSigningKeyFile != "/run/secrets/signing.asc" {
+		t.Fatalf("unexpected key file: %q", cfg.CommitSigningKeyFile)
+	}
+
+	env["COMMIT_SIGNING_FORMAT"] = "ssh"
+	env["COMMIT_SIGNING_KEY"] = "/run/secrets/id_ed25519"
+	if _, err := config.LoadFromEnv(func(key string) string { return env[key] }); err == nil {
+		t.Fatalf("expected an error for a key file with ssh signing")
+	}
+}
+
+func TestCommitEmailUsesNoreplyAddressWithID(t *testing.T) {
+	env := map[string]string{
+		"GITHUB_TOKEN":     "token",
+		"LLM_COMMAND":      "llm",
+		"COMMIT_AUTHOR_ID": "41898282",
+	}
+	cfg, err := config.LoadFromEnv(func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.CommitEmail(); got != "41898282+git-sonic[bot]@users.noreply.github.com" {
+		t.Fatalf("unexpected commit email: %q", got)
+	}
+
+	env["COMMIT_AUTHOR_EMAIL"] = "bot@example.com"
+	cfg, err = config.LoadFromEnv(func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.CommitEmail(); got != "bot@example.com" {
+		t.Fatalf("expected COMMIT_AUTHOR_EMAIL to win, got %q", got)
+	}
+}
@@ -0,0 +1,54 @@
+package unit_test
+
+import (
+	"testing"
+	"time"
+
+	"git_sonic/pkg/cron"
+)
+
+func TestCronMatches(t *testing.T) {
+	cases := []struct {
+		expr string
+		at   time.Time
+		want bool
+	}{
+		{"0 3 * * 1", time.Date(2026, 1, 5, 3, 0, 0, 0, time.UTC), true}, // Monday
+		{"0 3 * * 1", time.Date(2026, 1, 6, 3, 0, 0, 0, time.UTC), false},
+		{"*/15 9-17 * * 1-5", time.Date(2026, 1, 7, 9, 45, 30, 0, time.UTC), true},
+		{"*/15 9-17 * * 1-5", time.Date(2026, 1, 7, 9, 50, 0, 0, time.UTC), false},
+		{"0 0 * * 7", time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC), true}, // Sunday as 7
+		{"0 0 1 * 1", time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), true}, // restricted day fields are ORed
+		{"@daily", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"0 12 1,15 2 *", time.Date(2026, 2, 15, 12, 0, 0, 0, time.UTC), true},
+	}
+	for _, tc := range cases {
+		schedule, err := cron.Parse(tc.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.expr, err)
+		}
+		if got := schedule.Matches(tc.at); got != tc.want {
+			t.Fatalf("Parse(%q).Matches(%s) = %v, want %v", tc.expr, tc.at, got, tc.want)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	schedule, err := cron.Parse("30 4 * * 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := schedule.Next(time.Date(2026, 1, 5, 4, 30, 0, 0, time.UTC))
+	want := time.Date(2026, 1, 12, 4, 30, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Fatalf("Next() = %s, want %s", got, want)
+	}
+}
+
+func TestCronParseErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := cron.Parse(expr); err == nil {
+			t.Fatalf("expected error for %q", expr)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package unit_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git_sonic/pkg/gitutil"
+)
+
+// setupReleaseRepo creates a repository with a release branch cut from main
+// and a fix merged into main with a merge commit. It returns the repository
+// and the merge commit SHA.
+func setupReleaseRepo(t *testing.T, releaseContent string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-b", "main")
+	runGit(t, dir, "config", "user.email", "test@test.com")
+	runGit(t, dir, "config", "user.name", "Test")
+	writeFile(t, filepath.Join(dir, "file.txt"), "base\n")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", "base")
+	runGit(t, dir, "branch", "release-1.0")
+
+	runGit(t, dir, "checkout", "-b", "fix")
+	writeFile(t, filepath.Join(dir, "file.txt"), "fixed\n")
+	runGit(t, dir, "commit", "-am", "fix bug")
+	runGit(t, dir, "checkout", "main")
+	runGit(t, dir, "merge", "--no-ff", "-m", "Merge fix", "fix")
+	merge := runGit(t, dir, "rev-parse", "HEAD")
+
+	runGit(t, dir, "checkout", "release-1.0")
+	if releaseContent != "" {
+		writeFile(t, filepath.Join(dir, "file.txt"), releaseContent)
+		runGit(t, dir, "commit", "-am", "release change")
+	}
+	return dir, merge
+}
+
+func TestCherryPickMergeCommit(t *testing.T) {
+	dir, merge := setupReleaseRepo(t, "")
+	client := gitutil.Client{Commit: gitutil.CommitConfig{AuthorName: "Bot", AuthorEmail: "bot@test.com"}}
+
+	if err := client.CherryPick(context.Background(), dir, merge); err != nil {
+		t.Fatalf("CherryPick: %v", err)
+	}
+	if got := runGit(t, dir, "show", "HEAD:file.txt"); got != "fixed" {
+		t.Fatalf("unexpected file content after cherry-pick: %q", got)
+	}
+	if msg := runGit(t, dir, "log", "-1", "--format=%B"); !strings.Contains(msg, "cherry picked from commit "+merge) {
+		t.Fatalf("expected -x trailer, got %q", msg)
+	}
+}
+
+func TestCherryPickConflictReturnsConflictError(t *testing.T) {
+	dir, merge := setupReleaseRepo(t, "release\n")
+	client := gitutil.Client{Commit: gitutil.CommitConfig{AuthorName: "Bot", AuthorEmail: "bot@test.com"}}
+	ctx := context.Background()
+
+	err := client.CherryPick(ctx, dir, merge)
+	var conflict *gitutil.ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected *gitutil.ConflictError, got %v", err)
+	}
+	if conflict.Op != "cherry-pick" || len(conflict.Files) != 1 || conflict.Files[0] != "file.txt" {
+		t.Fatalf("unexpected conflict: %+v", conflict)
+	}
+
+	writeFile(t, filepath.Join(dir, "file.txt"), "release fixed\n")
+	if err := client.MarkResolved(ctx, dir, conflict.Files); err != nil {
+		t.Fatalf("MarkResolved: %v", err)
+	}
+	if err := client.ContinueCherryPick(ctx, dir); err != nil {
+		t.Fatalf("ContinueCherryPick: %v", err)
+	}
+	if got := runGit(t, dir, "log", "-1", "--format=%cn"); got != "Bot" {
+		t.Fatalf("expected bot committer on resolved pick, got %q", got)
+	}
+}
+
+func TestCommitRangeHelpers(t *testing.T) {
+	dir, merge := setupReleaseRepo(t, "")
+	client := gitutil.Client{}
+	ctx := context.Background()
+	fix := runGit(t, dir, "rev-parse", "fix")
+
+	parents, err := client.CommitParents(ctx, dir, merge)
+	if err != nil || len(parents) != 2 {
+		t.Fatalf("CommitParents(merge) = %v, %v; want two parents", parents, err)
+	}
+	base, err := client.MergeBase(ctx, dir, fix, "release-1.0")
+	if err != nil {
+		t.Fatalf("MergeBase: %v", err)
+	}
+	if want := runGit(t, dir, "rev-parse", "release-1.0"); base != want {
+		t.Fatalf("MergeBase = %q, want %q", base, want)
+	}
+	commits, err := client.CommitsBetween(ctx, dir, base, fix)
+	if err != nil {
+		t.Fatalf("CommitsBetween: %v", err)
+	}
+	if len(commits) != 1 || commits[0] != fix {
+		t.Fatalf("CommitsBetween = %v, want [%s]", commits, fix)
+	}
+}
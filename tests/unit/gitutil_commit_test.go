@@ -0,0 +1,95 @@
+package unit_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git_sonic/pkg/gitutil"
+)
+
+func TestCommitAllUsesConfiguredIdentityAndSignOff(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init")
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	client := gitutil.Client{Commit: gitutil.CommitConfig{
+		AuthorName:  "git-sonic[bot]",
+		AuthorEmail: "bot@example.com",
+		SignOff:     true,
+	}}
+	if err := client.CommitAll(context.Background(), dir, "Add a\n\nCo-authored-by: alice <alice@example.com>"); err != nil {
+		t.Fatalf("CommitAll: %v", err)
+	}
+
+	if got := runGit(t, dir, "log", "-1", "--format=%an <%ae>|%cn <%ce>"); got != "git-sonic[bot] <bot@example.com>|git-sonic[bot] <bot@example.com>" {
+		t.Fatalf("unexpected author/committer: %s", got)
+	}
+	body := runGit(t, dir, "log", "-1", "--format=%B")
+	if !strings.Contains(body, "Signed-off-by: git-sonic[bot] <bot@example.com>") {
+		t.Fatalf("expected Signed-off-by trailer, got: %s", body)
+	}
+	if !strings.Contains(body, "Co-authored-by: alice <alice@example.com>") {
+		t.Fatalf("expected Co-authored-by trailer, got: %s", body)
+	}
+}
+
+func TestCommitAllSignsWithImportedGPGKey(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg is not installed")
+	}
+	ctx := context.Background()
+	// Export a fresh key from a scratch keyring, as an operator would.
+	scratch := t.TempDir()
+	t.Cleanup(func() { _ = exec.Command("gpgconf", "--homedir", scratch, "--kill", "gpg-agent").Run() })
+	gpg := func(args ...string) []byte {
+		t.Helper()
+		out, err := exec.Command("gpg", append([]string{"--batch", "--homedir", scratch}, args...)...).Output()
+		if err != nil {
+			t.Fatalf("gpg %v: %v", args, err)
+		}
+		return out
+	}
+	gpg("--passphrase", "", "--quick-gen-key", "bot <bot@example.com>", "default", "default", "never")
+	keyFile := filepath.Join(t.TempDir(), "signing.asc")
+	if err := os.WriteFile(keyFile, gpg("--armor", "--export-secret-keys"), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+
+	home := t.TempDir()
+	t.Cleanup(func() { _ = exec.Command("gpgconf", "--homedir", home, "--kill", "gpg-agent").Run() })
+	fingerprint, err := gitutil.ImportGPGKey(ctx, keyFile, home)
+	if err != nil {
+		t.Fatalf("ImportGPGKey: %v", err)
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init")
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	client := gitutil.Client{Commit: gitutil.CommitConfig{
+		AuthorName:    "bot",
+		AuthorEmail:   "bot@example.com",
+		SigningFormat: "gpg",
+		SigningKey:    fingerprint,
+		GPGHome:       home,
+	}}
+	if err := client.CommitAll(ctx, dir, "Add a"); err != nil {
+		t.Fatalf("CommitAll: %v", err)
+	}
+	cmd := exec.Command("git", "-C", dir, "log", "-1", "--format=%G?|%GF")
+	cmd.Env = append(os.Environ(), "GNUPGHOME="+home)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git log: %v", err)
+	}
+	if status, signer, _ := strings.Cut(strings.TrimSpace(string(out)), "|"); status == "N" || signer != fingerprint {
+		t.Fatalf("expected a signature by %s, got %q", fingerprint, out)
+	}
+}
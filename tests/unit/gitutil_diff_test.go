@@ -0,0 +1,65 @@
+package unit_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git_sonic/pkg/gitutil"
+)
+
+func TestDiffIncludesUntrackedFilesAndSkipsArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init")
+	client := gitutil.Client{Commit: gitutil.CommitConfig{AuthorName: "bot", AuthorEmail: "bot@example.com"}}
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := client.CommitAll(context.Background(), dir, "init"); err != nil {
+		t.Fatalf("CommitAll: %v", err)
+	}
+
+	for name, content := range map[string]string{"a.txt": "a\nb\n", "new.txt": "new\n", "prompt.md": "prompt\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	diff, err := client.Diff(context.Background(), dir, "HEAD")
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if !strings.Contains(diff, "+b") || !strings.Contains(diff, "new file mode") || !strings.Contains(diff, "+new") {
+		t.Fatalf("expected modified and untracked files in diff, got:\n%s", diff)
+	}
+	if strings.Contains(diff, "prompt.md") {
+		t.Fatalf("expected automation artifacts to be excluded, got:\n%s", diff)
+	}
+}
+
+func TestShowFileReadsRevision(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init")
+	client := gitutil.Client{Commit: gitutil.CommitConfig{AuthorName: "bot", AuthorEmail: "bot@example.com"}}
+	if err := os.WriteFile(filepath.Join(dir, "CODEOWNERS"), []byte("* @base\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := client.CommitAll(context.Background(), dir, "init"); err != nil {
+		t.Fatalf("CommitAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "CODEOWNERS"), []byte("* @changed\n"), 0o644); err != nil {
+		t.Fatalf("rewrite file: %v", err)
+	}
+
+	content, found, err := client.ShowFile(context.Background(), dir, "HEAD", "CODEOWNERS")
+	if err != nil || !found || content != "* @base\n" {
+		t.Fatalf("ShowFile = %q, %v, %v; want committed content", content, found, err)
+	}
+	if _, found, err := client.ShowFile(context.Background(), dir, "HEAD", "docs/CODEOWNERS"); err != nil || found {
+		t.Fatalf("expected missing file to be reported as not found, got found=%v err=%v", found, err)
+	}
+	if _, _, err := client.ShowFile(context.Background(), dir, "no-such-branch", "CODEOWNERS"); err == nil {
+		t.Fatal("expected an error for an unknown revision")
+	}
+}
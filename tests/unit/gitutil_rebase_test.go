@@ -0,0 +1,102 @@
+package unit_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"git_sonic/pkg/gitutil"
+)
+
+// setupDivergedClone creates an origin repository and a clone whose local
+// commit and a later upstream commit both modify file.txt.
+func setupDivergedClone(t *testing.T, upstreamContent string) string {
+	t.Helper()
+	origin := t.TempDir()
+	runGit(t, origin, "init", "-b", "main")
+	runGit(t, origin, "config", "user.email", "test@test.com")
+	runGit(t, origin, "config", "user.name", "Test")
+	writeFile(t, filepath.Join(origin, "file.txt"), "base\n")
+	writeFile(t, filepath.Join(origin, "other.txt"), "other\n")
+	runGit(t, origin, "add", ".")
+	runGit(t, origin, "commit", "-m", "base")
+
+	clone := filepath.Join(t.TempDir(), "clone")
+	runGit(t, filepath.Dir(clone), "clone", origin, clone)
+	writeFile(t, filepath.Join(clone, "file.txt"), "bot change\n")
+	runGit(t, clone, "-c", "user.email=bot@test.com", "-c", "user.name=Bot", "commit", "-am", "bot change")
+
+	target := "file.txt"
+	if upstreamContent == "" {
+		target, upstreamContent = "other.txt", "human change\n"
+	}
+	writeFile(t, filepath.Join(origin, target), upstreamContent)
+	runGit(t, origin, "commit", "-am", "human change")
+	return clone
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestRebaseOntoUpdatedRemote(t *testing.T) {
+	clone := setupDivergedClone(t, "")
+	client := gitutil.Client{Commit: gitutil.CommitConfig{AuthorName: "Bot", AuthorEmail: "bot@test.com"}}
+	ctx := context.Background()
+
+	if err := client.Fetch(ctx, clone, "origin", "main"); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if err := client.Rebase(ctx, clone, "origin/main"); err != nil {
+		t.Fatalf("Rebase: %v", err)
+	}
+	if got := runGit(t, clone, "log", "--format=%s", "-n2"); got != "bot change\nhuman change" {
+		t.Fatalf("unexpected history after rebase: %q", got)
+	}
+}
+
+func TestRebaseConflictReturnsConflictError(t *testing.T) {
+	clone := setupDivergedClone(t, "human change\n")
+	client := gitutil.Client{Commit: gitutil.CommitConfig{AuthorName: "Bot", AuthorEmail: "bot@test.com"}}
+	ctx := context.Background()
+
+	if err := client.Fetch(ctx, clone, "origin", "main"); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	err := client.Rebase(ctx, clone, "origin/main")
+	var conflict *gitutil.ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected *gitutil.ConflictError, got %v", err)
+	}
+	if len(conflict.Files) != 1 || conflict.Files[0] != "file.txt" {
+		t.Fatalf("unexpected conflicted files: %v", conflict.Files)
+	}
+
+	// Resolve and continue.
+	writeFile(t, filepath.Join(clone, "file.txt"), "merged\n")
+	if err := client.MarkResolved(ctx, clone, conflict.Files); err != nil {
+		t.Fatalf("MarkResolved: %v", err)
+	}
+	if err := client.ContinueRebase(ctx, clone); err != nil {
+		t.Fatalf("ContinueRebase: %v", err)
+	}
+	if got := runGit(t, clone, "show", "HEAD:file.txt"); got != "merged" {
+		t.Fatalf("unexpected resolved content: %q", got)
+	}
+}
+
+func TestIsPushRejected(t *testing.T) {
+	rejected := &gitutil.GitError{Subcommand: "push", ExitCode: 1, Stderr: " ! [rejected]        main -> main (fetch first)"}
+	if !gitutil.IsPushRejected(rejected) {
+		t.Fatalf("expected rejected push to be detected")
+	}
+	other := &gitutil.GitError{Subcommand: "push", ExitCode: 128, Stderr: "fatal: Authentication failed"}
+	if gitutil.IsPushRejected(other) {
+		t.Fatalf("expected auth failure not to be treated as rejection")
+	}
+}
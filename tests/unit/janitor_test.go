@@ -0,0 +1,128 @@
+package unit_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git_sonic/internal/service/janitor"
+	"git_sonic/internal/service/workflow"
+)
+
+func makeWorkspace(t *testing.T, base, target string, created time.Time, status string) string {
+	t.Helper()
+	dir := filepath.Join(base, target+"-"+created.Format("20060102-150405"))
+	for _, sub := range []string{workflow.RepoSubdir, workflow.OutputsSubdir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, workflow.RepoSubdir, "main.go"), make([]byte, 1024), 0o644); err != nil {
+		t.Fatalf("write repo file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, workflow.OutputsSubdir, "prompt.md"), []byte("prompt"), 0o644); err != nil {
+		t.Fatalf("write output file: %v", err)
+	}
+	if status != "" {
+		data, _ := json.Marshal(workflow.RunStatus{Status: status, FinishedAt: created})
+		if err := os.WriteFile(filepath.Join(dir, workflow.OutputsSubdir, workflow.StatusFile), data, 0o644); err != nil {
+			t.Fatalf("write status: %v", err)
+		}
+	}
+	return dir
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestJanitorKeepsNewestPerTargetAndPreservesOutputs(t *testing.T) {
+	base := t.TempDir()
+	now := time.Now()
+	oldest := makeWorkspace(t, base, "issue-1", now.Add(-3*time.Hour), workflow.RunSucceeded)
+	middle := makeWorkspace(t, base, "issue-1", now.Add(-2*time.Hour), workflow.RunSucceeded)
+	newest := makeWorkspace(t, base, "issue-1", now.Add(-1*time.Hour), workflow.RunSucceeded)
+	other := makeWorkspace(t, base, "pr-7", now.Add(-5*time.Hour), workflow.RunSucceeded)
+
+	report, err := janitor.New(base, janitor.Policy{KeepPerTarget: 2, Outputs: janitor.OutputsKeep}).Sweep()
+	if err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	if report.Expired != 1 {
+		t.Fatalf("expected 1 expired workspace, got %d", report.Expired)
+	}
+	if exists(filepath.Join(oldest, workflow.RepoSubdir)) {
+		t.Fatalf("expected oldest repo clone to be removed")
+	}
+	if !exists(filepath.Join(oldest, workflow.OutputsSubdir, "prompt.md")) {
+		t.Fatalf("expected oldest outputs to be preserved")
+	}
+	for _, dir := range []string{middle, newest, other} {
+		if !exists(filepath.Join(dir, workflow.RepoSubdir)) {
+			t.Fatalf("expected %s to be kept", dir)
+		}
+	}
+}
+
+func TestJanitorKeepsFailedRunsLongerAndSkipsRunning(t *testing.T) {
+	base := t.TempDir()
+	now := time.Now()
+	succeeded := makeWorkspace(t, base, "issue-1", now.Add(-48*time.Hour), workflow.RunSucceeded)
+	failed := makeWorkspace(t, base, "issue-2", now.Add(-48*time.Hour), workflow.RunFailed)
+	running := makeWorkspace(t, base, "issue-3", now.Add(-2*time.Hour), "")
+
+	policy := janitor.Policy{MaxAge: 24 * time.Hour, FailedMaxAge: 72 * time.Hour, Outputs: janitor.OutputsArchive}
+	if _, err := janitor.New(base, policy).Sweep(); err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	if exists(succeeded) {
+		t.Fatalf("expected expired successful workspace to be removed")
+	}
+	if !exists(filepath.Join(base, janitor.ArchiveSubdir, filepath.Base(succeeded)+".tar.gz")) {
+		t.Fatalf("expected outputs archive for removed workspace")
+	}
+	if !exists(failed) {
+		t.Fatalf("expected failed workspace to be kept longer")
+	}
+	if !exists(running) {
+		t.Fatalf("expected running workspace to be kept")
+	}
+}
+
+func TestJanitorEnforcesMaxTotalBytes(t *testing.T) {
+	base := t.TempDir()
+	now := time.Now()
+	oldest := makeWorkspace(t, base, "issue-1", now.Add(-3*time.Hour), workflow.RunSucceeded)
+	newest := makeWorkspace(t, base, "issue-2", now.Add(-1*time.Hour), workflow.RunSucceeded)
+
+	policy := janitor.Policy{MaxTotalBytes: 1500, Outputs: janitor.OutputsDelete}
+	if _, err := janitor.New(base, policy).Sweep(); err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	if exists(oldest) {
+		t.Fatalf("expected oldest workspace to be removed to satisfy size limit")
+	}
+	if !exists(newest) {
+		t.Fatalf("expected newest workspace to be kept")
+	}
+}
+
+func TestJanitorDryRunReportsWithoutDeleting(t *testing.T) {
+	base := t.TempDir()
+	now := time.Now()
+	old := makeWorkspace(t, base, "issue-1", now.Add(-48*time.Hour), workflow.RunSucceeded)
+
+	report, err := janitor.New(base, janitor.Policy{MaxAge: time.Hour, Outputs: janitor.OutputsDelete, DryRun: true}).Sweep()
+	if err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	if !report.DryRun || report.Expired != 1 || report.FreedBytes == 0 {
+		t.Fatalf("expected the expired workspace to be reported, got %+v", report)
+	}
+	if !exists(filepath.Join(old, workflow.RepoSubdir, "main.go")) || !exists(filepath.Join(old, workflow.OutputsSubdir, "prompt.md")) {
+		t.Fatal("dry run must not delete anything")
+	}
+}
@@ -0,0 +1,167 @@
+package unit_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/internal/service/poller"
+	"git_sonic/internal/service/queue"
+	"git_sonic/pkg/github"
+)
+
+type pollGitHub struct {
+	queries []string
+	issues  []github.Issue
+	since   time.Time
+	at      time.Time
+}
+
+func (f *pollGitHub) GetRepo(ctx context.Context, owner, repo string) (github.Repo, error) {
+	return github.Repo{DefaultBranch: "main", CloneURL: "https://github.com/org/repo.git"}, nil
+}
+
+func (f *pollGitHub) GetIssue(ctx context.Context, owner, repo string, number int) (github.Issue, error) {
+	return github.Issue{Number: number, State: "open", Title: "t"}, nil
+}
+
+func (f *pollGitHub) SearchIssues(ctx context.Context, query string) ([]github.Issue, error) {
+	f.queries = append(f.queries, query)
+	return f.issues, nil
+}
+
+func (f *pollGitHub) ListRepoIssueComments(ctx context.Context, owner, repo string, since time.Time) ([]github.RepoComment, error) {
+	f.since = since
+	at := f.at
+	return []github.RepoComment{
+		{ID: 7, Number: 5, IsPullRequest: true, User: "dev", Body: "/ai-review", CreatedAt: at, UpdatedAt: at},
+		{ID: 8, Number: 6, User: "dev", Body: "edited", CreatedAt: at.Add(-48 * time.Hour), UpdatedAt: at},
+	}, nil
+}
+
+func (f *pollGitHub) ListPRReviewComments(ctx context.Context, owner, repo string, since time.Time) ([]github.RepoComment, error) {
+	at := f.at.Add(5 * time.Minute)
+	return []github.RepoComment{{ID: 9, Number: 5, IsPullRequest: true, User: "dev", Body: "/ai-optimize", CreatedAt: at, UpdatedAt: at}}, nil
+}
+
+func TestPollerEnqueuesOnceAndPersistsCursor(t *testing.T) {
+	at := time.Now().UTC().Add(-30 * time.Minute).Truncate(time.Second)
+	gh := &pollGitHub{at: at, issues: []github.Issue{{Number: 12, State: "open", Labels: []string{"ai-ready"}, UpdatedAt: at}}}
+	q := &recordingQueue{}
+	opts := poller.Options{
+		Repos:         []string{"org/repo"},
+		TriggerLabels: []string{"ai-ready"},
+		Lookback:      time.Hour,
+		CursorFile:    filepath.Join(t.TempDir(), "cursor.json"),
+	}
+	p, err := poller.New(gh, q, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	n, err := p.Poll(context.Background())
+	if err != nil || n != 3 {
+		t.Fatalf("expected 3 jobs, got %d (err=%v)", n, err)
+	}
+	if !strings.Contains(gh.queries[0], `repo:org/repo is:issue is:open label:"ai-ready" updated:>=`) {
+		t.Fatalf("unexpected search query: %s", gh.queries[0])
+	}
+	label, comment, review := q.jobs[0].Event, q.jobs[1].Event, q.jobs[2].Event
+	if label.Type != webhook.EventIssues || label.Action != "labeled" || label.Label != "ai-ready" || label.Repository.CloneURL == "" {
+		t.Fatalf("unexpected label event: %+v", label)
+	}
+	if comment.Type != webhook.EventIssueComment || !comment.Issue.IsPullRequest || comment.CommentBody != "/ai-review" {
+		t.Fatalf("unexpected comment event: %+v", comment)
+	}
+	if review.Type != webhook.EventPRComment || review.PullRequest.Number != 5 || review.Sender != "dev" {
+		t.Fatalf("unexpected review comment event: %+v", review)
+	}
+
+	// A restarted poller resumes from the cursor file and skips items already enqueued.
+	restarted, err := poller.New(gh, q, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, err := restarted.Poll(context.Background()); err != nil || n != 0 {
+		t.Fatalf("expected no new jobs after restart, got %d (err=%v)", n, err)
+	}
+	if !gh.since.Equal(at) {
+		t.Fatalf("expected comment cursor %s, got %s", at, gh.since)
+	}
+}
+
+type editedCommentGitHub struct {
+	pollGitHub
+	comments []github.RepoComment
+}
+
+func (f *editedCommentGitHub) ListRepoIssueComments(ctx context.Context, owner, repo string, since time.Time) ([]github.RepoComment, error) {
+	return f.comments, nil
+}
+
+func TestPollerComparesCreationTimesWithPollStart(t *testing.T) {
+	start := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	gh := &editedCommentGitHub{comments: []github.RepoComment{
+		{ID: 1, Number: 5, User: "dev", Body: "/ai-ask first", CreatedAt: start.Add(20 * time.Minute), UpdatedAt: start.Add(20 * time.Minute)},
+		// Created after the poll started but edited after the first comment.
+		{ID: 2, Number: 6, User: "dev", Body: "/ai-ask second", CreatedAt: start.Add(10 * time.Minute), UpdatedAt: start.Add(30 * time.Minute)},
+	}}
+	gh.at = start
+	q := &recordingQueue{}
+	p, err := poller.New(gh, q, poller.Options{
+		Repos:      []string{"org/repo"},
+		Lookback:   time.Hour,
+		CursorFile: filepath.Join(t.TempDir(), "cursor.json"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := p.Poll(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var bodies []string
+	for _, job := range q.jobs {
+		if job.Event.Type == webhook.EventIssueComment {
+			bodies = append(bodies, job.Event.CommentBody)
+		}
+	}
+	if strings.Join(bodies, ",") != "/ai-ask first,/ai-ask second" {
+		t.Fatalf("expected both new comments to be enqueued, got %v", bodies)
+	}
+}
+
+type fullQueue struct{}
+
+func (fullQueue) Enqueue(job queue.Job) error {
+	return errors.New("queue is full")
+}
+
+func TestPollerPassesEventsToCancelHookWhenQueueIsFull(t *testing.T) {
+	start := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	gh := &editedCommentGitHub{comments: []github.RepoComment{
+		{ID: 1, Number: 5, User: "dev", Body: "/ai-cancel", CreatedAt: start.Add(time.Minute), UpdatedAt: start.Add(time.Minute)},
+	}}
+	gh.at = start
+	p, err := poller.New(gh, fullQueue{}, poller.Options{
+		Repos:      []string{"org/repo"},
+		Lookback:   time.Hour,
+		CursorFile: filepath.Join(t.TempDir(), "cursor.json"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var cancels []string
+	p.WithCancelHook(func(event webhook.Event) { cancels = append(cancels, event.CommentBody) })
+
+	if _, err := p.Poll(context.Background()); err == nil {
+		t.Fatal("expected the full queue to be reported")
+	}
+	if len(cancels) == 0 || cancels[0] != "/ai-cancel" {
+		t.Fatalf("expected the cancel comment to reach the hook, got %v", cancels)
+	}
+}
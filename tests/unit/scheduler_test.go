@@ -0,0 +1,46 @@
+package unit_test
+
+import (
+	"testing"
+	"time"
+
+	"git_sonic/internal/config"
+	"git_sonic/internal/controller/webhook"
+	"git_sonic/internal/service/queue"
+	"git_sonic/internal/service/scheduler"
+)
+
+type recordingQueue struct {
+	jobs []queue.Job
+}
+
+func (q *recordingQueue) Enqueue(job queue.Job) error {
+	q.jobs = append(q.jobs, job)
+	return nil
+}
+
+func TestSchedulerTickEnqueuesDueTasks(t *testing.T) {
+	tasks := []config.ScheduledTask{
+		{Name: "fix-lint", Repo: "org/repo", Schedule: "0 3 * * *", Prompt: "Fix lint warnings"},
+		{Name: "bump-go", Repo: "org/repo", Schedule: "0 4 * * *", Prompt: "Bump Go patch version"},
+	}
+	q := &recordingQueue{}
+	sched, err := scheduler.New(tasks, q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := sched.Tick(time.Date(2026, 1, 2, 3, 0, 42, 0, time.UTC)); n != 1 {
+		t.Fatalf("expected one job, got %d", n)
+	}
+	event := q.jobs[0].Event
+	if event.Type != webhook.EventSchedule || event.Task != "fix-lint" || event.Repository.FullName != "org/repo" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if event.DeliveryID != "schedule-fix-lint-20260102-0300" {
+		t.Fatalf("unexpected delivery id: %s", event.DeliveryID)
+	}
+	if n := sched.Tick(time.Date(2026, 1, 2, 3, 1, 0, 0, time.UTC)); n != 0 {
+		t.Fatalf("expected no jobs, got %d", n)
+	}
+}